@@ -7,13 +7,21 @@ import (
 	"github.com/prometheus/common/model"
 )
 
+// Barchart renders the samples of a vector as horizontal bars. Native
+// histogram samples are drawn as their count of observations.
 func Barchart(vector model.Vector, width int) string {
 	barData := make([]barchart.BarData, 0, len(vector))
 	for i, sample := range vector {
+		label := fmt.Sprintf("%s (%d)", sample.Metric.String(), int(sample.Value))
+		value := float64(sample.Value)
+		if sample.Histogram != nil {
+			label = fmt.Sprintf("%s (histogram, count %s)", sample.Metric.String(), sample.Histogram.Count)
+			value = float64(sample.Histogram.Count)
+		}
 		barData = append(barData, barchart.BarData{
-			Label: fmt.Sprintf("%s (%d)", sample.Metric.String(), int(sample.Value)),
+			Label: label,
 			Values: []barchart.BarValue{
-				{Name: sample.Metric.String(), Value: float64(sample.Value), Style: SeriesStyle(i)},
+				{Name: sample.Metric.String(), Value: value, Style: SeriesStyle(i)},
 			},
 		})
 	}
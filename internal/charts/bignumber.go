@@ -0,0 +1,54 @@
+package charts
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// bigGlyphHeight is the number of terminal rows used by a big glyph.
+const bigGlyphHeight = 5
+
+// bigGlyphs maps characters to block glyphs; '#' marks a filled cell.
+var bigGlyphs = map[rune][bigGlyphHeight]string{
+	'0': {"###", "# #", "# #", "# #", "###"},
+	'1': {" # ", "## ", " # ", " # ", "###"},
+	'2': {"###", "  #", "###", "#  ", "###"},
+	'3': {"###", "  #", "###", "  #", "###"},
+	'4': {"# #", "# #", "###", "  #", "  #"},
+	'5': {"###", "#  ", "###", "  #", "###"},
+	'6': {"###", "#  ", "###", "# #", "###"},
+	'7': {"###", "  #", "  #", "  #", "  #"},
+	'8': {"###", "# #", "###", "# #", "###"},
+	'9': {"###", "# #", "###", "  #", "###"},
+	'.': {" ", " ", " ", " ", "#"},
+	'-': {"   ", "   ", "###", "   ", "   "},
+	'+': {"   ", " # ", "###", " # ", "   "},
+	'e': {"   ", "###", "###", "#  ", "###"},
+}
+
+// BigNumber renders a numeric string using large block glyphs.
+// Characters without a glyph (e.g. in NaN or Inf) are drawn at normal size
+// on the middle row.
+func BigNumber(s string, style lipgloss.Style) string {
+	var rows [bigGlyphHeight]strings.Builder
+	for i, r := range []rune(s) {
+		glyph, ok := bigGlyphs[r]
+		if !ok {
+			// Fall back to the plain character on the middle row
+			glyph = [bigGlyphHeight]string{" ", " ", string(r), " ", " "}
+		}
+		for row := range rows {
+			if i > 0 {
+				rows[row].WriteString(" ")
+			}
+			rows[row].WriteString(strings.ReplaceAll(glyph[row], "#", "█"))
+		}
+	}
+
+	lines := make([]string, 0, bigGlyphHeight)
+	for row := range rows {
+		lines = append(lines, style.Render(rows[row].String()))
+	}
+	return strings.Join(lines, "\n")
+}
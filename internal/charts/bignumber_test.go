@@ -0,0 +1,43 @@
+package charts
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestBigNumber(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"integer", "42"},
+		{"negative float", "-3.14"},
+		{"exponent", "1.5e+06"},
+		{"NaN", "NaN"},
+		{"infinity", "+Inf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BigNumber(tt.input, lipgloss.NewStyle())
+			lines := strings.Split(got, "\n")
+			if len(lines) != bigGlyphHeight {
+				t.Fatalf("BigNumber(%q) has %d lines, want %d", tt.input, len(lines), bigGlyphHeight)
+			}
+			for i, line := range lines {
+				if lipgloss.Width(line) != lipgloss.Width(lines[0]) {
+					t.Errorf("line %d width = %d, want %d", i, lipgloss.Width(line), lipgloss.Width(lines[0]))
+				}
+			}
+		})
+	}
+
+	t.Run("characters without glyph appear verbatim", func(t *testing.T) {
+		got := BigNumber("NaN", lipgloss.NewStyle())
+		if !strings.Contains(got, "N a N") {
+			t.Errorf("BigNumber(NaN) = %q, want it to contain %q", got, "N a N")
+		}
+	})
+}
@@ -23,6 +23,9 @@ var AxisColor = lipgloss.Color("#CCBB44") // Olive/Yellow - high visibility
 // LabelColor is the color used for chart labels.
 var LabelColor = lipgloss.Color("#66CCEE") // Cyan - good contrast
 
+// ExemplarColor is the color used for exemplar markers.
+var ExemplarColor = lipgloss.Color("#FFFFFF") // White - stands out from every series color
+
 // SeriesColor returns the color for a given series index, cycling through the palette.
 func SeriesColor(index int) lipgloss.Color {
 	return lipgloss.Color(SeriesPalette[index%len(SeriesPalette)])
@@ -0,0 +1,112 @@
+package charts
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/NimbleMarkets/ntcharts/barchart"
+	"github.com/prometheus/common/model"
+)
+
+// HistogramValue is the value native histogram samples are plotted as: a
+// value reduced from each sample, or a quantile.
+type HistogramValue struct {
+	Name string // Short name shown next to histogram series, e.g. "p99"
+	// Quantile is the quantile plotted when IsQuantile. The datasource computes
+	// quantiles with histogram_quantile, see WithQuantileSeries.
+	Quantile float64
+	quantile bool
+	reduce   func(*model.SampleHistogram) float64
+}
+
+// IsQuantile reports whether v is a quantile.
+func (v HistogramValue) IsQuantile() bool {
+	return v.quantile
+}
+
+// Of returns the value of h. Quantiles aren't computed from the sample, so
+// they are NaN.
+func (v HistogramValue) Of(h *model.SampleHistogram) float64 {
+	if v.quantile {
+		return math.NaN()
+	}
+	if v.reduce == nil {
+		return HistogramCount.reduce(h)
+	}
+	return v.reduce(h)
+}
+
+// HistogramCount plots the number of observations of a histogram.
+var HistogramCount = HistogramValue{Name: "count", reduce: func(h *model.SampleHistogram) float64 {
+	return float64(h.Count)
+}}
+
+// HistogramSum plots the sum of the observations of a histogram.
+var HistogramSum = HistogramValue{Name: "sum", reduce: func(h *model.SampleHistogram) float64 {
+	return float64(h.Sum)
+}}
+
+// HistogramQuantile plots the q-quantile of the observations of a histogram,
+// e.g. p99 for q = 0.99.
+func HistogramQuantile(q float64) HistogramValue {
+	// Rounded to hide float artifacts, e.g. p99.9 rather than p99.89999999999999
+	name := "p" + strconv.FormatFloat(math.Round(q*1e6)/1e4, 'f', -1, 64)
+	return HistogramValue{Name: name, Quantile: q, quantile: true}
+}
+
+// sortedBuckets returns the buckets of h ordered by their lower bound.
+func sortedBuckets(h *model.SampleHistogram) model.HistogramBuckets {
+	buckets := slices.Clone(h.Buckets)
+	slices.SortFunc(buckets, func(a, b *model.HistogramBucket) int {
+		switch {
+		case a.Lower < b.Lower:
+			return -1
+		case a.Lower > b.Lower:
+			return 1
+		}
+		return 0
+	})
+	return buckets
+}
+
+// bucketLabel formats the bounds of a bucket in interval notation, e.g.
+// (0.25,0.5] for a bucket including its upper bound only.
+func bucketLabel(b *model.HistogramBucket) string {
+	open, closed := "(", "]"
+	switch b.Boundaries {
+	case 1:
+		open, closed = "[", ")"
+	case 2:
+		open, closed = "(", ")"
+	case 3:
+		open, closed = "[", "]"
+	}
+	return fmt.Sprintf("%s%s,%s%s", open, b.Lower, b.Upper, closed)
+}
+
+// HistogramBuckets renders the bucket distribution of a native histogram as
+// a horizontal bar chart with one bar per populated bucket.
+func HistogramBuckets(h *model.SampleHistogram, width int, colorIndex int) string {
+	barData := make([]barchart.BarData, 0, len(h.Buckets))
+	for _, b := range sortedBuckets(h) {
+		if b.Count == 0 {
+			continue
+		}
+		barData = append(barData, barchart.BarData{
+			Label: fmt.Sprintf("%s (%s)", bucketLabel(b), b.Count),
+			Values: []barchart.BarValue{
+				{Name: bucketLabel(b), Value: float64(b.Count), Style: SeriesStyle(colorIndex)},
+			},
+		})
+	}
+	if len(barData) == 0 {
+		return labelStyle.Render("no observations")
+	}
+
+	bc := barchart.New(width, len(barData)*2, barchart.WithDataSet(barData), barchart.WithHorizontalBars())
+	bc.Draw()
+
+	return bc.View()
+}
@@ -0,0 +1,134 @@
+package charts
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/common/model"
+)
+
+// testHistogram has 10 observations: 2 in (0,1], 6 in (1,2] and 2 in (2,4].
+func testHistogram() *model.SampleHistogram {
+	return &model.SampleHistogram{
+		Count: 10,
+		Sum:   18,
+		Buckets: model.HistogramBuckets{
+			{Boundaries: 0, Lower: 2, Upper: 4, Count: 2},
+			{Boundaries: 0, Lower: 0, Upper: 1, Count: 2},
+			{Boundaries: 0, Lower: 1, Upper: 2, Count: 6},
+		},
+	}
+}
+
+func TestHistogramValues(t *testing.T) {
+	h := testHistogram()
+	tests := []struct {
+		value HistogramValue
+		name  string
+		want  float64
+	}{
+		{HistogramCount, "count", 10},
+		{HistogramSum, "sum", 18},
+	}
+
+	for _, tt := range tests {
+		if tt.value.Name != tt.name {
+			t.Errorf("Name = %q, want %q", tt.value.Name, tt.name)
+		}
+		if got := tt.value.Of(h); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if got := (HistogramValue{}).Of(h); got != 10 {
+		t.Errorf("zero HistogramValue = %v, want the count", got)
+	}
+
+	for q, name := range map[float64]string{0.5: "p50", 0.999: "p99.9", 1: "p100"} {
+		v := HistogramQuantile(q)
+		if v.Name != name || !v.IsQuantile() || v.Quantile != q {
+			t.Errorf("HistogramQuantile(%v) = %+v, want quantile %s", q, v, name)
+		}
+		// Quantiles are computed by the datasource, not from the sample
+		if got := v.Of(h); !math.IsNaN(got) {
+			t.Errorf("%s = %v, want NaN", name, got)
+		}
+	}
+	if HistogramCount.IsQuantile() || HistogramSum.IsQuantile() {
+		t.Error("count or sum is a quantile")
+	}
+}
+
+func TestHistogramBuckets(t *testing.T) {
+	chart := HistogramBuckets(testHistogram(), 80, 0)
+	for _, want := range []string{"(0,1] (2)", "(1,2] (6)", "(2,4] (2)"} {
+		if !strings.Contains(chart, want) {
+			t.Errorf("chart missing bucket %q", want)
+		}
+	}
+	if first, last := strings.Index(chart, "(0,1]"), strings.Index(chart, "(2,4]"); first > last {
+		t.Error("buckets not ordered by bound")
+	}
+
+	if chart := HistogramBuckets(&model.SampleHistogram{}, 80, 0); !strings.Contains(chart, "no observations") {
+		t.Errorf("empty histogram chart = %q", chart)
+	}
+}
+
+func TestTimeseriesHistograms(t *testing.T) {
+	matrix := model.Matrix{
+		&model.SampleStream{
+			Metric: model.Metric{"__name__": "http_request_duration_seconds"},
+			Histograms: []model.SampleHistogramPair{
+				{Timestamp: 1000, Histogram: testHistogram()},
+				{Timestamp: 2000, Histogram: &model.SampleHistogram{
+					Count:   4,
+					Sum:     12,
+					Buckets: model.HistogramBuckets{{Lower: 2, Upper: 4, Count: 4}},
+				}},
+			},
+		},
+		&model.SampleStream{
+			Metric: model.Metric{"__name__": "up"},
+			Values: []model.SamplePair{{Timestamp: 1000, Value: 1}, {Timestamp: 2000, Value: 1}},
+		},
+	}
+
+	// histogram_quantile drops the metric name
+	quantiles := model.Matrix{
+		&model.SampleStream{
+			Metric: model.Metric{},
+			Values: []model.SamplePair{{Timestamp: 1000, Value: 1.5}, {Timestamp: 2000, Value: 3}},
+		},
+	}
+	p50 := HistogramQuantile(0.5)
+
+	chart, legend := TimeseriesSplitWithSelection(matrix, 80, 0, 0, nil, WithHistogramValue(p50), WithQuantileSeries(quantiles))
+	if !legend[0].Histogram || legend[1].Histogram {
+		t.Errorf("legend = %+v, want only the first series marked as a histogram", legend)
+	}
+	if strings.TrimSpace(stripBraille(chart)) == strings.TrimSpace(chart) {
+		t.Error("histogram series not drawn")
+	}
+
+	t.Run("quantiles of other series aren't plotted", func(t *testing.T) {
+		other := model.Matrix{&model.SampleStream{
+			Metric: model.Metric{"job": "api"},
+			Values: quantiles[0].Values,
+		}}
+		chart, _ := TimeseriesSplitWithSelection(matrix, 80, 0, 0, nil, WithHistogramValue(p50), WithQuantileSeries(other))
+		if strings.TrimSpace(stripBraille(chart)) != strings.TrimSpace(chart) {
+			t.Error("histogram series drawn with the quantiles of another series")
+		}
+	})
+}
+
+// stripBraille removes the braille runes lines are drawn with.
+func stripBraille(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 0x2800 && r <= 0x28FF {
+			return -1
+		}
+		return r
+	}, s)
+}
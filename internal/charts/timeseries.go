@@ -2,7 +2,10 @@ package charts
 
 import (
 	"math"
+	"slices"
+	"time"
 
+	"github.com/NimbleMarkets/ntcharts/canvas"
 	"github.com/NimbleMarkets/ntcharts/canvas/runes"
 	"github.com/NimbleMarkets/ntcharts/linechart/timeserieslinechart"
 	"github.com/charmbracelet/lipgloss"
@@ -22,6 +25,100 @@ var labelStyle = lipgloss.NewStyle().
 type LegendEntry struct {
 	Metric     string
 	ColorIndex int
+	Histogram  bool // The series has native histogram samples
+}
+
+// exemplarRune marks an exemplar on a time series chart.
+const exemplarRune = '◆'
+
+var exemplarStyle = lipgloss.NewStyle().
+	Foreground(ExemplarColor).
+	Bold(true)
+
+// Exemplar is a single exemplar point overlaid on a time series chart.
+type Exemplar struct {
+	Time  time.Time
+	Value float64
+	// SeriesLabels are the labels of the series the exemplar was recorded for.
+	SeriesLabels model.LabelSet
+}
+
+// TimeseriesOption configures optional time series chart features.
+type TimeseriesOption func(*timeseriesOptions)
+
+type timeseriesOptions struct {
+	exemplars      []Exemplar
+	histogramValue HistogramValue
+	quantiles      model.Matrix
+}
+
+// WithExemplars overlays exemplar points on the chart. Exemplars outside
+// the time range of the visible series, or of series that aren't visible,
+// are not drawn.
+func WithExemplars(exemplars []Exemplar) TimeseriesOption {
+	return func(o *timeseriesOptions) {
+		o.exemplars = exemplars
+	}
+}
+
+// WithHistogramValue plots native histogram samples as the value v reduces
+// them to. Without it, their count is plotted.
+func WithHistogramValue(v HistogramValue) TimeseriesOption {
+	return func(o *timeseriesOptions) {
+		o.histogramValue = v
+	}
+}
+
+// WithQuantileSeries plots native histogram samples as the quantile plotted,
+// taken from quantiles, the result of histogram_quantile over the chart's
+// query. histogram_quantile drops the metric name, so a histogram series is
+// matched by its other labels. Samples without a quantile aren't plotted.
+func WithQuantileSeries(quantiles model.Matrix) TimeseriesOption {
+	return func(o *timeseriesOptions) {
+		o.quantiles = quantiles
+	}
+}
+
+// labelsWithoutName returns the fingerprint of the labels of metric other
+// than its name.
+func labelsWithoutName(metric model.Metric) model.Fingerprint {
+	labels := model.LabelSet(metric).Clone()
+	delete(labels, model.MetricNameLabel)
+	return labels.Fingerprint()
+}
+
+// seriesPoints returns the points plotted for a series: its float samples,
+// and its native histogram samples reduced to a value or replaced by their
+// value in quantile. NaN values, such as quantiles of empty histograms, are
+// not plotted.
+func seriesPoints(stream *model.SampleStream, histogramValue HistogramValue, quantile *model.SampleStream) []timeserieslinechart.TimePoint {
+	points := make([]timeserieslinechart.TimePoint, 0, len(stream.Values)+len(stream.Histograms))
+	for _, sample := range stream.Values {
+		points = append(points, timeserieslinechart.TimePoint{Time: sample.Timestamp.Time(), Value: float64(sample.Value)})
+	}
+	var quantiles map[model.Time]model.SampleValue
+	if histogramValue.IsQuantile() && quantile != nil {
+		quantiles = make(map[model.Time]model.SampleValue, len(quantile.Values))
+		for _, sample := range quantile.Values {
+			quantiles[sample.Timestamp] = sample.Value
+		}
+	}
+	for _, sample := range stream.Histograms {
+		value := histogramValue.Of(sample.Histogram)
+		if q, ok := quantiles[sample.Timestamp]; ok {
+			value = float64(q)
+		}
+		if !math.IsNaN(value) {
+			points = append(points, timeserieslinechart.TimePoint{Time: sample.Timestamp.Time(), Value: value})
+		}
+	}
+	if len(stream.Values) > 0 && len(stream.Histograms) > 0 {
+		// A series changing between float and histogram samples
+		slices.SortFunc(points, func(a, b timeserieslinechart.TimePoint) int {
+			return a.Time.Compare(b.Time)
+		})
+	}
+	return points
 }
 
 // TimeseriesSplit returns the chart and legend entries separately
@@ -40,25 +137,87 @@ func isSeriesVisible(i int, selectedIndex int, highlightedIndices map[int]bool)
 	return highlightedIndices[i]
 }
 
+// isExemplarVisible reports whether an exemplar belongs to a visible series.
+// Its series has all the labels of the result series, which may lack some
+// labels of the series the query selected, as aggregations drop them.
+func isExemplarVisible(e Exemplar, matrix model.Matrix, selectedIndex int, highlightedIndices map[int]bool) bool {
+	if selectedIndex == -1 {
+		return true // No selection: show all exemplars
+	}
+	for i, stream := range matrix {
+		if !isSeriesVisible(i, selectedIndex, highlightedIndices) {
+			continue
+		}
+		belongs := true
+		for name, value := range stream.Metric {
+			if e.SeriesLabels[name] != value {
+				belongs = false
+				break
+			}
+		}
+		if belongs {
+			return true
+		}
+	}
+	return false
+}
+
 // TimeseriesSplitWithSelection returns the chart and legend entries with a selected series highlighted.
 // height: explicit chart height; when <= 0, falls back to width/ChartHeightRatio.
 // selectedIndex: -1 means no selection, all series shown normally.
 // highlightedIndices: pinned series that remain visible alongside the selected series.
-func TimeseriesSplitWithSelection(matrix model.Matrix, width, height int, selectedIndex int, highlightedIndices map[int]bool) (chart string, legend []LegendEntry) {
+func TimeseriesSplitWithSelection(matrix model.Matrix, width, height int, selectedIndex int, highlightedIndices map[int]bool, opts ...TimeseriesOption) (chart string, legend []LegendEntry) {
+	var options timeseriesOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	quantiles := make(map[model.Fingerprint]*model.SampleStream, len(options.quantiles))
+	for _, stream := range options.quantiles {
+		quantiles[labelsWithoutName(stream.Metric)] = stream
+	}
+	points := make([][]timeserieslinechart.TimePoint, len(matrix))
+	for i, stream := range matrix {
+		var quantile *model.SampleStream
+		if len(stream.Histograms) > 0 {
+			quantile = quantiles[labelsWithoutName(stream.Metric)]
+		}
+		points[i] = seriesPoints(stream, options.histogramValue, quantile)
+	}
+
 	minYValue := model.SampleValue(math.MaxFloat64)
 	maxYValue := model.SampleValue(-math.MaxFloat64)
-	for i, stream := range matrix {
+	minTime, maxTime := model.Latest, model.Earliest
+	for i := range matrix {
 		if !isSeriesVisible(i, selectedIndex, highlightedIndices) {
 			continue
 		}
-		for _, sample := range stream.Values {
-			if sample.Value < minYValue {
-				minYValue = sample.Value
+		for _, point := range points[i] {
+			value := model.SampleValue(point.Value)
+			if value < minYValue {
+				minYValue = value
 			}
-			if sample.Value > maxYValue {
-				maxYValue = sample.Value
+			if value > maxYValue {
+				maxYValue = value
 			}
+			ts := model.TimeFromUnixNano(point.Time.UnixNano())
+			minTime = min(minTime, ts)
+			maxTime = max(maxTime, ts)
+		}
+	}
+
+	// Only exemplars of visible series within the plotted time range are
+	// drawn; they widen the Y range so drawing them doesn't rescale the chart
+	// after the lines are drawn.
+	exemplars := make([]Exemplar, 0, len(options.exemplars))
+	for _, e := range options.exemplars {
+		ts := model.TimeFromUnix(e.Time.Unix())
+		if ts.Before(minTime) || ts.After(maxTime) || !isExemplarVisible(e, matrix, selectedIndex, highlightedIndices) {
+			continue
 		}
+		exemplars = append(exemplars, e)
+		minYValue = min(minYValue, model.SampleValue(e.Value))
+		maxYValue = max(maxYValue, model.SampleValue(e.Value))
 	}
 
 	if height <= 0 {
@@ -84,6 +243,7 @@ func TimeseriesSplitWithSelection(matrix model.Matrix, width, height int, select
 		legendEntries = append(legendEntries, LegendEntry{
 			Metric:     stream.Metric.String(),
 			ColorIndex: i,
+			Histogram:  len(stream.Histograms) > 0,
 		})
 
 		// Skip the selected series here; it will be drawn last for layering emphasis
@@ -97,11 +257,7 @@ func TimeseriesSplitWithSelection(matrix model.Matrix, width, height int, select
 
 		style := SeriesStyle(i)
 		lc.SetDataSetStyle(stream.Metric.String(), style)
-		for _, sample := range stream.Values {
-			point := timeserieslinechart.TimePoint{
-				Time:  sample.Timestamp.Time(),
-				Value: float64(sample.Value),
-			}
+		for _, point := range points[i] {
 			lc.PushDataSet(stream.Metric.String(), point)
 		}
 	}
@@ -112,16 +268,16 @@ func TimeseriesSplitWithSelection(matrix model.Matrix, width, height int, select
 		style := SeriesStyle(selectedIndex)
 
 		lc.SetDataSetStyle(stream.Metric.String(), style)
-		for _, sample := range stream.Values {
-			point := timeserieslinechart.TimePoint{
-				Time:  sample.Timestamp.Time(),
-				Value: float64(sample.Value),
-			}
+		for _, point := range points[selectedIndex] {
 			lc.PushDataSet(stream.Metric.String(), point)
 		}
 	}
 
 	lc.DrawBrailleAll()
 
+	for _, e := range exemplars {
+		lc.DrawRuneWithStyle(canvas.Float64Point{X: float64(e.Time.Unix()), Y: e.Value}, exemplarRune, exemplarStyle)
+	}
+
 	return lc.View(), legendEntries
 }
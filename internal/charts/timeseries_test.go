@@ -1,6 +1,7 @@
 package charts
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -183,4 +184,43 @@ func TestTimeseriesSplitWithSelection(t *testing.T) {
 			t.Error("chart output is empty, want non-empty")
 		}
 	})
+
+	t.Run("exemplars within the time range are drawn", func(t *testing.T) {
+		exemplars := []Exemplar{{Time: now.Add(30 * time.Second).Time(), Value: 15}}
+		chart, _ := TimeseriesSplitWithSelection(matrix, 80, 0, -1, nil, WithExemplars(exemplars))
+
+		if !strings.ContainsRune(chart, exemplarRune) {
+			t.Error("chart does not contain an exemplar marker")
+		}
+	})
+
+	t.Run("exemplars of hidden series are skipped", func(t *testing.T) {
+		// An outlier of metric_b, recorded for one of the series it aggregates
+		exemplars := []Exemplar{{Time: now.Add(30 * time.Second).Time(), Value: 1000, SeriesLabels: model.LabelSet{"__name__": "metric_b", "instance": "a"}}}
+
+		chart, _ := TimeseriesSplitWithSelection(matrix, 80, 0, 0, nil, WithExemplars(exemplars))
+		if want, _ := TimeseriesSplitWithSelection(matrix, 80, 0, 0, nil); chart != want {
+			t.Errorf("chart of metric_a draws or scales to metric_b's exemplar:\n%s", chart)
+		}
+
+		for _, highlighted := range []map[int]bool{nil, {0: true}} {
+			chart, _ := TimeseriesSplitWithSelection(matrix, 80, 0, 1, highlighted, WithExemplars(exemplars))
+			if !strings.ContainsRune(chart, exemplarRune) {
+				t.Errorf("chart of metric_b with %v highlighted does not contain its exemplar", highlighted)
+			}
+		}
+		chart, _ = TimeseriesSplitWithSelection(matrix, 80, 0, 0, map[int]bool{1: true}, WithExemplars(exemplars))
+		if !strings.ContainsRune(chart, exemplarRune) {
+			t.Error("chart with metric_b highlighted does not contain its exemplar")
+		}
+	})
+
+	t.Run("exemplars outside the time range are skipped", func(t *testing.T) {
+		exemplars := []Exemplar{{Time: now.Add(time.Hour).Time(), Value: 15}}
+		chart, _ := TimeseriesSplitWithSelection(matrix, 80, 0, -1, nil, WithExemplars(exemplars))
+
+		if strings.ContainsRune(chart, exemplarRune) {
+			t.Error("chart contains an exemplar marker outside the plotted range")
+		}
+	})
 }
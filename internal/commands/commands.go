@@ -1,31 +1,338 @@
 package commands
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
 	"time"
 
+	"github.com/akasprzok/peat/internal/config"
+	"github.com/akasprzok/peat/internal/history"
 	"github.com/akasprzok/peat/internal/prometheus"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 // CLI represents the command-line interface for Peat.
 type CLI struct {
-	PrometheusURL string        `help:"URL of the Prometheus endpoint." short:"p" env:"PEAT_PROMETHEUS_URL" name:"prometheus-url"`
-	Timeout       time.Duration `help:"Timeout for Prometheus queries." short:"t" default:"60s"`
-	Range         time.Duration `name:"range" short:"r" help:"Initial range for range queries." default:"1h"`
-	Step          time.Duration `name:"step" short:"s" help:"Initial step interval for range queries." default:"1m"`
-	Limit         uint64        `name:"limit" short:"l" help:"Maximum number of series to return for series queries." default:"100"`
+	PrometheusURL   string        `help:"URL of the Prometheus endpoint." short:"p" env:"PEAT_PROMETHEUS_URL" name:"prometheus-url"`
+	Config          string        `name:"config" help:"Path to the config file (default: $XDG_CONFIG_HOME/peat/config.yaml)." env:"PEAT_CONFIG" type:"path"`
+	Datasource      string        `name:"datasource" short:"d" help:"Name of the configured datasource to connect to." env:"PEAT_DATASOURCE"`
+	Timeout         time.Duration `help:"Timeout for Prometheus queries." short:"t" default:"60s"`
+	Range           time.Duration `name:"range" short:"r" help:"Initial range for range queries." default:"1h"`
+	Step            time.Duration `name:"step" short:"s" help:"Initial step interval for range queries." default:"1m"`
+	AlertmanagerURL string        `name:"alertmanager-url" help:"URL of the Alertmanager receiving the datasource's alerts." env:"PEAT_ALERTMANAGER_URL"`
+	ExemplarURL     string        `name:"exemplar-url" help:"URL template for exemplar trace links, e.g. https://tempo.example.com/trace/{{.trace_id}}." env:"PEAT_EXEMPLAR_URL"`
+	Limit           uint64        `name:"limit" short:"l" help:"Maximum number of series to return for series queries, and of entries per TSDB status table." default:"100"`
+	Record          string        `name:"record" help:"Record the Prometheus API requests and responses of the session to a file." type:"path" xor:"traffic"`
+	Replay          string        `name:"replay" help:"Serve Prometheus API responses from a file written by --record, without contacting the server." type:"existingfile" xor:"traffic"`
+	Files           []string      `name:"file" short:"f" help:"Prometheus text or OpenMetrics file to query locally instead of a server. Repeatable." type:"existingfile" xor:"local"`
+	TSDBDir         string        `name:"tsdb-dir" help:"Prometheus data directory to open read-only and query locally instead of a server." type:"existingdir" xor:"local"`
+	RemoteReadURL   string        `name:"remote-read-url" help:"Remote-read endpoint whose raw series are queried locally instead of the query API. Label queries need a series selector." xor:"local"`
+
+	History     string `name:"history" help:"Path to the query history file (default: $XDG_STATE_HOME/peat/history.jsonl)." env:"PEAT_HISTORY" type:"path"`
+	HistorySize int    `name:"history-size" help:"Number of queries kept in the history. 0 disables it." env:"PEAT_HISTORY_SIZE" default:"1000"`
+
+	Library     string `name:"library" help:"Path to the file queries are saved to (default: $XDG_CONFIG_HOME/peat/queries.yaml)." env:"PEAT_LIBRARY" type:"path"`
+	TeamLibrary string `name:"team-library" help:"Path to a shared query file browsed alongside the personal one." env:"PEAT_TEAM_LIBRARY" type:"path"`
+
+	HistogramQuantiles []float64 `name:"histogram-quantiles" help:"Quantiles of native histograms that can be plotted in /query_range, cycled with H." default:"0.5,0.9,0.99"`
+
+	BasicAuthUser         string            `name:"basic-auth-user" help:"Username for HTTP basic authentication." env:"PEAT_BASIC_AUTH_USER" group:"Authentication"`
+	BasicAuthPassword     string            `name:"basic-auth-password" help:"Password for HTTP basic authentication." env:"PEAT_BASIC_AUTH_PASSWORD" group:"Authentication"`
+	BasicAuthPasswordFile string            `name:"basic-auth-password-file" help:"File containing the password for HTTP basic authentication." env:"PEAT_BASIC_AUTH_PASSWORD_FILE" type:"path" group:"Authentication"`
+	BearerToken           string            `name:"bearer-token" help:"Bearer token sent in the Authorization header." env:"PEAT_BEARER_TOKEN" group:"Authentication"`
+	BearerTokenFile       string            `name:"bearer-token-file" help:"File containing the bearer token. Re-read on every request." env:"PEAT_BEARER_TOKEN_FILE" type:"path" group:"Authentication"`
+	Headers               map[string]string `name:"header" short:"H" help:"Extra HTTP header sent with every request (e.g. X-Scope-OrgID=tenant). Repeatable." env:"PEAT_HEADERS" group:"Authentication"`
+
+	TLSCAFile             string `name:"tls-ca-file" help:"PEM CA bundle used to verify the server certificate." env:"PEAT_TLS_CA_FILE" type:"path" group:"TLS"`
+	TLSCertFile           string `name:"tls-cert-file" help:"PEM client certificate for mutual TLS." env:"PEAT_TLS_CERT_FILE" type:"path" group:"TLS"`
+	TLSKeyFile            string `name:"tls-key-file" help:"PEM client key for mutual TLS." env:"PEAT_TLS_KEY_FILE" type:"path" group:"TLS"`
+	TLSServerName         string `name:"tls-server-name" help:"Server name used to verify the server certificate." env:"PEAT_TLS_SERVER_NAME" group:"TLS"`
+	TLSInsecureSkipVerify bool   `name:"tls-insecure-skip-verify" help:"Disable server certificate verification." env:"PEAT_TLS_INSECURE_SKIP_VERIFY" group:"TLS"`
 }
 
+// cliDatasourceName is the name of the datasource defined by command-line flags.
+const cliDatasourceName = "cli"
+
+// replayURL is the endpoint of the datasource used to replay a recording
+// when no other datasource is configured. It is never contacted.
+const replayURL = "http://replay.invalid"
+
 // Run starts the interactive TUI.
 func (c *CLI) Run() error {
-	client, err := prometheus.NewClient(c.PrometheusURL)
+	cfg, err := c.loadConfig()
+	if err != nil {
+		return err
+	}
+	datasources, active, err := c.datasources(cfg)
+	if err != nil {
+		return err
+	}
+	for _, q := range c.HistogramQuantiles {
+		if q < 0 || q > 1 {
+			return fmt.Errorf("--histogram-quantiles: %g is not between 0 and 1", q)
+		}
+	}
+
+	var recorder *prometheus.Recorder
+	if c.Record != "" {
+		f, err := createRecording(c.Record)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		recorder = prometheus.NewRecorder(f)
+	}
+	var replay *prometheus.Replay
+	if c.Replay != "" {
+		if replay, err = loadReplay(c.Replay); err != nil {
+			return err
+		}
+	}
+	var clients []prometheus.Client
+	defer func() {
+		for _, client := range clients {
+			if closer, ok := client.(io.Closer); ok {
+				_ = closer.Close()
+			}
+		}
+	}()
+	connect := connector(recorder, replay, func(client prometheus.Client) {
+		clients = append(clients, client)
+	})
+
+	client, err := connect(datasources[active])
 	if err != nil {
 		return err
 	}
+	if err := checkConnection(context.Background(), client, datasources[active], c.Timeout); err != nil {
+		return err
+	}
 
-	model := NewTUIModel(client, c.Range, c.Step, c.Limit, c.Timeout)
+	hist, err := c.openHistory()
+	if err != nil {
+		return err
+	}
+	libraryPath, teamLibraryPath := c.libraries(cfg)
+
+	model := NewTUIModel(client, c.Range, c.Step, c.Limit, c.Timeout).
+		WithDatasources(datasources, active, connect).
+		WithHistogramQuantiles(c.HistogramQuantiles).
+		WithHistory(hist).
+		WithLibrary(libraryPath, teamLibraryPath)
 	p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithMouseCellMotion())
 
-	_, err = p.Run()
-	return err
+	if _, err = p.Run(); err != nil {
+		return err
+	}
+	if recorder != nil && recorder.Err() != nil {
+		return fmt.Errorf("writing recording: %w", recorder.Err())
+	}
+	if hist != nil && hist.Err() != nil {
+		return hist.Err()
+	}
+	return nil
+}
+
+// openHistory opens the query history, or returns nil when it is disabled.
+func (c *CLI) openHistory() (*history.Store, error) {
+	if c.HistorySize <= 0 {
+		return nil, nil
+	}
+	path := c.History
+	if path == "" {
+		defaultPath, err := config.DefaultHistoryPath()
+		if err != nil {
+			// Without a home directory there is nowhere to keep the history.
+			return nil, nil
+		}
+		path = defaultPath
+	}
+	return history.Open(path, c.HistorySize)
+}
+
+// connector returns a function creating the Prometheus client for a
+// datasource. The clients record their traffic to recorder, or answer from
+// replay instead of the network, when set. Every client created is passed to
+// opened, if not nil.
+func connector(recorder *prometheus.Recorder, replay *prometheus.Replay, opened func(prometheus.Client)) ConnectFunc {
+	return func(ds config.Datasource) (prometheus.Client, error) {
+		var client prometheus.Client
+		var err error
+		switch {
+		case len(ds.Files) > 0:
+			client, err = prometheus.NewFileClient(ds.Files)
+		case ds.TSDBDir != "":
+			client, err = prometheus.NewTSDBClient(ds.TSDBDir)
+		case ds.RemoteReadURL != "":
+			cfg := ds.ClientConfig()
+			cfg.URL = ds.RemoteReadURL
+			client, err = prometheus.NewRemoteReadClient(cfg)
+		default:
+			cfg := ds.ClientConfig()
+			cfg.Record = recorder
+			cfg.Replay = replay
+			client, err = prometheus.NewClient(cfg)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("datasource %s: %w", ds.Name, err)
+		}
+		if opened != nil {
+			opened(client)
+		}
+		return client, nil
+	}
+}
+
+// createRecording creates the file written by --record. Recordings hold full
+// query results, so only the user may read them.
+func createRecording(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("creating recording: %w", err)
+	}
+	return f, nil
+}
+
+// loadReplay reads a recording written with --record.
+func loadReplay(path string) (*prometheus.Replay, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening recording: %w", err)
+	}
+	defer f.Close()
+	return prometheus.LoadReplay(f)
+}
+
+// connectionCheckTimeout bounds the startup connectivity check, so an
+// unreachable endpoint fails quickly even with a long query timeout.
+const connectionCheckTimeout = 10 * time.Second
+
+// checkConnection fetches the server's build info to verify that the
+// datasource is reachable before the TUI starts. Thanos, Mimir and other
+// Prometheus-compatible servers may answer that they don't serve build info,
+// which proves them reachable; any other error fails, including rejected
+// credentials and answers that don't come from the Prometheus API.
+func checkConnection(ctx context.Context, client prometheus.Client, ds config.Datasource, timeout time.Duration) error {
+	if ds.Timeout > 0 {
+		timeout = ds.Timeout
+	}
+	_, err := client.Buildinfo(ctx, min(timeout, connectionCheckTimeout))
+	if err == nil || errors.Is(err, prometheus.ErrNoBuildinfo) {
+		return nil
+	}
+	return fmt.Errorf("datasource %s: cannot reach Prometheus at %s: %w", ds.Name, ds.Location(), err)
+}
+
+// datasources returns the available datasources and the index of the one to
+// connect to at startup. A datasource built from the command-line flags is
+// listed first when --prometheus-url or a local data flag is set.
+func (c *CLI) datasources(cfg config.Config) ([]config.Datasource, int, error) {
+	var datasources []config.Datasource
+	if c.PrometheusURL != "" || c.local() {
+		ds := c.cliDatasource()
+		if _, err := ds.ExemplarTemplate(); err != nil {
+			return nil, 0, err
+		}
+		datasources = append(datasources, ds)
+	}
+	datasources = append(datasources, cfg.Datasources...)
+
+	if len(datasources) == 0 && c.Replay != "" {
+		// A replay needs no endpoint
+		datasources = append(datasources, config.Datasource{Name: "replay", URL: replayURL})
+	}
+	if len(datasources) == 0 {
+		return nil, 0, errors.New("no Prometheus endpoint: set --prometheus-url, --file, --tsdb-dir or --remote-read-url, or configure a datasource")
+	}
+
+	name := c.Datasource
+	if name == "" && c.PrometheusURL == "" && !c.local() {
+		name = cfg.DefaultDatasource
+	}
+	if name == "" {
+		return datasources, 0, nil
+	}
+	for i, ds := range datasources {
+		if ds.Name == name {
+			return datasources, i, nil
+		}
+	}
+	return nil, 0, fmt.Errorf("unknown datasource %q", name)
+}
+
+// loadConfig reads the config file. A missing file at the default location is not an error.
+func (c *CLI) loadConfig() (config.Config, error) {
+	path := c.Config
+	if path == "" {
+		defaultPath, err := config.DefaultPath()
+		if err != nil {
+			// Without a home directory there is no default config file to read.
+			return config.Config{}, nil
+		}
+		cfg, err := config.Load(defaultPath)
+		if errors.Is(err, os.ErrNotExist) {
+			return config.Config{}, nil
+		}
+		return cfg, err
+	}
+	return config.Load(path)
+}
+
+// libraries returns the paths of the personal and team query libraries.
+// The flags take precedence over the config file.
+func (c *CLI) libraries(cfg config.Config) (string, string) {
+	path, teamPath := c.Library, c.TeamLibrary
+	if path == "" {
+		path = cfg.Library
+	}
+	if path == "" {
+		// Without a home directory, queries can't be saved.
+		path, _ = config.DefaultLibraryPath()
+	}
+	if teamPath == "" {
+		teamPath = cfg.TeamLibrary
+	}
+	return path, teamPath
+}
+
+// local reports whether the command-line flags select data queried locally
+// instead of a server's query API.
+func (c *CLI) local() bool {
+	return len(c.Files) > 0 || c.TSDBDir != "" || c.RemoteReadURL != ""
+}
+
+// cliDatasource builds a datasource from the command-line flags. Local data
+// takes precedence over --prometheus-url.
+func (c *CLI) cliDatasource() config.Datasource {
+	url := c.PrometheusURL
+	if c.local() {
+		url = ""
+	}
+	return config.Datasource{
+		Name:            cliDatasourceName,
+		URL:             url,
+		Files:           c.Files,
+		TSDBDir:         c.TSDBDir,
+		RemoteReadURL:   c.RemoteReadURL,
+		ExemplarURL:     c.ExemplarURL,
+		AlertmanagerURL: c.AlertmanagerURL,
+		Auth: config.AuthConfig{
+			BasicAuthUser:         c.BasicAuthUser,
+			BasicAuthPassword:     c.BasicAuthPassword,
+			BasicAuthPasswordFile: c.BasicAuthPasswordFile,
+			BearerToken:           c.BearerToken,
+			BearerTokenFile:       c.BearerTokenFile,
+			Headers:               c.Headers,
+		},
+		TLS: config.TLSConfig{
+			CAFile:             c.TLSCAFile,
+			CertFile:           c.TLSCertFile,
+			KeyFile:            c.TLSKeyFile,
+			ServerName:         c.TLSServerName,
+			InsecureSkipVerify: c.TLSInsecureSkipVerify,
+		},
+	}
 }
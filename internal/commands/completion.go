@@ -0,0 +1,468 @@
+package commands
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// CompletionMaxRows is the maximum number of suggestions shown at once.
+const CompletionMaxRows = 8
+
+// completionRetryDelay is how long after a failed fetch the names of a list
+// are fetched again.
+const completionRetryDelay = 30 * time.Second
+
+// completionKind is what the word at the cursor of the query input names.
+type completionKind int
+
+const (
+	completeNone       completionKind = iota
+	completeExpression                // Metric, function or aggregation name
+	completeLabelName                 // Label name inside {} or by()
+	completeLabelValue                // Label value after =, !=, =~ or !~
+)
+
+// completionContext describes the word being completed at the cursor.
+type completionContext struct {
+	kind   completionKind
+	start  int    // Byte offset of the word, replaced when accepting a suggestion
+	prefix string // Part of the word typed so far, without any opening quote
+	label  string // Label whose values are completed
+	quote  byte   // Opening quote of a label value, 0 if none was typed yet
+}
+
+// completion is a suggestion for the word at the cursor.
+type completion struct {
+	text string
+	kind string // Shown next to the suggestion, e.g. "function"
+}
+
+// completionCache holds the names suggestions are made from, fetched once
+// per datasource.
+type completionCache struct {
+	labelNames  []string                     // nil until fetched
+	labelValues map[string][]string          // By label name; __name__ holds the metric names
+	fetching    map[string]bool              // Lists being fetched, by label name or "" for the label names
+	failures    map[string]completionFailure // Lists whose last fetch failed
+}
+
+// completionFailure is a failed fetch of the names of a list.
+type completionFailure struct {
+	err     error
+	retryAt time.Time // When the list may be fetched again
+}
+
+func newCompletionCache() *completionCache {
+	return &completionCache{
+		labelValues: make(map[string][]string),
+		fetching:    make(map[string]bool),
+		failures:    make(map[string]completionFailure),
+	}
+}
+
+// completesPromQL reports whether the input of mode is PromQL or series
+// selectors, rather than a filter.
+func completesPromQL(mode QueryMode) bool {
+	return mode == ModeInstant || mode == ModeRange || mode == ModeSeries || mode == ModeLabels
+}
+
+// groupingKeywords open a list of label names, e.g. by (job).
+var groupingKeywords = map[parser.ItemType]bool{
+	parser.BY:          true,
+	parser.WITHOUT:     true,
+	parser.ON:          true,
+	parser.IGNORING:    true,
+	parser.GROUP_LEFT:  true,
+	parser.GROUP_RIGHT: true,
+}
+
+// matchOperators compare a label to a value inside braces.
+var matchOperators = map[parser.ItemType]bool{
+	parser.EQL:       true,
+	parser.NEQ:       true,
+	parser.EQL_REGEX: true,
+	parser.NEQ_REGEX: true,
+}
+
+// completionContextAt works out what the word ending at the cursor of input
+// names, by lexing the input up to the word.
+func completionContextAt(input string, cursor int) completionContext {
+	text := input[:min(cursor, len(input))]
+
+	start := len(text)
+	for start > 0 && isNameChar(text[start-1]) {
+		start--
+	}
+	prefix := text[start:]
+	if prefix != "" && prefix[0] >= '0' && prefix[0] <= '9' {
+		// A number or a duration
+		return completionContext{}
+	}
+
+	var (
+		items []parser.Item
+		open  []parser.ItemType // Enclosing braces and parentheses, grouping ones as BY
+	)
+	lexer := parser.Lex(text[:start])
+	for {
+		var item parser.Item
+		lexer.NextItem(&item)
+		if item.Typ == parser.ERROR && strings.HasPrefix(item.Val, "unterminated") {
+			// A label value being typed lexes as an unterminated string
+			if len(items) > 0 && matchOperators[items[len(items)-1].Typ] && len(open) > 0 && open[len(open)-1] == parser.LEFT_BRACE {
+				return completionContext{
+					kind:   completeLabelValue,
+					start:  int(item.Pos),
+					prefix: text[item.Pos+1:],
+					label:  matchedLabel(items),
+					quote:  text[item.Pos],
+				}
+			}
+			return completionContext{}
+		}
+		if item.Typ == parser.ERROR || item.Typ == parser.EOF {
+			// Unclosed braces and parentheses are expected while typing
+			break
+		}
+
+		switch item.Typ {
+		case parser.LEFT_BRACE:
+			open = append(open, parser.LEFT_BRACE)
+		case parser.LEFT_PAREN:
+			if len(items) > 0 && groupingKeywords[items[len(items)-1].Typ] {
+				open = append(open, parser.BY)
+			} else {
+				open = append(open, parser.LEFT_PAREN)
+			}
+		case parser.RIGHT_BRACE, parser.RIGHT_PAREN:
+			if len(open) > 0 {
+				open = open[:len(open)-1]
+			}
+		}
+		items = append(items, item)
+	}
+
+	var last parser.ItemType
+	if len(items) > 0 {
+		last = items[len(items)-1].Typ
+	}
+	ctx := completionContext{start: start, prefix: prefix}
+
+	switch {
+	case len(open) > 0 && open[len(open)-1] == parser.LEFT_BRACE:
+		switch {
+		case last == parser.LEFT_BRACE || last == parser.COMMA:
+			ctx.kind = completeLabelName
+		case matchOperators[last]:
+			ctx.kind = completeLabelValue
+			ctx.label = matchedLabel(items)
+		}
+	case len(open) > 0 && open[len(open)-1] == parser.BY:
+		if last == parser.LEFT_PAREN || last == parser.COMMA {
+			ctx.kind = completeLabelName
+		}
+	case prefix != "" && !endsOperand(last):
+		ctx.kind = completeExpression
+	}
+	return ctx
+}
+
+// matchedLabel returns the label of the matcher whose operator is the last of items.
+func matchedLabel(items []parser.Item) string {
+	if len(items) < 2 {
+		return ""
+	}
+	return items[len(items)-2].Val
+}
+
+// endsOperand reports whether an item of type t ends an operand, so the next
+// word is an operator or keyword like "and" or "offset" rather than a name.
+func endsOperand(t parser.ItemType) bool {
+	switch t {
+	case parser.IDENTIFIER, parser.METRIC_IDENTIFIER, parser.NUMBER, parser.STRING, parser.DURATION,
+		parser.RIGHT_PAREN, parser.RIGHT_BRACE, parser.RIGHT_BRACKET:
+		return true
+	}
+	return false
+}
+
+// isNameChar reports whether c can be part of a metric or label name.
+func isNameChar(c byte) bool {
+	return c == '_' || c == ':' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// promqlFunctions returns the names of the PromQL functions and aggregations
+// that can be used without enabling experimental features.
+func promqlFunctions() []completion {
+	var functions []completion
+	for name, fn := range parser.Functions {
+		if !fn.Experimental {
+			functions = append(functions, completion{text: name, kind: "function"})
+		}
+	}
+	for typ, name := range parser.ItemTypeStr {
+		if typ.IsAggregator() && !typ.IsExperimentalAggregator() {
+			functions = append(functions, completion{text: name, kind: "aggregation"})
+		}
+	}
+	return functions
+}
+
+// completionList returns the name of the cached list suggestions for ctx
+// are made from: a label name for its values, or "" for the label names.
+func (ctx completionContext) completionList() string {
+	switch ctx.kind {
+	case completeExpression:
+		return model.MetricNameLabel
+	case completeLabelValue:
+		return ctx.label
+	}
+	return ""
+}
+
+// activeCompletionCache returns the suggestion cache of the active datasource.
+func (m TUIModel) activeCompletionCache() *completionCache {
+	cache, ok := m.completionCaches[m.activeDatasource]
+	if !ok {
+		cache = newCompletionCache()
+		m.completionCaches[m.activeDatasource] = cache
+	}
+	return cache
+}
+
+// updateCompletions refreshes the suggestions for the word at the cursor,
+// fetching the names they are made from if they aren't cached yet. Names
+// whose fetch failed are fetched again after completionRetryDelay.
+func (m TUIModel) updateCompletions() (TUIModel, tea.Cmd) {
+	m.completions = nil
+	m.completionErr = nil
+	m.completionCursor = 0
+	if !m.insertMode || !completesPromQL(m.mode) {
+		return m, nil
+	}
+
+	value := m.queryInput.Value()
+	ctx := completionContextAt(value, byteOffset(value, m.queryInput.Position()))
+	m.completionCtx = ctx
+	if ctx.kind == completeNone || (ctx.kind == completeLabelValue && ctx.label == "") {
+		return m, nil
+	}
+
+	cache := m.activeCompletionCache()
+	list := ctx.completionList()
+	var names []string
+	var cached bool
+	if ctx.kind == completeLabelName {
+		names, cached = cache.labelNames, cache.labelNames != nil
+	} else {
+		names, cached = cache.labelValues[list]
+	}
+
+	var cmd tea.Cmd
+	failure, failed := cache.failures[list]
+	if !cached && !cache.fetching[list] && (!failed || !time.Now().Before(failure.retryAt)) {
+		cache.fetching[list] = true
+		cmd = m.fetchCompletions(list)
+	}
+	if failed {
+		m.completionErr = failure.err
+	}
+
+	kind := map[completionKind]string{
+		completeExpression: "metric",
+		completeLabelName:  "label",
+		completeLabelValue: "value",
+	}[ctx.kind]
+	candidates := make([]completion, 0, len(names))
+	for _, name := range names {
+		candidates = append(candidates, completion{text: name, kind: kind})
+	}
+	if ctx.kind == completeExpression {
+		candidates = append(candidates, promqlFunctions()...)
+	}
+
+	for _, c := range candidates {
+		if strings.HasPrefix(strings.ToLower(c.text), strings.ToLower(ctx.prefix)) && c.text != ctx.prefix {
+			m.completions = append(m.completions, c)
+		}
+	}
+	slices.SortFunc(m.completions, func(a, b completion) int {
+		return strings.Compare(a.text, b.text)
+	})
+	return m, cmd
+}
+
+// fetchCompletions fetches the names of the list "" (label names) or the
+// values of the label list, seen within the range of the active datasource.
+func (m TUIModel) fetchCompletions(list string) tea.Cmd {
+	client := m.promClient
+	datasource := m.activeDatasource
+	return func() tea.Msg {
+		end := time.Now()
+		start := end.Add(-m.rangeValue)
+		var names []string
+		var err error
+		if list == "" {
+			names, _, err = client.LabelNames(context.Background(), nil, start, end, m.timeout)
+		} else {
+			names, _, err = client.LabelValues(context.Background(), list, nil, start, end, m.timeout)
+		}
+		return tuiCompletionMsg{datasource: datasource, list: list, names: names, err: err}
+	}
+}
+
+// handleCompletionResult caches the fetched names and refreshes the
+// suggestions. Failures are recorded instead, so the fetch is retried after
+// completionRetryDelay rather than on every key, and shown in the popup.
+func (m TUIModel) handleCompletionResult(msg tuiCompletionMsg) (tea.Model, tea.Cmd) {
+	cache, ok := m.completionCaches[msg.datasource]
+	if !ok {
+		return m, nil
+	}
+	delete(cache.fetching, msg.list)
+	if msg.err != nil {
+		cache.failures[msg.list] = completionFailure{err: msg.err, retryAt: time.Now().Add(completionRetryDelay)}
+	} else {
+		delete(cache.failures, msg.list)
+		names := msg.names
+		if names == nil {
+			names = []string{}
+		}
+		if msg.list == "" {
+			cache.labelNames = names
+		} else {
+			cache.labelValues[msg.list] = names
+		}
+	}
+
+	if msg.datasource != m.activeDatasource {
+		return m, nil
+	}
+	m, cmd := m.updateCompletions()
+	return m, cmd
+}
+
+// handleCompletionKey handles the keys navigating and accepting the
+// suggestions while they are shown. It reports whether key was handled.
+func (m TUIModel) handleCompletionKey(msg tea.KeyMsg) (TUIModel, bool) {
+	if len(m.completions) == 0 {
+		return m, false
+	}
+	switch msg.String() {
+	case "down", "ctrl+n":
+		m.completionCursor = (m.completionCursor + 1) % len(m.completions)
+	case "up", "ctrl+p":
+		m.completionCursor = (m.completionCursor - 1 + len(m.completions)) % len(m.completions)
+	case "tab":
+		m = m.acceptCompletion()
+	case "esc":
+		m.completions = nil
+	default:
+		return m, false
+	}
+	return m, true
+}
+
+// acceptCompletion replaces the word at the cursor with the selected
+// suggestion. Functions and aggregations get an opening parenthesis, label
+// values are quoted.
+func (m TUIModel) acceptCompletion() TUIModel {
+	c := m.completions[m.completionCursor]
+	ctx := m.completionCtx
+	value := m.queryInput.Value()
+	end := byteOffset(value, m.queryInput.Position())
+
+	insert := c.text
+	switch {
+	case c.kind == "function" || c.kind == "aggregation":
+		insert += "("
+	case ctx.kind == completeLabelValue:
+		insert = strconv.Quote(c.text)
+		if ctx.quote != 0 && end < len(value) && value[end] == ctx.quote {
+			// Replace the closing quote typed before the value
+			end++
+		}
+	}
+
+	m.queryInput.SetValue(value[:ctx.start] + insert + value[end:])
+	m.queryInput.SetCursor(utf8.RuneCountInString(value[:ctx.start] + insert))
+	m.completions = nil
+	m.completionCursor = 0
+	return m
+}
+
+// renderCompletions renders the suggestions as a popup listing at most
+// CompletionMaxRows of them around the selected one.
+func (m TUIModel) renderCompletions() string {
+	showErr := m.insertMode && m.completionErr != nil
+	if len(m.completions) == 0 && !showErr {
+		return ""
+	}
+
+	itemStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("252"))
+	selectedStyle := itemStyle.
+		Bold(true).
+		Background(lipgloss.Color("63")).
+		Foreground(lipgloss.Color("231"))
+	kindStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+
+	first := max(0, min(m.completionCursor-CompletionMaxRows/2, len(m.completions)-CompletionMaxRows))
+	visible := m.completions[first:min(first+CompletionMaxRows, len(m.completions))]
+
+	width := 0
+	for _, c := range visible {
+		width = max(width, len(c.text))
+	}
+	width = min(width, m.getTerminalWidth()/2)
+
+	lines := make([]string, 0, len(visible)+2)
+	for i, c := range visible {
+		style := itemStyle
+		if first+i == m.completionCursor {
+			style = selectedStyle
+		}
+		text := ansi.Truncate(c.text, width, "…")
+		lines = append(lines, style.Render(text+strings.Repeat(" ", width-ansi.StringWidth(text)))+" "+kindStyle.Render(c.kind))
+	}
+	if len(m.completions) > CompletionMaxRows {
+		lines = append(lines, kindStyle.Render(strconv.Itoa(m.completionCursor+1)+"/"+strconv.Itoa(len(m.completions))))
+	}
+	if showErr {
+		// Names that couldn't be fetched are missing from the suggestions
+		text := "Suggestions incomplete: " + m.completionErr.Error()
+		lines = append(lines, ErrorStyle.Render(ansi.Truncate(text, m.getTerminalWidth()/2, "…")))
+	}
+
+	popupStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("205")).
+		Background(lipgloss.Color("235")).
+		Padding(0, 1)
+	return popupStyle.Render(strings.Join(lines, "\n"))
+}
+
+// overlayLines draws popup over the top left of content, starting at
+// column left and keeping the rest of the covered lines.
+func overlayLines(content, popup string, left int) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range strings.Split(popup, "\n") {
+		if i >= len(lines) {
+			lines = append(lines, "")
+		}
+		base := lines[i]
+		width := ansi.StringWidth(line)
+		padding := strings.Repeat(" ", max(0, left-ansi.StringWidth(base)))
+		lines[i] = ansi.Truncate(base, left, "") + padding + line + ansi.TruncateLeft(base, left+width, "")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,193 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akasprzok/peat/internal/prometheus"
+	"github.com/charmbracelet/bubbles/cursor"
+	tea "github.com/charmbracelet/bubbletea"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+)
+
+func TestCompletionContextAt(t *testing.T) {
+	tests := []struct {
+		input  string
+		kind   completionKind
+		prefix string
+		label  string
+	}{
+		{"", completeNone, "", ""},
+		{"up", completeExpression, "up", ""},
+		{"sum(rate(http_req", completeExpression, "http_req", ""},
+		{"up and", completeNone, "and", ""},
+		{"up{", completeLabelName, "", ""},
+		{`up{job="app",inst`, completeLabelName, "inst", ""},
+		{`up{job=`, completeLabelValue, "", "job"},
+		{`up{job=~"ap`, completeLabelValue, "ap", "job"},
+		{`up{job!="a-b`, completeLabelValue, "a-b", "job"},
+		{`up{job="app"} + on(`, completeLabelName, "", ""},
+		{"sum by (job, inst", completeLabelName, "inst", ""},
+		{"sum by (job) (ra", completeExpression, "ra", ""},
+		{`label_replace(up, "ds`, completeNone, "", ""},
+		{"rate(up[5m", completeNone, "", ""},
+	}
+
+	for _, tt := range tests {
+		ctx := completionContextAt(tt.input, len(tt.input))
+		if ctx.kind != tt.kind || (tt.kind != completeNone && (ctx.prefix != tt.prefix || ctx.label != tt.label)) {
+			t.Errorf("completionContextAt(%q) = %+v, want kind %d, prefix %q and label %q",
+				tt.input, ctx, tt.kind, tt.prefix, tt.label)
+		}
+	}
+
+	if ctx := completionContextAt("up{job} + rate", 3); ctx.kind != completeLabelName {
+		t.Errorf("context before the end of the input = %+v, want a label name", ctx)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	fetches := 0
+	mockClient := &prometheus.MockClient{
+		LabelNamesFunc: func(_ context.Context, _ []string, _, _ time.Time, _ time.Duration) ([]string, v1.Warnings, error) {
+			fetches++
+			return []string{"__name__", "instance", "job"}, nil, nil
+		},
+		LabelValuesFunc: func(_ context.Context, label string, _ []string, _, _ time.Time, _ time.Duration) ([]string, v1.Warnings, error) {
+			fetches++
+			if label == "__name__" {
+				return []string{"node_cpu_seconds_total", "rate_limited_total", "up"}, nil, nil
+			}
+			return []string{"api", `app"1`}, nil, nil
+		},
+	}
+
+	// typeKeys sends keys to the model, running the commands they return
+	typeKeys := func(m TUIModel, keys ...tea.KeyMsg) TUIModel {
+		for _, key := range keys {
+			updated, cmd := m.Update(key)
+			m = updated.(TUIModel)
+			for _, msg := range runCmd(cmd) {
+				if msg, ok := msg.(tuiCompletionMsg); ok {
+					updated, _ = m.Update(msg)
+					m = updated.(TUIModel)
+				}
+			}
+		}
+		return m
+	}
+
+	m := NewTUIModel(mockClient, time.Hour, 15*time.Second, 100, 60*time.Second)
+	m.width = 120
+	m.height = 40
+	m.queryInput.Cursor.SetMode(cursor.CursorStatic) // No blink commands to wait for
+
+	t.Run("metrics functions and aggregations", func(t *testing.T) {
+		m := typeKeys(m, runes("rat"))
+		var got []string
+		for _, c := range m.completions {
+			got = append(got, c.text+":"+c.kind)
+		}
+		if want := "rate:function,rate_limited_total:metric"; !strings.HasPrefix(strings.Join(got, ","), want) {
+			t.Errorf("completions = %v, want them to start with %s", got, want)
+		}
+		if view := m.View(); !strings.Contains(view, "rate_limited_total") || !strings.Contains(view, "tab: complete") {
+			t.Error("suggestions popup not shown")
+		}
+
+		m = typeKeys(m, tea.KeyMsg{Type: tea.KeyTab})
+		if got := m.queryInput.Value(); got != "rate(" {
+			t.Errorf("input = %q, want rate(", got)
+		}
+		if m.mode != ModeInstant {
+			t.Error("tab switched modes instead of completing")
+		}
+	})
+
+	t.Run("label values", func(t *testing.T) {
+		m := typeKeys(m, runes(`up{j`))
+		if len(m.completions) != 1 || m.completions[0].text != "job" {
+			t.Fatalf("completions = %v, want job", m.completions)
+		}
+		m = typeKeys(m, tea.KeyMsg{Type: tea.KeyTab}, runes(`="`))
+		m = typeKeys(m, tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyTab})
+		if got := m.queryInput.Value(); got != `up{job="app\"1"` {
+			t.Errorf("input = %q, want the quoted value", got)
+		}
+	})
+
+	t.Run("cached per datasource", func(t *testing.T) {
+		before := fetches
+		m := typeKeys(m, runes("u"))
+		if fetches != before || len(m.completions) == 0 {
+			t.Errorf("fetched %d lists again", fetches-before)
+		}
+
+		m = typeKeys(m, tea.KeyMsg{Type: tea.KeyEsc})
+		if len(m.completions) != 0 || !m.insertMode {
+			t.Error("esc didn't just dismiss the suggestions")
+		}
+
+		m.activeDatasource = 1
+		_ = typeKeys(m, runes("p"))
+		if fetches != before+1 {
+			t.Errorf("fetched %d lists for another datasource, want the metric names", fetches-before)
+		}
+	})
+
+	t.Run("failed fetch", func(t *testing.T) {
+		var failing error = errors.New("connection refused")
+		fetches := 0
+		mockClient := &prometheus.MockClient{
+			LabelValuesFunc: func(_ context.Context, _ string, _ []string, _, _ time.Time, _ time.Duration) ([]string, v1.Warnings, error) {
+				fetches++
+				if failing != nil {
+					return nil, nil, failing
+				}
+				return []string{"up"}, nil, nil
+			},
+		}
+		m := NewTUIModel(mockClient, time.Hour, 15*time.Second, 100, 60*time.Second)
+		m.width = 120
+		m.height = 40
+		m.queryInput.Cursor.SetMode(cursor.CursorStatic)
+
+		m = typeKeys(m, runes("u"))
+		if m.completionErr == nil || !strings.Contains(m.View(), "connection refused") {
+			t.Errorf("fetch error %v not shown in the popup", m.completionErr)
+		}
+		if m = typeKeys(m, runes("p")); fetches != 1 || m.completionErr == nil {
+			t.Errorf("fetched %d times before the retry delay, error %v", fetches, m.completionErr)
+		}
+
+		// Retried once the delay is over
+		failing = nil
+		cache := m.activeCompletionCache()
+		cache.failures["__name__"] = completionFailure{err: cache.failures["__name__"].err, retryAt: time.Now()}
+		m = typeKeys(m, tea.KeyMsg{Type: tea.KeyBackspace})
+		up := slices.ContainsFunc(m.completions, func(c completion) bool { return c.text == "up" })
+		if fetches != 2 || m.completionErr != nil || !up {
+			t.Errorf("after retrying: %d fetches, error %v, completions %v", fetches, m.completionErr, m.completions)
+		}
+	})
+}
+
+// runCmd runs cmd and any batched commands, returning their messages.
+func runCmd(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		var msgs []tea.Msg
+		for _, cmd := range batch {
+			msgs = append(msgs, runCmd(cmd)...)
+		}
+		return msgs
+	}
+	return []tea.Msg{msg}
+}
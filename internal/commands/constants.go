@@ -18,9 +18,13 @@ const (
 	// LegendMaxRows is the maximum number of visible rows in the legend table.
 	LegendMaxRows = 5
 
-	// ChromeHeightExpanded is lines consumed by non-results chrome (input expanded).
+	// ChromeHeightExpanded is lines consumed by non-results chrome (input expanded to one line).
+	// Each further line of the query editor takes one more.
 	ChromeHeightExpanded = 12
 
+	// QueryEditorMaxLines is the maximum number of lines the query editor grows to.
+	QueryEditorMaxLines = 10
+
 	// ChromeHeightCollapsed is lines consumed by non-results chrome (input collapsed).
 	ChromeHeightCollapsed = 10
 
@@ -35,4 +39,19 @@ const (
 
 	// ChartBorderLines is the chart border overhead.
 	ChartBorderLines = 2
+
+	// HistorySearchMaxRows is the number of matches shown in the history search.
+	HistorySearchMaxRows = 10
+
+	// HistorySearchMaxWidth is the maximum width of the history search's list.
+	HistorySearchMaxWidth = 120
+
+	// LibraryMaxRows is the number of saved queries shown in the library browser.
+	LibraryMaxRows = 10
+
+	// LibraryMaxWidth is the maximum width of the library browser's list.
+	LibraryMaxWidth = 120
+
+	// RuleDetailsLines is the space reserved below the rules table for the selected row's details.
+	RuleDetailsLines = 8
 )
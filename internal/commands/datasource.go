@@ -0,0 +1,220 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/akasprzok/peat/internal/config"
+	"github.com/akasprzok/peat/internal/prometheus"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+)
+
+// ConnectFunc creates a Prometheus client for a datasource.
+type ConnectFunc func(ds config.Datasource) (prometheus.Client, error)
+
+// datasourceDefaults are the query parameters used when a datasource doesn't override them.
+type datasourceDefaults struct {
+	timeout    time.Duration
+	rangeValue time.Duration
+	stepValue  time.Duration
+}
+
+// WithDatasources enables runtime switching between the given datasources.
+// active is the index of the datasource the model's client is connected to.
+func (m TUIModel) WithDatasources(datasources []config.Datasource, active int, connect ConnectFunc) TUIModel {
+	m.datasources = datasources
+	m.activeDatasource = active
+	m.connect = connect
+	m.defaults = datasourceDefaults{
+		timeout:    m.timeout,
+		rangeValue: m.rangeValue,
+		stepValue:  m.stepValue,
+	}
+	if active >= 0 && active < len(datasources) {
+		m = m.applyDatasourceDefaults(datasources[active])
+	}
+	return m
+}
+
+// activeDatasourceName returns the name of the connected datasource, if any.
+func (m TUIModel) activeDatasourceName() string {
+	if m.activeDatasource < 0 || m.activeDatasource >= len(m.datasources) {
+		return ""
+	}
+	return m.datasources[m.activeDatasource].Name
+}
+
+func (m TUIModel) applyDatasourceDefaults(ds config.Datasource) TUIModel {
+	m.timeout = m.defaults.timeout
+	m.rangeValue = m.defaults.rangeValue
+	m.stepValue = m.defaults.stepValue
+	if ds.Timeout > 0 {
+		m.timeout = ds.Timeout
+	}
+	if ds.Range > 0 {
+		m.rangeValue = ds.Range
+	}
+	if ds.Step > 0 {
+		m.stepValue = ds.Step
+	}
+	// The template was validated when the datasources were loaded
+	m.exemplarURL, _ = ds.ExemplarTemplate()
+	m.alertmanager, m.alertmanagerErr = nil, nil
+	if cfg, ok := ds.AlertmanagerConfig(); ok {
+		client, err := prometheus.NewAlertmanagerClient(cfg)
+		if err != nil {
+			m.alertmanagerErr = fmt.Errorf("datasource %s: alertmanager: %w", ds.Name, err)
+		} else {
+			m.alertmanager = client
+		}
+	}
+	return m
+}
+
+func (m TUIModel) openDatasourcePicker() (tea.Model, tea.Cmd) {
+	if len(m.datasources) == 0 {
+		return m, nil
+	}
+	m.showDatasourcePicker = true
+	m.datasourceCursor = max(m.activeDatasource, 0)
+	m.datasourceErr = nil
+	return m, nil
+}
+
+func (m TUIModel) handleDatasourcePickerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "d":
+		m.showDatasourcePicker = false
+		m.datasourceErr = nil
+	case "j", "down":
+		if m.datasourceCursor < len(m.datasources)-1 {
+			m.datasourceCursor++
+		}
+	case "k", "up":
+		if m.datasourceCursor > 0 {
+			m.datasourceCursor--
+		}
+	case "enter":
+		return m.switchDatasource(m.datasourceCursor)
+	}
+	return m, nil
+}
+
+// switchDatasource connects to the datasource at index i and resets all
+// mode results, which belong to the previous datasource.
+func (m TUIModel) switchDatasource(i int) (tea.Model, tea.Cmd) {
+	if i == m.activeDatasource {
+		m.showDatasourcePicker = false
+		return m, nil
+	}
+
+	ds := m.datasources[i]
+	client, err := m.connect(ds)
+	if err != nil {
+		m.datasourceErr = err
+		return m, nil
+	}
+
+	for mode := range m.modeStates {
+		m.finishLoading(QueryMode(mode))
+		m.modeStates[mode] = StateInput
+		m.modeErrors[mode] = nil
+		m.modeWarnings[mode] = nil
+		m.modeDurations[mode] = 0
+		m.modeStats[mode] = nil
+	}
+	m.instantValue = nil
+	m.matrix = nil
+	m.series = nil
+	m.labels = nil
+	m.labelValues = nil
+	m.metadata = nil
+	m.targets = v1.TargetsResult{}
+	m.targetRows = nil
+	m.rules = prometheus.RulesResult{}
+	m.ruleRows = nil
+	m.tsdb = v1.TSDBResult{}
+	m.buildinfo = v1.BuildinfoResult{}
+	m.runtimeinfo = v1.RuntimeinfoResult{}
+	m.flags = nil
+	m.serverConfig = v1.ConfigResult{}
+	m.alertGroups = nil
+	m.silences = nil
+	m.alertRows = nil
+	m.silenceRows = nil
+	m.silenceNotice = ""
+	m.silenceErr = nil
+	m.exemplars = nil
+	m.histogramQuantiles = nil
+	m.viewingLabelValues = false
+	m.legendEntries = nil
+	m.selectedIndex = -1
+	m.highlightedIndices = make(map[int]bool)
+	m.legendFocused = false
+	m.focusedPane = PaneQuery
+	m.inputCollapsed = false
+
+	m.promClient = client
+	m.activeDatasource = i
+	m = m.applyDatasourceDefaults(ds)
+	m.showDatasourcePicker = false
+	m.datasourceErr = nil
+	m.resultsViewport.Height = m.getAvailableResultsHeight()
+	return m, nil
+}
+
+func (m TUIModel) renderDatasourcePicker() string {
+	accentColor := lipgloss.Color("205")
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(accentColor).
+		MarginBottom(1)
+
+	itemStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("252"))
+
+	cursorStyle := itemStyle.
+		Bold(true).
+		Background(lipgloss.Color("63")).
+		Foreground(lipgloss.Color("231"))
+
+	descStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("245"))
+
+	var content strings.Builder
+	content.WriteString(titleStyle.Render("Datasources"))
+	content.WriteString("\n")
+
+	for i, ds := range m.datasources {
+		marker := "  "
+		if i == m.activeDatasource {
+			marker = "* "
+		}
+		style := itemStyle
+		if i == m.datasourceCursor {
+			style = cursorStyle
+		}
+		content.WriteString(style.Render(fmt.Sprintf("%s%-20s", marker, ds.Name)))
+		content.WriteString(" " + descStyle.Render(ds.Location()) + "\n")
+	}
+
+	if m.datasourceErr != nil {
+		content.WriteString("\n")
+		content.WriteString(ErrorStyle.Render("Error: ") + m.datasourceErr.Error())
+		content.WriteString("\n")
+	}
+
+	content.WriteString("\n")
+	content.WriteString(descStyle.Render("j/k: move | enter: connect | esc: close"))
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(accentColor).
+		Padding(1, 2)
+
+	return boxStyle.Render(content.String())
+}
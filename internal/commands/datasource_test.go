@@ -0,0 +1,225 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/akasprzok/peat/internal/config"
+	"github.com/akasprzok/peat/internal/prometheus"
+	tea "github.com/charmbracelet/bubbletea"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+)
+
+func TestCLIDatasources(t *testing.T) {
+	missingConfig := filepath.Join(t.TempDir(), "missing.yaml")
+
+	t.Run("prometheus-url creates a cli datasource", func(t *testing.T) {
+		cli := CLI{PrometheusURL: "http://localhost:9090", BearerToken: "abc"}
+		datasources, active, err := cli.datasources(config.Config{})
+		if err != nil {
+			t.Fatalf("datasources() error = %v", err)
+		}
+		if len(datasources) != 1 || active != 0 {
+			t.Fatalf("datasources() = %v, %d, want 1 datasource, active 0", datasources, active)
+		}
+		if datasources[0].Name != cliDatasourceName || datasources[0].Auth.BearerToken != "abc" {
+			t.Errorf("datasources()[0] = %+v", datasources[0])
+		}
+	})
+
+	t.Run("no endpoint is an error", func(t *testing.T) {
+		cli := CLI{}
+		if _, _, err := cli.datasources(config.Config{}); err == nil {
+			t.Error("datasources() error = nil, want error")
+		}
+	})
+
+	t.Run("replay needs no endpoint", func(t *testing.T) {
+		cli := CLI{Replay: "session.jsonl"}
+		datasources, _, err := cli.datasources(config.Config{})
+		if err != nil {
+			t.Fatalf("datasources() error = %v", err)
+		}
+		if len(datasources) != 1 || datasources[0].URL != replayURL {
+			t.Errorf("datasources() = %+v, want the replay datasource", datasources)
+		}
+	})
+
+	t.Run("files need no endpoint", func(t *testing.T) {
+		cli := CLI{Files: []string{"metrics.txt"}}
+		datasources, _, err := cli.datasources(config.Config{})
+		if err != nil {
+			t.Fatalf("datasources() error = %v", err)
+		}
+		if len(datasources) != 1 || datasources[0].Location() != "metrics.txt" {
+			t.Errorf("datasources() = %+v, want the files datasource", datasources)
+		}
+	})
+
+	t.Run("local data takes precedence over the URL", func(t *testing.T) {
+		cli := CLI{PrometheusURL: "http://localhost:9090", TSDBDir: "/data"}
+		datasources, _, err := cli.datasources(config.Config{})
+		if err != nil {
+			t.Fatalf("datasources() error = %v", err)
+		}
+		if len(datasources) != 1 || datasources[0].URL != "" || datasources[0].Location() != "/data" {
+			t.Errorf("datasources() = %+v, want the TSDB datasource", datasources)
+		}
+	})
+
+	t.Run("config default datasource is active", func(t *testing.T) {
+		cfg := config.Config{
+			DefaultDatasource: "prod",
+			Datasources:       []config.Datasource{{Name: "dev", URL: "http://dev:9090"}, {Name: "prod", URL: "http://prod:9090"}},
+		}
+		datasources, active, err := (&CLI{}).datasources(cfg)
+		if err != nil {
+			t.Fatalf("datasources() error = %v", err)
+		}
+		if len(datasources) != 2 || active != 1 {
+			t.Errorf("datasources() = %+v, %d, want the configured ones with prod active", datasources, active)
+		}
+	})
+
+	t.Run("explicit config file must exist", func(t *testing.T) {
+		cli := CLI{PrometheusURL: "http://localhost:9090", Config: missingConfig}
+		if _, err := cli.loadConfig(); err == nil {
+			t.Error("loadConfig() error = nil, want error")
+		}
+	})
+
+	t.Run("unknown datasource is an error", func(t *testing.T) {
+		cli := CLI{PrometheusURL: "http://localhost:9090", Datasource: "prod"}
+		if _, _, err := cli.datasources(config.Config{}); err == nil {
+			t.Error("datasources() error = nil, want error")
+		}
+	})
+}
+
+func TestCheckConnection(t *testing.T) {
+	ds := config.Datasource{Name: "prod", URL: "http://prometheus.invalid:9090", Timeout: time.Minute}
+
+	var gotTimeout time.Duration
+	client := &prometheus.MockClient{
+		BuildinfoFunc: func(_ context.Context, timeout time.Duration) (v1.BuildinfoResult, error) {
+			gotTimeout = timeout
+			return v1.BuildinfoResult{Version: "2.53.0"}, nil
+		},
+	}
+	if err := checkConnection(context.Background(), client, ds, time.Second); err != nil {
+		t.Fatalf("checkConnection() error = %v", err)
+	}
+	if gotTimeout != connectionCheckTimeout {
+		t.Errorf("timeout = %v, want %v", gotTimeout, connectionCheckTimeout)
+	}
+
+	client.BuildinfoFunc = func(context.Context, time.Duration) (v1.BuildinfoResult, error) {
+		return v1.BuildinfoResult{}, errors.New("no such host")
+	}
+	err := checkConnection(context.Background(), client, ds, time.Second)
+	if err == nil {
+		t.Fatal("checkConnection() error = nil, want error")
+	}
+	want := "datasource prod: cannot reach Prometheus at http://prometheus.invalid:9090: no such host"
+	if err.Error() != want {
+		t.Errorf("checkConnection() error = %q, want %q", err, want)
+	}
+
+	// A server answering that it has no build info endpoint is reachable
+	client.BuildinfoFunc = func(context.Context, time.Duration) (v1.BuildinfoResult, error) {
+		return v1.BuildinfoResult{}, fmt.Errorf("%w: 404 Not Found", prometheus.ErrNoBuildinfo)
+	}
+	if err := checkConnection(context.Background(), client, ds, time.Second); err != nil {
+		t.Errorf("checkConnection() error = %v for a server without build info, want nil", err)
+	}
+
+	// but rejected credentials, answers that don't come from the API, such
+	// as a login page, and server errors aren't
+	for _, apiErr := range []*v1.Error{
+		{Type: v1.ErrClient, Msg: "401 Unauthorized"},
+		{Type: v1.ErrClient, Msg: "403 Forbidden"},
+		{Type: v1.ErrBadResponse, Msg: "invalid character '<' looking for beginning of value"},
+		{Type: v1.ErrServer, Msg: "502 Bad Gateway"},
+	} {
+		client.BuildinfoFunc = func(context.Context, time.Duration) (v1.BuildinfoResult, error) {
+			return v1.BuildinfoResult{}, apiErr
+		}
+		if err := checkConnection(context.Background(), client, ds, time.Second); err == nil {
+			t.Errorf("checkConnection() error = nil for %s, want error", apiErr.Msg)
+		}
+	}
+}
+
+func TestSwitchDatasource(t *testing.T) {
+	first := &prometheus.MockClient{}
+	second := &prometheus.MockClient{}
+	datasources := []config.Datasource{
+		{Name: "prod", URL: "http://prod:9090"},
+		{Name: "staging", URL: "http://staging:9090", Range: 6 * time.Hour},
+		{Name: "broken", URL: "http://broken:9090"},
+	}
+	connect := func(ds config.Datasource) (prometheus.Client, error) {
+		if ds.Name == "broken" {
+			return nil, errors.New("connection refused")
+		}
+		return second, nil
+	}
+
+	m := NewTUIModel(first, time.Hour, 15*time.Second, 100, 60*time.Second).
+		WithDatasources(datasources, 0, connect)
+	m.insertMode = false
+	m.modeStates[ModeInstant] = StateResults
+
+	updated, _ := m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	m = updated.(TUIModel)
+	if !m.showDatasourcePicker {
+		t.Fatal("showDatasourcePicker = false after pressing d")
+	}
+
+	t.Run("failed connection keeps the current datasource", func(t *testing.T) {
+		updated, _ := m.switchDatasource(2)
+		got := updated.(TUIModel)
+		if got.activeDatasourceName() != "prod" {
+			t.Errorf("activeDatasourceName() = %q, want %q", got.activeDatasourceName(), "prod")
+		}
+		if got.datasourceErr == nil {
+			t.Error("datasourceErr = nil, want connection error")
+		}
+	})
+
+	updated, _ = m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	m = updated.(TUIModel)
+	updated, _ = m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(TUIModel)
+
+	t.Run("client is swapped", func(t *testing.T) {
+		if m.promClient != second {
+			t.Error("promClient was not replaced")
+		}
+		if m.activeDatasourceName() != "staging" {
+			t.Errorf("activeDatasourceName() = %q, want %q", m.activeDatasourceName(), "staging")
+		}
+		if m.showDatasourcePicker {
+			t.Error("picker still open after switching")
+		}
+	})
+
+	t.Run("datasource overrides are applied", func(t *testing.T) {
+		if m.rangeValue != 6*time.Hour {
+			t.Errorf("rangeValue = %v, want %v", m.rangeValue, 6*time.Hour)
+		}
+		if m.stepValue != 15*time.Second {
+			t.Errorf("stepValue = %v, want %v", m.stepValue, 15*time.Second)
+		}
+	})
+
+	t.Run("results of the previous datasource are cleared", func(t *testing.T) {
+		if m.modeStates[ModeInstant] != StateInput {
+			t.Errorf("modeStates[ModeInstant] = %v, want %v", m.modeStates[ModeInstant], StateInput)
+		}
+	})
+}
@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/prometheus/common/model"
+)
+
+// EvalTime is the evaluation time of an instant query. It is either
+// "now" (the zero value), an absolute time, or an offset relative to now.
+type EvalTime struct {
+	absolute time.Time
+	offset   time.Duration
+	relative bool
+}
+
+// ParseEvalTime parses an evaluation time. Accepted formats are
+// "now" or an empty string, RFC3339 timestamps (2024-01-02T03:04:05Z),
+// relative offsets (-2h, now-1d30m) and unix timestamps in seconds (1700000000).
+func ParseEvalTime(s string) (EvalTime, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "now" {
+		return EvalTime{}, nil
+	}
+	s = strings.TrimPrefix(s, "now")
+
+	if s[0] == '-' || s[0] == '+' {
+		d, err := model.ParseDuration(s[1:])
+		if err != nil {
+			return EvalTime{}, fmt.Errorf("invalid offset %q: %w", s, err)
+		}
+		offset := time.Duration(d)
+		if s[0] == '-' {
+			offset = -offset
+		}
+		return EvalTime{offset: offset, relative: true}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return EvalTime{absolute: t}, nil
+	}
+
+	if secs, err := strconv.ParseFloat(s, 64); err == nil {
+		whole, frac := math.Modf(secs)
+		return EvalTime{absolute: time.Unix(int64(whole), int64(frac*float64(time.Second)))}, nil
+	}
+
+	return EvalTime{}, fmt.Errorf("invalid time %q: use now, RFC3339, a relative offset like -2h, or unix seconds", s)
+}
+
+// IsNow reports whether the query is evaluated at the current time.
+func (e EvalTime) IsNow() bool {
+	return !e.relative && e.absolute.IsZero()
+}
+
+// Resolve returns the evaluation time relative to now.
+func (e EvalTime) Resolve(now time.Time) time.Time {
+	switch {
+	case e.relative:
+		return now.Add(e.offset)
+	case e.absolute.IsZero():
+		return now
+	default:
+		return e.absolute
+	}
+}
+
+func (e EvalTime) String() string {
+	switch {
+	case e.relative:
+		sign := "+"
+		d := e.offset
+		if d < 0 {
+			sign = "-"
+			d = -d
+		}
+		return "now" + sign + model.Duration(d).String()
+	case e.absolute.IsZero():
+		return "now"
+	default:
+		return e.absolute.UTC().Format(time.RFC3339)
+	}
+}
+
+func (m TUIModel) openEvalTimePrompt() (tea.Model, tea.Cmd) {
+	if m.mode != ModeInstant {
+		return m, nil
+	}
+	m.editingEvalTime = true
+	m.evalTimeErr = nil
+	m.evalTimeInput.SetValue("")
+	if !m.evalTime.IsNow() {
+		m.evalTimeInput.SetValue(m.evalTime.String())
+	}
+	m.evalTimeInput.CursorEnd()
+	m.resultsViewport.Height = m.getAvailableResultsHeight()
+	return m, m.evalTimeInput.Focus()
+}
+
+func (m TUIModel) handleEvalTimeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.editingEvalTime = false
+		m.evalTimeErr = nil
+		m.evalTimeInput.Blur()
+		m.resultsViewport.Height = m.getAvailableResultsHeight()
+		return m, nil
+	case "enter":
+		evalTime, err := ParseEvalTime(m.evalTimeInput.Value())
+		if err != nil {
+			m.evalTimeErr = err
+			return m, nil
+		}
+		m.evalTime = evalTime
+		m.editingEvalTime = false
+		m.evalTimeErr = nil
+		m.evalTimeInput.Blur()
+		m.resultsViewport.Height = m.getAvailableResultsHeight()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.evalTimeInput, cmd = m.evalTimeInput.Update(msg)
+	return m, cmd
+}
+
+func (m TUIModel) renderEvalTimePrompt() string {
+	inputStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("205")).
+		Padding(0, 1)
+
+	content := "Evaluation time: " + m.evalTimeInput.View()
+	if m.evalTimeErr != nil {
+		content += "  " + ErrorStyle.Render(m.evalTimeErr.Error())
+	}
+	return inputStyle.Render(content)
+}
@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/akasprzok/peat/internal/prometheus"
+	tea "github.com/charmbracelet/bubbletea"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+)
+
+func TestParseEvalTime(t *testing.T) {
+	now := time.Date(2024, 5, 6, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		input      string
+		want       time.Time
+		wantString string
+		wantErr    bool
+	}{
+		{"empty is now", "", now, "now", false},
+		{"now", "now", now, "now", false},
+		{"RFC3339", "2024-05-06T03:12:00Z", time.Date(2024, 5, 6, 3, 12, 0, 0, time.UTC), "2024-05-06T03:12:00Z", false},
+		{"RFC3339 with offset", "2024-05-06T05:12:00+02:00", time.Date(2024, 5, 6, 3, 12, 0, 0, time.UTC), "2024-05-06T03:12:00Z", false},
+		{"negative offset", "-2h", now.Add(-2 * time.Hour), "now-2h", false},
+		{"offset relative to now", "now-1d30m", now.Add(-24*time.Hour - 30*time.Minute), "now-1d30m", false},
+		{"positive offset", "+15m", now.Add(15 * time.Minute), "now+15m", false},
+		{"unix seconds", "1714964400", time.Unix(1714964400, 0), "2024-05-06T03:00:00Z", false},
+		{"fractional unix seconds", "1714964400.5", time.Unix(1714964400, 500000000), "2024-05-06T03:00:00Z", false},
+		{"invalid offset", "-2x", time.Time{}, "", true},
+		{"garbage", "yesterday", time.Time{}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseEvalTime(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseEvalTime(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if resolved := got.Resolve(now); !resolved.Equal(tt.want) {
+				t.Errorf("Resolve() = %v, want %v", resolved, tt.want)
+			}
+			if got.String() != tt.wantString {
+				t.Errorf("String() = %q, want %q", got.String(), tt.wantString)
+			}
+		})
+	}
+}
+
+func TestEvalTimeStringRoundTrips(t *testing.T) {
+	for _, input := range []string{"now", "-2h", "2024-05-06T03:12:00Z"} {
+		first, err := ParseEvalTime(input)
+		if err != nil {
+			t.Fatalf("ParseEvalTime(%q) error = %v", input, err)
+		}
+		second, err := ParseEvalTime(first.String())
+		if err != nil {
+			t.Fatalf("ParseEvalTime(%q) error = %v", first.String(), err)
+		}
+		if first != second {
+			t.Errorf("round trip of %q = %+v, want %+v", input, second, first)
+		}
+	}
+}
+
+func TestEvalTimePrompt(t *testing.T) {
+	var gotTime time.Time
+	mockClient := &prometheus.MockClient{
+		QueryFunc: func(_ context.Context, _ string, ts time.Time, _ time.Duration, _ bool) (v1.Warnings, model.Value, *prometheus.QueryStats, error) {
+			gotTime = ts
+			return nil, nil, nil, nil
+		},
+	}
+	m := NewTUIModel(mockClient, time.Hour, 15*time.Second, 100, 60*time.Second)
+	m.insertMode = false
+
+	updated, _ := m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+	m = updated.(TUIModel)
+	if !m.editingEvalTime {
+		t.Fatal("editingEvalTime = false after pressing t")
+	}
+
+	m.evalTimeInput.SetValue("2024-05-06T03:12:00Z")
+	updated, _ = m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(TUIModel)
+
+	if m.editingEvalTime {
+		t.Error("editingEvalTime = true after enter")
+	}
+	if got, want := (InstantMode{}).RenderStatusParams(&m), "   Time: 2024-05-06T03:12:00Z"; got != want {
+		t.Errorf("RenderStatusParams() = %q, want %q", got, want)
+	}
+
+	m.executeInstantQuery(context.Background())()
+	if want := time.Date(2024, 5, 6, 3, 12, 0, 0, time.UTC); !gotTime.Equal(want) {
+		t.Errorf("query evaluated at %v, want %v", gotTime, want)
+	}
+}
@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/akasprzok/peat/internal/charts"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/prometheus/common/model"
+)
+
+// maxExemplarRows caps the number of exemplars listed below a range chart.
+const maxExemplarRows = 50
+
+// exemplarRow is a single exemplar with the labels of the series it belongs to.
+type exemplarRow struct {
+	timestamp    time.Time
+	value        float64
+	labels       model.LabelSet
+	seriesLabels model.LabelSet
+}
+
+// exemplarRows flattens the exemplars of the last range query, newest first.
+func (m TUIModel) exemplarRows() []exemplarRow {
+	var rows []exemplarRow
+	for _, result := range m.exemplars {
+		for _, e := range result.Exemplars {
+			rows = append(rows, exemplarRow{
+				timestamp:    e.Timestamp.Time(),
+				value:        float64(e.Value),
+				labels:       e.Labels,
+				seriesLabels: result.SeriesLabels,
+			})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].timestamp.After(rows[j].timestamp)
+	})
+	return rows
+}
+
+// rangeChartOptions returns the options for drawing the range chart.
+func (m TUIModel) rangeChartOptions() []charts.TimeseriesOption {
+	options := []charts.TimeseriesOption{charts.WithHistogramValue(m.currentHistogramValue())}
+	if v := m.currentHistogramValue(); v.IsQuantile() {
+		options = append(options, charts.WithQuantileSeries(m.histogramQuantiles[v.Quantile]))
+	}
+	if !m.showExemplars {
+		return options
+	}
+	rows := m.exemplarRows()
+	points := make([]charts.Exemplar, 0, len(rows))
+	for _, row := range rows {
+		points = append(points, charts.Exemplar{Time: row.timestamp, Value: row.value, SeriesLabels: row.seriesLabels})
+	}
+	return append(options, charts.WithExemplars(points))
+}
+
+// toggleExemplars shows or hides exemplars on the range chart. Exemplars
+// are only fetched while shown, so showing them re-runs the query.
+func (m TUIModel) toggleExemplars() (tea.Model, tea.Cmd) {
+	if m.mode != ModeRange {
+		return m, nil
+	}
+	m.showExemplars = !m.showExemplars
+	if m.currentState() != StateResults {
+		return m, nil
+	}
+	if m.showExemplars && m.exemplars == nil {
+		return m.executeQuery()
+	}
+	m = m.renderRangeChart()
+	m = m.syncViewportContent()
+	return m, nil
+}
+
+// exemplarLink renders the trace URL of an exemplar. The template can use
+// the exemplar's labels and those of its series, e.g. {{.trace_id}}.
+func (m TUIModel) exemplarLink(row exemplarRow) (string, bool) {
+	if m.exemplarURL == nil {
+		return "", false
+	}
+	data := make(map[string]string, len(row.labels)+len(row.seriesLabels))
+	for name, value := range row.seriesLabels {
+		data[string(name)] = string(value)
+	}
+	for name, value := range row.labels {
+		data[string(name)] = string(value)
+	}
+	var b strings.Builder
+	if err := m.exemplarURL.Execute(&b, data); err != nil {
+		return "", false
+	}
+	return b.String(), true
+}
+
+// hyperlink wraps text in an OSC 8 terminal hyperlink to url.
+// Terminals without hyperlink support print the text only.
+func hyperlink(url, text string) string {
+	return "\x1b]8;;" + url + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+func (m TUIModel) renderExemplarList() string {
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(charts.ExemplarColor)
+	timeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+	linkStyle := lipgloss.NewStyle().Foreground(charts.LabelColor).Underline(true)
+
+	rows := m.exemplarRows()
+
+	var s strings.Builder
+	s.WriteString(titleStyle.Render(fmt.Sprintf("Exemplars (%d)", len(rows))))
+	s.WriteString("\n")
+	if len(rows) == 0 {
+		s.WriteString(timeStyle.Render("  No exemplars in range"))
+		s.WriteString("\n")
+	}
+
+	for i, row := range rows {
+		if i == maxExemplarRows {
+			s.WriteString(timeStyle.Render(fmt.Sprintf("  ... %d more", len(rows)-maxExemplarRows)))
+			s.WriteString("\n")
+			break
+		}
+		s.WriteString("  ")
+		s.WriteString(timeStyle.Render(row.timestamp.UTC().Format(sampleTimeFormat)))
+		s.WriteString(fmt.Sprintf("  %-12g  %s", row.value, row.labels))
+		if url, ok := m.exemplarLink(row); ok {
+			s.WriteString("  ")
+			s.WriteString(hyperlink(url, linkStyle.Render(url)))
+		}
+		s.WriteString("\n")
+	}
+	return s.String()
+}
@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akasprzok/peat/internal/config"
+	"github.com/akasprzok/peat/internal/prometheus"
+	tea "github.com/charmbracelet/bubbletea"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+)
+
+func TestExemplars(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	var exemplarErr error
+	mockClient := &prometheus.MockClient{
+		QueryRangeFunc: func(_ context.Context, _ string, _, _ time.Time, _ time.Duration, _ time.Duration, _ bool) (model.Matrix, v1.Warnings, *prometheus.QueryStats, error) {
+			return model.Matrix{
+				&model.SampleStream{
+					Metric: model.Metric{"job": "api"},
+					Values: []model.SamplePair{
+						{Timestamp: model.TimeFromUnix(now.Add(-time.Minute).Unix()), Value: 1},
+						{Timestamp: model.TimeFromUnix(now.Unix()), Value: 2},
+					},
+				},
+			}, nil, nil, nil
+		},
+		ExemplarsFunc: func(_ context.Context, _ string, _, _ time.Time, _ time.Duration) ([]v1.ExemplarQueryResult, error) {
+			if exemplarErr != nil {
+				return nil, exemplarErr
+			}
+			return []v1.ExemplarQueryResult{
+				{
+					SeriesLabels: model.LabelSet{"job": "api"},
+					Exemplars: []v1.Exemplar{
+						{Labels: model.LabelSet{"trace_id": "old"}, Value: 1.5, Timestamp: model.TimeFromUnix(now.Add(-50 * time.Second).Unix())},
+						{Labels: model.LabelSet{"trace_id": "new"}, Value: 1.8, Timestamp: model.TimeFromUnix(now.Add(-10 * time.Second).Unix())},
+					},
+				},
+			}, nil
+		},
+	}
+
+	newModel := func(t *testing.T) TUIModel {
+		t.Helper()
+		m := NewTUIModel(mockClient, time.Hour, 15*time.Second, 100, 60*time.Second).
+			WithDatasources([]config.Datasource{{
+				Name:        "local",
+				URL:         "http://localhost:9090",
+				ExemplarURL: "https://tempo.example.com/trace/{{.trace_id}}?job={{.job}}",
+			}}, 0, nil)
+		m.width = 120
+		m.height = 60
+		m.insertMode = false
+		updated, _ := m.switchToMode(ModeRange)
+		m = updated.(TUIModel)
+		m.queryInput.SetValue("rate(http_requests_total[5m])")
+		ctx := m.startLoading(ModeRange)
+		updated, _ = m.Update(m.executeRangeQuery(ctx)())
+		return updated.(TUIModel)
+	}
+
+	toggle := func(m TUIModel) (TUIModel, tea.Cmd) {
+		updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+		return updated.(TUIModel), cmd
+	}
+
+	t.Run("exemplars are not fetched until shown", func(t *testing.T) {
+		m := newModel(t)
+		if m.exemplars != nil {
+			t.Errorf("exemplars = %v, want nil", m.exemplars)
+		}
+	})
+
+	t.Run("showing exemplars re-runs the query", func(t *testing.T) {
+		m := newModel(t)
+		m, cmd := toggle(m)
+		if !m.showExemplars {
+			t.Fatal("showExemplars = false, want true")
+		}
+		if cmd == nil || m.currentState() != StateLoading {
+			t.Fatalf("currentState() = %v, want the query to re-run", m.currentState())
+		}
+
+		ctx := m.startLoading(ModeRange)
+		updated, _ := m.Update(m.executeRangeQuery(ctx)())
+		m = updated.(TUIModel)
+
+		rows := m.exemplarRows()
+		if len(rows) != 2 || rows[0].labels["trace_id"] != "new" {
+			t.Fatalf("exemplarRows() = %v, want newest first", rows)
+		}
+
+		content := m.resultsViewport.View()
+		link := "https://tempo.example.com/trace/new?job=api"
+		if !strings.Contains(content, "\x1b]8;;"+link+"\x1b\\") {
+			t.Errorf("results do not contain a hyperlink to %q", link)
+		}
+
+		// Hiding exemplars keeps them for the next toggle
+		m, cmd = toggle(m)
+		if cmd != nil || m.exemplars == nil {
+			t.Error("hiding exemplars discarded them or re-ran the query")
+		}
+	})
+
+	t.Run("exemplar errors are warnings", func(t *testing.T) {
+		exemplarErr = errors.New("exemplar storage disabled")
+		defer func() { exemplarErr = nil }()
+
+		m := newModel(t)
+		m.showExemplars = true
+		ctx := m.startLoading(ModeRange)
+		updated, _ := m.Update(m.executeRangeQuery(ctx)())
+		m = updated.(TUIModel)
+
+		if m.currentState() != StateResults {
+			t.Fatalf("currentState() = %v, want %v", m.currentState(), StateResults)
+		}
+		warnings := m.currentWarnings()
+		if len(warnings) != 1 || !strings.Contains(warnings[0], "exemplar storage disabled") {
+			t.Errorf("warnings = %v, want the exemplar error", warnings)
+		}
+	})
+}
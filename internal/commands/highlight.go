@@ -0,0 +1,205 @@
+package commands
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// Syntax highlighting styles
+var (
+	yamlKeyStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("75"))
+	yamlStringStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("114"))
+	yamlLiteralStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("215"))
+	yamlCommentStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	yamlPunctStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
+
+	promqlMetricStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("75"))
+	promqlLabelStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("180"))
+	promqlStringStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("114"))
+	promqlNumberStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("215"))
+	promqlDurationStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("179"))
+	promqlFunctionStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("212"))
+	promqlKeywordStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("176"))
+	promqlOperatorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("204"))
+	promqlPunctStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
+	promqlErrorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Underline(true)
+)
+
+// yamlKeyPattern matches a mapping key at the start of a line, after any
+// indentation and list marker.
+var yamlKeyPattern = regexp.MustCompile(`^("[^"]*"|'[^']*'|[^\s"'#][^:#]*?):(\s|$)`)
+
+// yamlLiteralPattern matches plain scalars that are not strings.
+var yamlLiteralPattern = regexp.MustCompile(`^(true|false|null|~|[-+]?(\d[\d_]*(\.\d*)?([eE][-+]?\d+)?|\.inf|\.nan))$`)
+
+// highlightYAML colors the keys, values and comments of a YAML document, one
+// line at a time. The lines of block scalars (| and >) are colored as strings.
+func highlightYAML(src string) string {
+	lines := strings.Split(strings.TrimRight(src, "\n"), "\n")
+	blockIndent := -1 // Column of the key of the enclosing block scalar, or -1
+
+	for i, line := range lines {
+		body := strings.TrimLeft(line, " ")
+		indent := line[:len(line)-len(body)]
+
+		if blockIndent >= 0 {
+			if body == "" || len(indent) > blockIndent {
+				lines[i] = indent + yamlStringStyle.Render(body)
+				continue
+			}
+			blockIndent = -1
+		}
+
+		if body == "" {
+			continue
+		}
+		if strings.HasPrefix(body, "#") {
+			lines[i] = indent + yamlCommentStyle.Render(body)
+			continue
+		}
+
+		var s strings.Builder
+		s.WriteString(indent)
+		for strings.HasPrefix(body, "- ") || body == "-" {
+			s.WriteString(yamlPunctStyle.Render("-"))
+			body = strings.TrimPrefix(body[1:], " ")
+			s.WriteString(" ")
+		}
+
+		value := body
+		if match := yamlKeyPattern.FindStringSubmatch(body); match != nil {
+			s.WriteString(yamlKeyStyle.Render(match[1]))
+			s.WriteString(yamlPunctStyle.Render(":"))
+			value = strings.TrimPrefix(body[len(match[1])+1:], " ")
+			if value != "" {
+				s.WriteString(" ")
+			}
+		}
+
+		if strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+			// The scalar's lines are indented past the key, which may follow list markers
+			blockIndent = len(line) - len(body)
+			s.WriteString(yamlPunctStyle.Render(value))
+		} else {
+			s.WriteString(highlightYAMLValue(value))
+		}
+		lines[i] = s.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// highlightYAMLValue colors a scalar value and its trailing comment.
+func highlightYAMLValue(value string) string {
+	comment := ""
+	if !strings.HasPrefix(value, `"`) && !strings.HasPrefix(value, "'") {
+		if i := strings.Index(value, " #"); i >= 0 {
+			value, comment = value[:i], value[i:]
+		}
+	}
+
+	switch {
+	case value == "":
+	case value == "{}" || value == "[]":
+		value = yamlPunctStyle.Render(value)
+	case yamlLiteralPattern.MatchString(value):
+		value = yamlLiteralStyle.Render(value)
+	default:
+		value = yamlStringStyle.Render(value)
+	}
+
+	if comment != "" {
+		value += yamlCommentStyle.Render(comment)
+	}
+	return value
+}
+
+// promqlStyles returns the style of each byte of a PromQL expression, nil
+// for whitespace. Anything after a token the lexer rejects is left unstyled.
+func promqlStyles(src string) []*lipgloss.Style {
+	styles := make([]*lipgloss.Style, len(src))
+
+	var (
+		items []parser.Item
+		open  []parser.ItemType // Enclosing braces and parentheses, grouping ones as BY
+	)
+	lexer := parser.Lex(src)
+	for {
+		var item parser.Item
+		lexer.NextItem(&item)
+		if item.Typ == parser.EOF || item.Typ == parser.ERROR {
+			break
+		}
+		switch item.Typ {
+		case parser.LEFT_BRACE:
+			open = append(open, parser.LEFT_BRACE)
+		case parser.LEFT_PAREN:
+			if len(items) > 0 && groupingKeywords[items[len(items)-1].Typ] {
+				open = append(open, parser.BY)
+			} else {
+				open = append(open, parser.LEFT_PAREN)
+			}
+		case parser.RIGHT_BRACE, parser.RIGHT_PAREN:
+			if len(open) > 0 {
+				open = open[:len(open)-1]
+			}
+		}
+		items = append(items, item)
+
+		var style *lipgloss.Style
+		switch t := item.Typ; {
+		case t == parser.IDENTIFIER || t == parser.METRIC_IDENTIFIER:
+			style = &promqlMetricStyle
+			if len(open) > 0 && (open[len(open)-1] == parser.LEFT_BRACE || open[len(open)-1] == parser.BY) {
+				style = &promqlLabelStyle
+			} else if _, ok := parser.Functions[item.Val]; ok && strings.HasPrefix(strings.TrimLeft(src[item.PositionRange().End:], " \t\r\n"), "(") {
+				style = &promqlFunctionStyle
+			}
+		case t == parser.STRING:
+			style = &promqlStringStyle
+		case t == parser.NUMBER:
+			style = &promqlNumberStyle
+		case t == parser.DURATION:
+			style = &promqlDurationStyle
+		case t.IsAggregator():
+			style = &promqlFunctionStyle
+		case t.IsKeyword():
+			style = &promqlKeywordStyle
+		case t.IsOperator() || matchOperators[t]:
+			style = &promqlOperatorStyle
+		default:
+			style = &promqlPunctStyle
+		}
+		pr := item.PositionRange()
+		for i := int(pr.Start); i < int(pr.End) && i < len(styles); i++ {
+			styles[i] = style
+		}
+	}
+	return styles
+}
+
+// highlightPromQL colors the metric names, labels, strings, numbers,
+// durations, functions and operators of a PromQL expression.
+func highlightPromQL(src string) string {
+	return renderStyled(src, promqlStyles(src))
+}
+
+// renderStyled renders each run of bytes of src sharing a style with it.
+func renderStyled(src string, styles []*lipgloss.Style) string {
+	var s strings.Builder
+	for start := 0; start < len(src); {
+		end := start + 1
+		for end < len(src) && styles[end] == styles[start] {
+			end++
+		}
+		if styles[start] == nil {
+			s.WriteString(src[start:end])
+		} else {
+			s.WriteString(styles[start].Render(src[start:end]))
+		}
+		start = end
+	}
+	return s.String()
+}
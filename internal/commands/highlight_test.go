@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+)
+
+func TestHighlightYAML(t *testing.T) {
+	src := `# comment
+global:
+  scrape_interval: 15s
+  external_labels: {}
+scrape_configs:
+  - job_name: "node"
+    honor_labels: true
+    sample_limit: 0
+    static_configs:
+      - targets:
+          - localhost:9100
+    metric_relabel_configs:
+      - regex: |
+          foo: bar
+        action: drop # unused
+`
+
+	profile := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.ANSI256)
+	defer lipgloss.SetColorProfile(profile)
+
+	got := highlightYAML(src)
+
+	// Highlighting must not change the text
+	if plain := ansi.Strip(got); plain != strings.TrimRight(src, "\n") {
+		t.Errorf("highlightYAML() changed the text:\n%s", plain)
+	}
+
+	lines := strings.Split(got, "\n")
+	tests := []struct {
+		line int
+		want string
+	}{
+		{0, yamlCommentStyle.Render("# comment")},
+		{1, yamlKeyStyle.Render("global")},
+		{2, yamlStringStyle.Render("15s")},
+		{3, yamlPunctStyle.Render("{}")},
+		{5, yamlStringStyle.Render(`"node"`)},
+		{6, yamlLiteralStyle.Render("true")},
+		{7, yamlLiteralStyle.Render("0")},
+		{10, yamlStringStyle.Render("localhost:9100")},
+		{12, yamlPunctStyle.Render("|")},
+		// Block scalar lines are strings, not keys
+		{13, yamlStringStyle.Render("foo: bar")},
+		{14, yamlKeyStyle.Render("action")},
+		{14, yamlCommentStyle.Render(" # unused")},
+	}
+	for _, tt := range tests {
+		if !strings.Contains(lines[tt.line], tt.want) {
+			t.Errorf("line %d = %q, want it to contain %q", tt.line, lines[tt.line], tt.want)
+		}
+	}
+}
+
+func TestHighlightPromQL(t *testing.T) {
+	src := `sum by (job) (rate(http_requests_total{code=~"5.."}[5m] offset 1h)) > 0.5`
+
+	profile := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.ANSI256)
+	defer lipgloss.SetColorProfile(profile)
+
+	got := highlightPromQL(src)
+	if plain := ansi.Strip(got); plain != src {
+		t.Errorf("highlightPromQL() changed the text: %s", plain)
+	}
+
+	for _, want := range []string{
+		promqlFunctionStyle.Render("sum"),
+		promqlKeywordStyle.Render("by"),
+		promqlLabelStyle.Render("job"),
+		promqlFunctionStyle.Render("rate"),
+		promqlMetricStyle.Render("http_requests_total"),
+		promqlLabelStyle.Render("code"),
+		promqlOperatorStyle.Render("=~"),
+		promqlStringStyle.Render(`"5.."`),
+		promqlDurationStyle.Render("5m"),
+		promqlKeywordStyle.Render("offset"),
+		promqlOperatorStyle.Render(">"),
+		promqlNumberStyle.Render("0.5"),
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("highlightPromQL() = %q, want it to contain %q", got, want)
+		}
+	}
+
+	// A metric named like a function is only a function when called
+	if got := highlightPromQL("time + time()"); !strings.HasPrefix(got, promqlMetricStyle.Render("time")) ||
+		!strings.Contains(got, promqlFunctionStyle.Render("time")) {
+		t.Errorf("highlightPromQL() = %q, want a metric and a function", got)
+	}
+
+	// Text after a lexing error is kept
+	if plain := ansi.Strip(highlightPromQL(`up{job="unterminated`)); plain != `up{job="unterminated` {
+		t.Errorf("highlightPromQL() changed the text: %s", plain)
+	}
+}
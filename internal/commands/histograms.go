@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akasprzok/peat/internal/charts"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+)
+
+// DefaultHistogramQuantiles are the quantiles of native histograms that can
+// be plotted in /query_range when none are configured.
+var DefaultHistogramQuantiles = []float64{0.5, 0.9, 0.99}
+
+// histogramValues returns the values native histograms can be plotted as in
+// /query_range, cycled with H: the given quantiles, then count and sum.
+func histogramValues(quantiles []float64) []charts.HistogramValue {
+	values := make([]charts.HistogramValue, 0, len(quantiles)+2)
+	for _, q := range quantiles {
+		values = append(values, charts.HistogramQuantile(q))
+	}
+	return append(values, charts.HistogramCount, charts.HistogramSum)
+}
+
+// WithHistogramQuantiles sets the quantiles of native histograms that can
+// be plotted in /query_range.
+func (m TUIModel) WithHistogramQuantiles(quantiles []float64) TUIModel {
+	m.histogramValues = histogramValues(quantiles)
+	m.histogramValue = 0
+	return m
+}
+
+// currentHistogramValue returns the value native histograms are plotted as.
+func (m TUIModel) currentHistogramValue() charts.HistogramValue {
+	return m.histogramValues[m.histogramValue]
+}
+
+// cycleHistogramValue plots native histograms as the next value: the next
+// quantile, the count or the sum.
+func (m TUIModel) cycleHistogramValue() (tea.Model, tea.Cmd) {
+	if m.mode != ModeRange {
+		return m, nil
+	}
+	m.histogramValue = (m.histogramValue + 1) % len(m.histogramValues)
+	if m.currentState() == StateResults {
+		m = m.renderRangeChart()
+		m = m.syncViewportContent()
+	}
+	return m, nil
+}
+
+// hasHistograms reports whether any series of matrix has native histogram samples.
+func hasHistograms(matrix model.Matrix) bool {
+	for _, stream := range matrix {
+		if len(stream.Histograms) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// histogramQuantileQuery returns the query computing the q-quantile of the
+// native histograms returned by query. The query is closed on its own line
+// so that a trailing comment doesn't swallow the parenthesis.
+func histogramQuantileQuery(q float64, query string) string {
+	return fmt.Sprintf("histogram_quantile(%s, %s\n)", strconv.FormatFloat(q, 'g', -1, 64), query)
+}
+
+// queryHistogramQuantiles runs histogram_quantile over query for every
+// quantile histograms can be plotted as, over the range of the range query.
+// Failures are returned as warnings.
+func (m TUIModel) queryHistogramQuantiles(ctx context.Context, query string, start, end time.Time) (map[float64]model.Matrix, v1.Warnings) {
+	quantiles := make(map[float64]model.Matrix)
+	var warnings v1.Warnings
+	for _, v := range m.histogramValues {
+		if !v.IsQuantile() {
+			continue
+		}
+		matrix, quantileWarnings, _, err := m.promClient.QueryRange(ctx, histogramQuantileQuery(v.Quantile, query), start, end, m.stepValue, m.timeout, false)
+		if err != nil {
+			warnings = append(warnings, v.Name+": "+err.Error())
+			continue
+		}
+		warnings = append(warnings, quantileWarnings...)
+		quantiles[v.Quantile] = matrix
+	}
+	return quantiles, warnings
+}
+
+// renderHistogramSamples renders the bucket distribution of every native
+// histogram sample of vector, below a summary of its count and sum.
+func renderHistogramSamples(vector model.Vector, width int) string {
+	captionStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+
+	var s strings.Builder
+	for i, sample := range vector {
+		if sample.Histogram == nil {
+			continue
+		}
+		s.WriteString("\n")
+		s.WriteString(charts.SeriesStyle(i).Bold(true).Render(sample.Metric.String()))
+		s.WriteString("\n")
+		s.WriteString(captionStyle.Render(fmt.Sprintf("histogram  count: %s  sum: %s",
+			sample.Histogram.Count, sample.Histogram.Sum)))
+		s.WriteString("\n")
+		s.WriteString(charts.HistogramBuckets(sample.Histogram, width, i))
+		s.WriteString("\n")
+	}
+	return s.String()
+}
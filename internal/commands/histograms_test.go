@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akasprzok/peat/internal/prometheus"
+	tea "github.com/charmbracelet/bubbletea"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+)
+
+func testHistogram() *model.SampleHistogram {
+	return &model.SampleHistogram{
+		Count: 10,
+		Sum:   18,
+		Buckets: model.HistogramBuckets{
+			{Boundaries: 0, Lower: 0, Upper: 1, Count: 4},
+			{Boundaries: 0, Lower: 1, Upper: 2, Count: 6},
+		},
+	}
+}
+
+func TestNativeHistograms(t *testing.T) {
+	now := model.TimeFromUnix(time.Now().Unix())
+	var rangeQueries []string
+	mockClient := &prometheus.MockClient{
+		QueryFunc: func(_ context.Context, _ string, _ time.Time, _ time.Duration, _ bool) (v1.Warnings, model.Value, *prometheus.QueryStats, error) {
+			return nil, model.Vector{
+				{Metric: model.Metric{"__name__": "rpc_duration_seconds"}, Histogram: testHistogram(), Timestamp: now},
+				{Metric: model.Metric{"__name__": "up"}, Value: 1, Timestamp: now},
+			}, nil, nil
+		},
+		QueryRangeFunc: func(_ context.Context, query string, _, _ time.Time, _ time.Duration, _ time.Duration, _ bool) (model.Matrix, v1.Warnings, *prometheus.QueryStats, error) {
+			rangeQueries = append(rangeQueries, query)
+			if strings.HasPrefix(query, "histogram_quantile(") {
+				return model.Matrix{
+					&model.SampleStream{
+						Metric: model.Metric{},
+						Values: []model.SamplePair{{Timestamp: now - 60000, Value: 1.9}, {Timestamp: now, Value: 1.95}},
+					},
+				}, nil, nil, nil
+			}
+			if query == "up" {
+				return model.Matrix{
+					&model.SampleStream{
+						Metric: model.Metric{"__name__": "up"},
+						Values: []model.SamplePair{{Timestamp: now, Value: 1}},
+					},
+				}, nil, nil, nil
+			}
+			return model.Matrix{
+				&model.SampleStream{
+					Metric: model.Metric{"__name__": "rpc_duration_seconds"},
+					Histograms: []model.SampleHistogramPair{
+						{Timestamp: now - 60000, Histogram: testHistogram()},
+						{Timestamp: now, Histogram: testHistogram()},
+					},
+				},
+			}, nil, nil, nil
+		},
+	}
+	newModel := func() TUIModel {
+		m := NewTUIModel(mockClient, time.Hour, 15*time.Second, 100, 60*time.Second).
+			WithHistogramQuantiles([]float64{0.99})
+		m.width = 120
+		m.height = 60
+		m.insertMode = false
+		m.queryInput.SetValue("rpc_duration_seconds")
+		return m
+	}
+
+	t.Run("instant bucket distribution", func(t *testing.T) {
+		m := newModel()
+		ctx := m.startLoading(ModeInstant)
+		updated, _ := m.Update(m.executeInstantQuery(ctx)())
+		m = updated.(TUIModel)
+		for _, want := range []string{"histogram, count 10", "count: 10  sum: 18", "(0,1] (4)", "(1,2] (6)"} {
+			if !strings.Contains(m.chartContent, want) {
+				t.Errorf("chart missing %q", want)
+			}
+		}
+	})
+
+	t.Run("range values cycle", func(t *testing.T) {
+		rangeQueries = nil
+		m := newModel()
+		updated, _ := m.switchToMode(ModeRange)
+		m = updated.(TUIModel)
+		m.queryInput.SetValue("rpc_duration_seconds")
+		ctx := m.startLoading(ModeRange)
+		updated, _ = m.Update(m.executeRangeQuery(ctx)())
+		m = updated.(TUIModel)
+
+		want := []string{"rpc_duration_seconds", "histogram_quantile(0.99, rpc_duration_seconds\n)"}
+		if !slices.Equal(rangeQueries, want) {
+			t.Errorf("range queries = %q, want %q", rangeQueries, want)
+		}
+		if m.histogramQuantiles[0.99] == nil {
+			t.Error("histogram_quantile result not kept")
+		}
+		withoutQuantiles := m
+		withoutQuantiles.histogramQuantiles = nil
+		if withoutQuantiles.renderRangeChart().chartContent == m.chartContent {
+			t.Error("p99 not plotted")
+		}
+
+		for _, want := range []string{"p99", "count", "sum", "p99"} {
+			if status := m.currentMode().RenderResultsStatusBar(&m); status != " | Histograms: "+want {
+				t.Errorf("status bar = %q, want histograms plotted as %s", status, want)
+			}
+			if !strings.Contains(m.legendTable.View(), want) {
+				t.Errorf("legend doesn't show %s", want)
+			}
+			updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("H")})
+			m = updated.(TUIModel)
+		}
+	})
+
+	t.Run("float results run no quantile queries", func(t *testing.T) {
+		rangeQueries = nil
+		m := newModel()
+		updated, _ := m.switchToMode(ModeRange)
+		m = updated.(TUIModel)
+		m.queryInput.SetValue("up")
+		ctx := m.startLoading(ModeRange)
+		updated, _ = m.Update(m.executeRangeQuery(ctx)())
+		m = updated.(TUIModel)
+		if !slices.Equal(rangeQueries, []string{"up"}) {
+			t.Errorf("range queries = %q, want only the query", rangeQueries)
+		}
+	})
+}
@@ -0,0 +1,247 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/akasprzok/peat/internal/history"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// historyMatch is a history entry matching the search.
+type historyMatch struct {
+	entry     history.Entry
+	text      string // Query on a single line
+	positions []int  // Rune indexes of text matching the search
+	score     int
+}
+
+// WithHistory records the queries run to store, and recalls them from it.
+func (m TUIModel) WithHistory(store *history.Store) TUIModel {
+	m.history = store
+	return m
+}
+
+// recordHistory adds the query run in mode to the history, with the number
+// of series or rows it returned.
+func (m TUIModel) recordHistory(mode QueryMode, duration time.Duration, size int) TUIModel {
+	m.historyIndex = -1
+	if m.history == nil || strings.TrimSpace(m.modeQueries[mode]) == "" {
+		return m
+	}
+	m.history.Add(history.Entry{
+		Time:       time.Now(),
+		Mode:       mode.String(),
+		Datasource: m.activeDatasourceName(),
+		Query:      m.modeQueries[mode],
+		Duration:   duration,
+		ResultSize: size,
+	})
+	return m
+}
+
+// recallHistory replaces the query being edited with the entry step places
+// older (1) or newer (-1) than the recalled one, in the history of the current
+// mode and datasource. Going past the newest entry restores the query that
+// was being edited.
+func (m TUIModel) recallHistory(step int) TUIModel {
+	if m.history == nil {
+		return m
+	}
+	if m.historyIndex < 0 {
+		if step < 0 {
+			return m
+		}
+		m.historyDraft = m.queryInput.Value()
+		m.historyRecall = nil
+		for _, e := range m.history.Entries() {
+			if e.Mode == m.mode.String() && e.Datasource == m.activeDatasourceName() && e.Query != m.historyDraft {
+				m.historyRecall = append(m.historyRecall, e)
+			}
+		}
+	}
+
+	index := m.historyIndex + step
+	switch {
+	case index >= len(m.historyRecall):
+		return m
+	case index < 0:
+		m.historyIndex = -1
+		m.queryInput.SetValue(m.historyDraft)
+	default:
+		m.historyIndex = index
+		m.queryInput.SetValue(m.historyRecall[index].Query)
+	}
+	m.completions = nil
+	m.completionErr = nil
+	return m
+}
+
+func (m TUIModel) openHistorySearch() (tea.Model, tea.Cmd) {
+	if m.history == nil {
+		return m, nil
+	}
+	m.showHistorySearch = true
+	m.completions = nil
+	m.historySearchInput.SetValue("")
+	m = m.searchHistory()
+	return m, m.historySearchInput.Focus()
+}
+
+func (m TUIModel) handleHistorySearchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+g":
+		m.showHistorySearch = false
+		m.historySearchInput.Blur()
+		return m, nil
+	case "up", "ctrl+p":
+		if m.historyCursor > 0 {
+			m.historyCursor--
+		}
+		return m, nil
+	case "down", "ctrl+n", "ctrl+r":
+		if m.historyCursor < len(m.historyMatches)-1 {
+			m.historyCursor++
+		}
+		return m, nil
+	case "enter":
+		if len(m.historyMatches) == 0 {
+			return m, nil
+		}
+		return m.loadHistoryEntry(m.historyMatches[m.historyCursor].entry)
+	}
+
+	var cmd tea.Cmd
+	m.historySearchInput, cmd = m.historySearchInput.Update(msg)
+	m = m.searchHistory()
+	return m, cmd
+}
+
+// searchHistory lists the entries of the active datasource fuzzily matching
+// the search, best matches first and newest first among equal ones.
+func (m TUIModel) searchHistory() TUIModel {
+	pattern := m.historySearchInput.Value()
+	m.historyMatches = nil
+	m.historyCursor = 0
+	for _, e := range m.history.Entries() {
+		if e.Datasource != m.activeDatasourceName() {
+			continue
+		}
+		text := strings.Join(strings.Fields(e.Query), " ")
+		if score, positions, ok := history.Match(pattern, text); ok {
+			m.historyMatches = append(m.historyMatches, historyMatch{entry: e, text: text, positions: positions, score: score})
+		}
+	}
+	sort.SliceStable(m.historyMatches, func(i, j int) bool {
+		return m.historyMatches[i].score > m.historyMatches[j].score
+	})
+	return m
+}
+
+// loadHistoryEntry switches to the mode of e and edits its query.
+func (m TUIModel) loadHistoryEntry(e history.Entry) (tea.Model, tea.Cmd) {
+	m.showHistorySearch = false
+	m.historySearchInput.Blur()
+	m.historyIndex = -1
+	if mode, ok := parseQueryMode(e.Mode); ok {
+		model, _ := m.switchToMode(mode)
+		m = model.(TUIModel)
+	}
+	m.queryInput.SetValue(e.Query)
+	return m.enterInsertMode()
+}
+
+func (m TUIModel) renderHistorySearch() string {
+	accentColor := lipgloss.Color("205")
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(accentColor).
+		MarginBottom(1)
+
+	itemStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("252"))
+
+	cursorStyle := itemStyle.
+		Bold(true).
+		Background(lipgloss.Color("63")).
+		Foreground(lipgloss.Color("231"))
+
+	matchStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(accentColor)
+
+	descStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("245"))
+
+	// Leave room for the box's border and padding
+	width := max(min(m.getTerminalWidth()-8, HistorySearchMaxWidth), 40)
+
+	var content strings.Builder
+	content.WriteString(titleStyle.Render("Query history"))
+	content.WriteString("\n")
+	content.WriteString(m.historySearchInput.View())
+	content.WriteString("\n\n")
+
+	if len(m.historyMatches) == 0 {
+		content.WriteString(descStyle.Render("No matching queries"))
+		content.WriteString("\n")
+	}
+
+	now := time.Now()
+	first := max(0, m.historyCursor-HistorySearchMaxRows+1)
+	for i := first; i < min(len(m.historyMatches), first+HistorySearchMaxRows); i++ {
+		match := m.historyMatches[i]
+		details := fmt.Sprintf(" %d results  %s  %s",
+			match.entry.ResultSize, formatDuration(match.entry.Duration), formatRelative(match.entry.Time, now))
+
+		style := itemStyle
+		if i == m.historyCursor {
+			style = cursorStyle
+		}
+		query := highlightMatches(match.text, match.positions, style, matchStyle.Inherit(style))
+		query = ansi.Truncate(query, width-lipgloss.Width(details)-15, "…")
+		line := style.Render(fmt.Sprintf("%-14s", match.entry.Mode)) + query
+		line += strings.Repeat(" ", max(0, width-lipgloss.Width(line)-lipgloss.Width(details)))
+		content.WriteString(line + descStyle.Render(details) + "\n")
+	}
+
+	content.WriteString("\n")
+	content.WriteString(descStyle.Render("↑/↓: move | enter: edit | esc: close"))
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(accentColor).
+		Padding(1, 2)
+
+	return boxStyle.Render(content.String())
+}
+
+// highlightMatches renders text with the runes at positions in matchStyle
+// and the others in style.
+func highlightMatches(text string, positions []int, style, matchStyle lipgloss.Style) string {
+	matched := make(map[int]bool, len(positions))
+	for _, i := range positions {
+		matched[i] = true
+	}
+
+	var s strings.Builder
+	runes := []rune(text)
+	for start := 0; start < len(runes); {
+		end := start + 1
+		for end < len(runes) && matched[end] == matched[start] {
+			end++
+		}
+		if matched[start] {
+			s.WriteString(matchStyle.Render(string(runes[start:end])))
+		} else {
+			s.WriteString(style.Render(string(runes[start:end])))
+		}
+		start = end
+	}
+	return s.String()
+}
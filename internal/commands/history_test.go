@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akasprzok/peat/internal/history"
+	"github.com/akasprzok/peat/internal/prometheus"
+	"github.com/charmbracelet/bubbles/cursor"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+)
+
+func TestQueryHistory(t *testing.T) {
+	mockClient := &prometheus.MockClient{
+		QueryFunc: func(_ context.Context, _ string, _ time.Time, _ time.Duration, _ bool) (v1.Warnings, model.Value, *prometheus.QueryStats, error) {
+			return nil, model.Vector{{Value: 1}, {Value: 2}}, nil, nil
+		},
+	}
+	store, err := history.Open(filepath.Join(t.TempDir(), "history.jsonl"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewTUIModel(mockClient, time.Hour, 15*time.Second, 100, 60*time.Second).WithHistory(store)
+	m.width = 120
+	m.height = 40
+	m.queryInput.Cursor.SetMode(cursor.CursorStatic)
+
+	// update sends msg to the model, passing on the query results
+	update := func(m TUIModel, msg tea.Msg) TUIModel {
+		updated, cmd := m.Update(msg)
+		m = updated.(TUIModel)
+		for _, msg := range runCmd(cmd) {
+			if msg, ok := msg.(tuiInstantResultMsg); ok {
+				updated, _ = m.Update(msg)
+				m = updated.(TUIModel)
+			}
+		}
+		return m
+	}
+	run := func(m TUIModel, query string) TUIModel {
+		updated, _ := m.enterInsertMode()
+		m = updated.(TUIModel)
+		m.queryInput.SetValue(query)
+		return update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	}
+	up, down := tea.KeyMsg{Type: tea.KeyUp}, tea.KeyMsg{Type: tea.KeyDown}
+
+	for _, query := range []string{"up", "rate(http_requests_total[5m])", "up"} {
+		m = run(m, query)
+	}
+	entries := store.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("history has %d entries, want 2 without the duplicate", len(entries))
+	}
+	if e := entries[0]; e.Query != "up" || e.Mode != "/query" || e.ResultSize != 2 || e.Time.IsZero() {
+		t.Errorf("newest entry = %+v, want up run in /query with 2 results", e)
+	}
+
+	t.Run("recall", func(t *testing.T) {
+		updated, _ := m.enterInsertMode()
+		m := updated.(TUIModel)
+		m.queryInput.SetValue("")
+		var got []string
+		for _, key := range []tea.KeyMsg{up, up, up, down, down} {
+			m = update(m, key)
+			got = append(got, m.queryInput.Value())
+		}
+		if want := "up|rate(http_requests_total[5m])|rate(http_requests_total[5m])|up|"; strings.Join(got, "|") != want {
+			t.Errorf("recalled %q, want %q", strings.Join(got, "|"), want)
+		}
+
+		// Each mode has its own history
+		m = update(m, tea.KeyMsg{Type: tea.KeyTab})
+		if m = update(m, up); m.mode != ModeRange || m.queryInput.Value() != "" {
+			t.Errorf("recalled %q in /query_range", m.queryInput.Value())
+		}
+	})
+
+	t.Run("search", func(t *testing.T) {
+		m := update(m, tea.KeyMsg{Type: tea.KeyTab})
+		if m = update(m, tea.KeyMsg{Type: tea.KeyCtrlR}); !m.showHistorySearch {
+			t.Fatal("ctrl+r didn't open the history search")
+		}
+		m = update(m, runes("rtot"))
+		view := ansi.Strip(m.View())
+		if !strings.Contains(view, "rate(http_requests_total[5m])") || strings.Contains(view, "/query        up") {
+			t.Errorf("search view = %q, want only the matching query", view)
+		}
+
+		m = update(m, tea.KeyMsg{Type: tea.KeyEnter})
+		if m.showHistorySearch || m.mode != ModeInstant || !m.insertMode || m.queryInput.Value() != "rate(http_requests_total[5m])" {
+			t.Errorf("loaded %q in %s, want the query edited in /query", m.queryInput.Value(), m.mode)
+		}
+	})
+}
@@ -0,0 +1,379 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/akasprzok/peat/internal/library"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/prometheus/common/model"
+)
+
+var errNoLibrary = errors.New("no query library: set --library or library in the config file")
+
+// Fields of the save query form
+const (
+	saveFieldName = iota
+	saveFieldDescription
+	saveFieldTags
+
+	saveFieldCount
+)
+
+var saveFieldLabels = [saveFieldCount]string{"Name", "Description", "Tags"}
+
+// saveQueryForm holds the inputs of a query being saved to the library.
+type saveQueryForm struct {
+	inputs [saveFieldCount]textinput.Model
+	focus  int
+	err    error
+}
+
+// libraryQuery is a saved query and the library it comes from.
+type libraryQuery struct {
+	query library.Query
+	team  bool // From the team library, which is never written to
+}
+
+// WithLibrary enables saving queries to the library at path and browsing
+// them along with those of the team library at teamPath, if not empty.
+func (m TUIModel) WithLibrary(path, teamPath string) TUIModel {
+	m.libraryPath = path
+	m.teamLibraryPath = teamPath
+	return m
+}
+
+// openSaveForm opens the form saving the current query. It is prefilled
+// with the name, description and tags the query was saved with, if any.
+func (m TUIModel) openSaveForm() (tea.Model, tea.Cmd) {
+	query := m.queryInput.Value()
+	if strings.TrimSpace(query) == "" {
+		return m, nil
+	}
+
+	var form saveQueryForm
+	for i := range form.inputs {
+		input := textinput.New()
+		input.Width = 60
+		input.Prompt = ""
+		form.inputs[i] = input
+	}
+	form.inputs[saveFieldTags].Placeholder = "api, slo"
+	if m.libraryPath == "" {
+		form.err = errNoLibrary
+	} else if saved, err := library.Load(m.libraryPath); err == nil {
+		for _, q := range saved {
+			if q.Mode == m.mode.String() && q.Query == query {
+				form.inputs[saveFieldName].SetValue(q.Name)
+				form.inputs[saveFieldDescription].SetValue(q.Description)
+				form.inputs[saveFieldTags].SetValue(strings.Join(q.Tags, ", "))
+				break
+			}
+		}
+	}
+
+	m.saveForm = form
+	m.showSaveForm = true
+	return m, m.saveForm.inputs[saveFieldName].Focus()
+}
+
+func (m TUIModel) handleSaveFormKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	form := &m.saveForm
+	switch msg.String() {
+	case "esc":
+		m.showSaveForm = false
+		return m, nil
+	case "tab", "down", "shift+tab", "up":
+		form.inputs[form.focus].Blur()
+		if key := msg.String(); key == "tab" || key == "down" {
+			form.focus = (form.focus + 1) % saveFieldCount
+		} else {
+			form.focus = (form.focus + saveFieldCount - 1) % saveFieldCount
+		}
+		return m, form.inputs[form.focus].Focus()
+	case "enter":
+		if m.libraryPath == "" {
+			form.err = errNoLibrary
+			return m, nil
+		}
+		q, err := m.savedQuery()
+		if err == nil {
+			err = library.Save(m.libraryPath, q)
+		}
+		if form.err = err; err != nil {
+			return m, nil
+		}
+		m.showSaveForm = false
+		m.libraryNotice = fmt.Sprintf("Saved %q", q.Name)
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	form.inputs[form.focus], cmd = form.inputs[form.focus].Update(msg)
+	return m, cmd
+}
+
+// savedQuery returns the current query as described in the save form, with
+// the range parameters of range queries.
+func (m TUIModel) savedQuery() (library.Query, error) {
+	inputs := m.saveForm.inputs
+	name := strings.TrimSpace(inputs[saveFieldName].Value())
+	if name == "" {
+		return library.Query{}, errors.New("name is required")
+	}
+	q := library.Query{
+		Name:        name,
+		Description: strings.TrimSpace(inputs[saveFieldDescription].Value()),
+		Tags: strings.FieldsFunc(inputs[saveFieldTags].Value(), func(r rune) bool {
+			return r == ',' || r == ' '
+		}),
+		Mode:  m.mode.String(),
+		Query: m.queryInput.Value(),
+	}
+	if m.mode == ModeRange {
+		q.Range = model.Duration(m.rangeValue)
+		q.Step = model.Duration(m.stepValue)
+	}
+	return q, nil
+}
+
+// openLibrary reads the personal and team libraries and opens the browser.
+// The libraries are read every time, so changes pulled into the team
+// library's repository show up without restarting.
+func (m TUIModel) openLibrary() (tea.Model, tea.Cmd) {
+	m.libraryQueries = nil
+	var errs []error
+	if m.libraryPath != "" {
+		queries, err := library.Load(m.libraryPath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+		for _, q := range queries {
+			m.libraryQueries = append(m.libraryQueries, libraryQuery{query: q})
+		}
+	}
+	if m.teamLibraryPath != "" {
+		queries, err := library.Load(m.teamLibraryPath)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for _, q := range queries {
+			m.libraryQueries = append(m.libraryQueries, libraryQuery{query: q, team: true})
+		}
+	}
+	m.libraryErr = errors.Join(errs...)
+
+	m.showLibrary = true
+	m.completions = nil
+	m.libraryInput.SetValue("")
+	m = m.filterLibrary()
+	return m, m.libraryInput.Focus()
+}
+
+func (m TUIModel) handleLibraryKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.showLibrary = false
+		m.libraryInput.Blur()
+		return m, nil
+	case "up", "ctrl+p":
+		if m.libraryCursor > 0 {
+			m.libraryCursor--
+		}
+		return m, nil
+	case "down", "ctrl+n":
+		if m.libraryCursor < len(m.libraryMatches)-1 {
+			m.libraryCursor++
+		}
+		return m, nil
+	case "enter":
+		if len(m.libraryMatches) == 0 {
+			return m, nil
+		}
+		return m.loadLibraryQuery(m.libraryMatches[m.libraryCursor].query)
+	}
+
+	var cmd tea.Cmd
+	m.libraryInput, cmd = m.libraryInput.Update(msg)
+	m = m.filterLibrary()
+	return m, cmd
+}
+
+// filterLibrary lists the saved queries matching every word of the filter.
+func (m TUIModel) filterLibrary() TUIModel {
+	m.libraryMatches = nil
+	m.libraryCursor = 0
+	for _, q := range m.libraryQueries {
+		if q.query.Matches(m.libraryInput.Value()) {
+			m.libraryMatches = append(m.libraryMatches, q)
+		}
+	}
+	return m
+}
+
+// loadLibraryQuery switches to the mode of q with its range parameters and
+// edits its query.
+func (m TUIModel) loadLibraryQuery(q library.Query) (tea.Model, tea.Cmd) {
+	m.showLibrary = false
+	m.libraryInput.Blur()
+	if mode, ok := parseQueryMode(q.Mode); ok {
+		model, _ := m.switchToMode(mode)
+		m = model.(TUIModel)
+	}
+	if q.Range > 0 {
+		m.rangeValue = time.Duration(q.Range)
+	}
+	if q.Step > 0 {
+		m.stepValue = time.Duration(q.Step)
+	}
+	m.queryInput.SetValue(q.Query)
+	return m.enterInsertMode()
+}
+
+func (m TUIModel) renderSaveForm() string {
+	accentColor := lipgloss.Color("205")
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(accentColor).
+		MarginBottom(1)
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("245")).Width(13)
+	focusedLabelStyle := labelStyle.Foreground(accentColor).Bold(true)
+	descStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+
+	var content strings.Builder
+	content.WriteString(titleStyle.Render("Save query"))
+	content.WriteString("\n")
+
+	query := strings.Join(strings.Fields(m.queryInput.Value()), " ")
+	params := m.mode.String()
+	if m.mode == ModeRange {
+		params += fmt.Sprintf(", range %s, step %s", model.Duration(m.rangeValue), model.Duration(m.stepValue))
+	}
+	content.WriteString(labelStyle.Render("Query") + " " + ansi.Truncate(query, 60, "…") + "\n")
+	content.WriteString(labelStyle.Render("") + " " + descStyle.Render(params) + "\n\n")
+
+	for i, input := range m.saveForm.inputs {
+		style := labelStyle
+		if i == m.saveForm.focus {
+			style = focusedLabelStyle
+		}
+		content.WriteString(style.Render(saveFieldLabels[i]) + " " + input.View() + "\n")
+	}
+
+	if m.saveForm.err != nil {
+		content.WriteString("\n")
+		content.WriteString(ErrorStyle.Render("Error: ") + m.saveForm.err.Error())
+		content.WriteString("\n")
+	}
+
+	content.WriteString("\n")
+	if m.libraryPath != "" {
+		content.WriteString(descStyle.Render("Saving to " + m.libraryPath + ", replacing a query of the same name"))
+		content.WriteString("\n")
+	}
+	content.WriteString(descStyle.Render("tab: next field | enter: save | esc: cancel"))
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(accentColor).
+		Padding(1, 2)
+
+	return boxStyle.Render(content.String())
+}
+
+func (m TUIModel) renderLibrary() string {
+	accentColor := lipgloss.Color("205")
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(accentColor).
+		MarginBottom(1)
+
+	itemStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("252"))
+
+	cursorStyle := itemStyle.
+		Bold(true).
+		Background(lipgloss.Color("63")).
+		Foreground(lipgloss.Color("231"))
+
+	descStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("245"))
+
+	// Leave room for the box's border and padding
+	width := max(min(m.getTerminalWidth()-8, LibraryMaxWidth), 40)
+
+	var content strings.Builder
+	content.WriteString(titleStyle.Render("Saved queries"))
+	content.WriteString("\n")
+	content.WriteString(m.libraryInput.View())
+	content.WriteString("\n\n")
+
+	if m.libraryErr != nil {
+		content.WriteString(ErrorStyle.Render("Error: ") + m.libraryErr.Error())
+		content.WriteString("\n\n")
+	}
+	if len(m.libraryMatches) == 0 {
+		if len(m.libraryQueries) == 0 {
+			content.WriteString(descStyle.Render("No saved queries yet: press w to save the current query"))
+		} else {
+			content.WriteString(descStyle.Render("No matching queries"))
+		}
+		content.WriteString("\n")
+	}
+
+	first := max(0, m.libraryCursor-LibraryMaxRows+1)
+	for i := first; i < min(len(m.libraryMatches), first+LibraryMaxRows); i++ {
+		q := m.libraryMatches[i].query
+		details := ""
+		for _, tag := range q.Tags {
+			details += " #" + tag
+		}
+		if m.libraryMatches[i].team {
+			details += " (team)"
+		}
+
+		style := itemStyle
+		if i == m.libraryCursor {
+			style = cursorStyle
+		}
+		name := ansi.Truncate(q.Name, width-lipgloss.Width(details)-16, "…")
+		line := style.Render(fmt.Sprintf("%-14s%s", q.Mode, name))
+		line += strings.Repeat(" ", max(0, width-lipgloss.Width(line)-lipgloss.Width(details)))
+		content.WriteString(line + descStyle.Render(details) + "\n")
+	}
+
+	// Details of the selected query
+	if len(m.libraryMatches) > 0 {
+		q := m.libraryMatches[m.libraryCursor].query
+		content.WriteString("\n")
+		if q.Description != "" {
+			content.WriteString(descStyle.Render(ansi.Truncate(q.Description, width, "…")) + "\n")
+		}
+		query := strings.Join(strings.Fields(q.Query), " ")
+		if mode, ok := parseQueryMode(q.Mode); ok && completesPromQL(mode) {
+			query = highlightPromQL(query)
+		}
+		content.WriteString(ansi.Truncate(query, width, "…") + "\n")
+		if q.Range > 0 || q.Step > 0 {
+			content.WriteString(descStyle.Render(fmt.Sprintf("range %s, step %s", q.Range, q.Step)) + "\n")
+		}
+	}
+
+	content.WriteString("\n")
+	content.WriteString(descStyle.Render("↑/↓: move | enter: edit | esc: close"))
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(accentColor).
+		Padding(1, 2)
+
+	return boxStyle.Render(content.String())
+}
@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akasprzok/peat/internal/library"
+	"github.com/akasprzok/peat/internal/prometheus"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestQueryLibrary(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "peat", "queries.yaml")
+	teamPath := filepath.Join(dir, "runbooks", "queries.yaml")
+	if err := os.MkdirAll(filepath.Dir(teamPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(teamPath, []byte(`queries:
+  - name: Targets down
+    tags: [oncall]
+    mode: /query
+    query: up == 0
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewTUIModel(&prometheus.MockClient{}, time.Hour, 15*time.Second, 100, 60*time.Second).WithLibrary(path, teamPath)
+	m.width = 120
+	m.height = 40
+	m.mode = ModeRange
+	m.rangeValue = 6 * time.Hour
+	m.stepValue = 30 * time.Second
+	m.queryInput.SetValue("sum(rate(http_requests_total[5m]))")
+	m.insertMode = false
+
+	press := func(m TUIModel, keys ...tea.KeyMsg) TUIModel {
+		for _, key := range keys {
+			updated, _ := m.Update(key)
+			m = updated.(TUIModel)
+		}
+		return m
+	}
+	tab := tea.KeyMsg{Type: tea.KeyTab}
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+
+	// Save the query with its range parameters
+	if m = press(m, runes("w")); !m.showSaveForm {
+		t.Fatal("w didn't open the save form")
+	}
+	m = press(m, enter)
+	if m.saveForm.err == nil {
+		t.Error("saved a query without a name")
+	}
+	m = press(m, runes("API requests"), tab, runes("Request rate"), tab, runes("api, slo"), enter)
+	if m.showSaveForm || m.saveForm.err != nil {
+		t.Fatalf("save form still open, error %v", m.saveForm.err)
+	}
+	if status := m.renderResultsStatusBar(); !strings.Contains(status, `Saved "API requests"`) {
+		t.Errorf("results status bar = %q, want the save notice", status)
+	}
+	saved, err := library.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 1 || saved[0].Mode != "/query_range" || time.Duration(saved[0].Range) != 6*time.Hour ||
+		time.Duration(saved[0].Step) != 30*time.Second || strings.Join(saved[0].Tags, ",") != "api,slo" {
+		t.Fatalf("library = %+v, want the range query with its parameters", saved)
+	}
+
+	// Saving it again starts from its saved description
+	if m = press(m, runes("w")); m.saveForm.inputs[saveFieldDescription].Value() != "Request rate" {
+		t.Errorf("save form description = %q, want the saved one", m.saveForm.inputs[saveFieldDescription].Value())
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyEsc})
+
+	// Browse both libraries and load the saved query in another mode
+	m = press(m, runes("1"))
+	m.rangeValue = time.Hour
+	if m = press(m, runes("L")); !m.showLibrary || len(m.libraryMatches) != 2 {
+		t.Fatalf("library shows %d queries, want the saved and the team one", len(m.libraryMatches))
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "Targets down") || !strings.Contains(view, "(team)") {
+		t.Errorf("library view = %q, want the team query", view)
+	}
+	if m = press(m, runes("slo api")); len(m.libraryMatches) != 1 {
+		t.Fatalf("library shows %d queries for slo api, want 1", len(m.libraryMatches))
+	}
+
+	m = press(m, enter)
+	if m.showLibrary || m.mode != ModeRange || !m.insertMode || m.queryInput.Value() != "sum(rate(http_requests_total[5m]))" {
+		t.Errorf("loaded %q in %s, want the saved query edited in /query_range", m.queryInput.Value(), m.mode)
+	}
+	if m.rangeValue != 6*time.Hour || m.stepValue != 30*time.Second {
+		t.Errorf("range %s and step %s, want the saved 6h and 30s", m.rangeValue, m.stepValue)
+	}
+}
@@ -1,11 +1,13 @@
 package commands
 
 import (
+	"context"
+
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 // Mode defines the interface for query mode implementations.
-// Each mode (Instant, Range, Series, Labels) implements this interface
+// Each mode (Instant, Range, Series, Labels, Metadata, Targets, Rules, TSDB, Status, Alertmanager) implements this interface
 // to handle mode-specific logic for interactive behavior, query execution,
 // and rendering.
 type Mode interface {
@@ -18,8 +20,9 @@ type Mode interface {
 	// HandleLegendKey handles navigation keys when in interactive/legend mode
 	HandleLegendKey(m *TUIModel, msg tea.KeyMsg) tea.Cmd
 
-	// ExecuteQuery executes the appropriate query for this mode
-	ExecuteQuery(m *TUIModel) tea.Cmd
+	// ExecuteQuery executes the appropriate query for this mode.
+	// The query is abandoned when ctx is cancelled.
+	ExecuteQuery(ctx context.Context, m *TUIModel) tea.Cmd
 
 	// RenderStatusParams returns the mode-specific parameters for the status bar
 	RenderStatusParams(m *TUIModel) string
@@ -36,10 +39,16 @@ type Mode interface {
 
 // Mode registry - maps QueryMode to Mode implementations
 var modes = map[QueryMode]Mode{
-	ModeInstant: InstantMode{},
-	ModeRange:   RangeMode{},
-	ModeSeries:  SeriesMode{},
-	ModeLabels:  LabelsMode{},
+	ModeInstant:      InstantMode{},
+	ModeRange:        RangeMode{},
+	ModeSeries:       SeriesMode{},
+	ModeLabels:       LabelsMode{},
+	ModeMetadata:     MetadataMode{},
+	ModeTargets:      TargetsMode{},
+	ModeRules:        RulesMode{},
+	ModeTSDB:         TSDBMode{},
+	ModeStatus:       StatusMode{},
+	ModeAlertmanager: AlertmanagerMode{},
 }
 
 // currentMode returns the Mode implementation for the current mode
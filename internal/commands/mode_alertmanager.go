@@ -0,0 +1,378 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/akasprzok/peat/internal/prometheus"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// errNoAlertmanager is returned by Alertmanager queries and writes when the
+// datasource has no Alertmanager.
+var errNoAlertmanager = errors.New("no Alertmanager configured: set alertmanager_url on the datasource or --alertmanager-url")
+
+// alertmanagerError returns why the datasource has no Alertmanager client:
+// none is configured, or the configured one couldn't be created.
+func (m TUIModel) alertmanagerError() error {
+	if m.alertmanagerErr != nil {
+		return m.alertmanagerErr
+	}
+	return errNoAlertmanager
+}
+
+// alertmanagerView is one of the tables of the Alertmanager mode.
+type alertmanagerView int
+
+const (
+	alertmanagerAlerts alertmanagerView = iota
+	alertmanagerSilences
+
+	// alertmanagerViewCount is the number of views. Keep it last.
+	alertmanagerViewCount
+)
+
+func (v alertmanagerView) String() string {
+	if v == alertmanagerSilences {
+		return "Silences"
+	}
+	return "Alerts"
+}
+
+// Silence states reported by the Alertmanager
+const (
+	silenceActive  = "active"
+	silencePending = "pending"
+	silenceExpired = "expired"
+)
+
+// alertRow is a row of the alerts table: either the header of an
+// Alertmanager route group or one of its alerts.
+type alertRow struct {
+	group       bool
+	name        string            // receiver and group labels for groups, alertname for alerts
+	state       string            // alert counts for groups, alert state otherwise
+	labels      map[string]string // group labels for groups, alert labels otherwise
+	annotations map[string]string
+	startsAt    time.Time
+	silencedBy  []string
+}
+
+// buildAlertRows flattens alert groups into table rows, sorted by receiver.
+// Alerts are kept when their labels or receiver contain filter
+// (case-insensitive). Groups without remaining alerts are dropped.
+func buildAlertRows(groups []prometheus.AlertGroup, filter string) []alertRow {
+	filter = strings.ToLower(strings.TrimSpace(filter))
+
+	groups = append([]prometheus.AlertGroup(nil), groups...)
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Receiver.Name != groups[j].Receiver.Name {
+			return groups[i].Receiver.Name < groups[j].Receiver.Name
+		}
+		return formatLabels(groups[i].Labels) < formatLabels(groups[j].Labels)
+	})
+
+	var rows []alertRow
+	for _, group := range groups {
+		receiverMatches := filter == "" || strings.Contains(strings.ToLower(group.Receiver.Name), filter)
+
+		var alertRows []alertRow
+		states := make(map[string]int)
+		for _, alert := range group.Alerts {
+			if !receiverMatches && !strings.Contains(strings.ToLower(formatLabels(alert.Labels)), filter) {
+				continue
+			}
+			states[alert.Status.State]++
+			alertRows = append(alertRows, alertRow{
+				name:        alert.Labels["alertname"],
+				state:       alert.Status.State,
+				labels:      alert.Labels,
+				annotations: alert.Annotations,
+				startsAt:    alert.StartsAt,
+				silencedBy:  alert.Status.SilencedBy,
+			})
+		}
+		if len(alertRows) == 0 {
+			continue
+		}
+
+		// Active alerts first, then by name
+		sort.SliceStable(alertRows, func(i, j int) bool {
+			if (alertRows[i].state == "active") != (alertRows[j].state == "active") {
+				return alertRows[i].state == "active"
+			}
+			if alertRows[i].name != alertRows[j].name {
+				return alertRows[i].name < alertRows[j].name
+			}
+			return formatLabels(alertRows[i].labels) < formatLabels(alertRows[j].labels)
+		})
+
+		counts := make([]string, 0, len(states))
+		for _, state := range []string{"active", "suppressed", "unprocessed"} {
+			if states[state] > 0 {
+				counts = append(counts, fmt.Sprintf("%d %s", states[state], state))
+			}
+		}
+		rows = append(rows, alertRow{
+			group:  true,
+			name:   strings.TrimSpace(group.Receiver.Name + " " + formatLabels(group.Labels)),
+			state:  strings.Join(counts, ", "),
+			labels: group.Labels,
+		})
+		rows = append(rows, alertRows...)
+	}
+	return rows
+}
+
+// filterSilences returns the silences whose ID, matchers, author or comment
+// contain filter (case-insensitive). Active silences come first, then
+// pending and expired ones, each ordered by end time.
+func filterSilences(silences []prometheus.Silence, filter string) []prometheus.Silence {
+	filter = strings.ToLower(strings.TrimSpace(filter))
+
+	result := make([]prometheus.Silence, 0, len(silences))
+	for _, silence := range silences {
+		text := strings.ToLower(strings.Join([]string{
+			silence.ID, formatMatchers(silence.Matchers), silence.CreatedBy, silence.Comment,
+		}, " "))
+		if filter == "" || strings.Contains(text, filter) {
+			result = append(result, silence)
+		}
+	}
+
+	rank := map[string]int{silenceActive: 0, silencePending: 1, silenceExpired: 2}
+	sort.SliceStable(result, func(i, j int) bool {
+		ri, rj := rank[result[i].Status.State], rank[result[j].Status.State]
+		if ri != rj {
+			return ri < rj
+		}
+		if result[i].Status.State == silenceExpired {
+			// Most recently expired first
+			return result[i].EndsAt.After(result[j].EndsAt)
+		}
+		return result[i].EndsAt.Before(result[j].EndsAt)
+	})
+	return result
+}
+
+// formatLabels formats labels as a sorted PromQL selector, with alertname first.
+func formatLabels(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == "alertname") != (names[j] == "alertname") {
+			return names[i] == "alertname"
+		}
+		return names[i] < names[j]
+	})
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf("%s=%q", name, labels[name])
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// formatMatchers formats silence matchers as a PromQL selector.
+func formatMatchers(matchers []prometheus.Matcher) string {
+	parts := make([]string, len(matchers))
+	for i, matcher := range matchers {
+		parts[i] = matcher.String()
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+// formatAge formats a duration in its two largest units, e.g. 3h12m or 2d4h.
+func formatAge(d time.Duration) string {
+	d = d.Truncate(time.Second)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%ds", int(d.Minutes()), int(d.Seconds())%60)
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dd%dh", int(d.Hours())/24, int(d.Hours())%24)
+	}
+}
+
+// formatRelative formats t relative to now, e.g. "3m0s ago" or "in 1h59m".
+func formatRelative(t, now time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	if t.After(now) {
+		return "in " + formatAge(t.Sub(now))
+	}
+	return formatAge(now.Sub(t)) + " ago"
+}
+
+// AlertmanagerMode lists alerts and silences of the datasource's Alertmanager (/alertmanager)
+type AlertmanagerMode struct{}
+
+func (AlertmanagerMode) Name() string {
+	return "10) /alertmanager"
+}
+
+func (AlertmanagerMode) HandleInteractiveToggle(m *TUIModel) tea.Cmd {
+	m.legendFocused = !m.legendFocused
+	if m.legendFocused {
+		m.focusedPane = PaneLegend
+		m.queryInput.Blur()
+		m.alertmanagerTable = m.alertmanagerTable.Focused(true)
+	} else {
+		m.focusedPane = PaneQuery
+		// Stay in normal mode - don't focus query input
+		m.alertmanagerTable = m.alertmanagerTable.Focused(false)
+	}
+	return nil
+}
+
+func (AlertmanagerMode) HandleLegendKey(m *TUIModel, msg tea.KeyMsg) tea.Cmd {
+	key := msg.String()
+
+	switch key {
+	case "q":
+		return tea.Quit
+	case "i", "esc":
+		// Exit interactive mode
+		m.legendFocused = false
+		m.focusedPane = PaneQuery
+		m.alertmanagerTable = m.alertmanagerTable.Focused(false)
+		return nil
+	case "]", "[":
+		m.alertmanagerView = (m.alertmanagerView + 1) % alertmanagerViewCount
+		m.alertmanagerTable = m.alertmanagerTable.WithHighlightedRow(0)
+		*m = m.refreshAlertmanager()
+		return nil
+	case "S":
+		// Silence the selected alert or group, or re-create the selected silence
+		index := m.alertmanagerTable.GetHighlightedRowIndex()
+		switch {
+		case m.alertmanagerView == alertmanagerAlerts && index >= 0 && index < len(m.alertRows):
+			return m.openSilenceForm(formatLabels(m.alertRows[index].labels))
+		case m.alertmanagerView == alertmanagerSilences && index >= 0 && index < len(m.silenceRows):
+			return m.openSilenceForm(formatMatchers(m.silenceRows[index].Matchers))
+		}
+		return nil
+	case "x":
+		// Expire the selected silence
+		index := m.alertmanagerTable.GetHighlightedRowIndex()
+		if m.alertmanagerView != alertmanagerSilences || index < 0 || index >= len(m.silenceRows) {
+			return nil
+		}
+		m.confirmExpireSilence(m.silenceRows[index])
+		return nil
+	}
+
+	// Handle table navigation
+	var tableCmd tea.Cmd
+	switch key {
+	case "j":
+		m.alertmanagerTable, tableCmd = m.alertmanagerTable.Update(tea.KeyMsg{Type: tea.KeyDown})
+	case "k":
+		m.alertmanagerTable, tableCmd = m.alertmanagerTable.Update(tea.KeyMsg{Type: tea.KeyUp})
+	case "h":
+		m.alertmanagerTable, tableCmd = m.alertmanagerTable.Update(tea.KeyMsg{Type: tea.KeyPgUp})
+	case "l":
+		m.alertmanagerTable, tableCmd = m.alertmanagerTable.Update(tea.KeyMsg{Type: tea.KeyPgDown})
+	default:
+		m.alertmanagerTable, tableCmd = m.alertmanagerTable.Update(msg)
+	}
+	return tableCmd
+}
+
+func (AlertmanagerMode) ExecuteQuery(ctx context.Context, m *TUIModel) tea.Cmd {
+	return m.executeAlertmanagerQuery(ctx)
+}
+
+func (AlertmanagerMode) RenderStatusParams(m *TUIModel) string {
+	if filter := strings.TrimSpace(m.modeQueries[ModeAlertmanager]); filter != "" {
+		return fmt.Sprintf("   Filter: %s", filter)
+	}
+	return ""
+}
+
+func (AlertmanagerMode) RenderResultsContent(m *TUIModel) string {
+	var s strings.Builder
+
+	// Result of the last silence write
+	switch {
+	case m.silenceErr != nil:
+		s.WriteString(" " + ErrorStyle.Render("Error: ") + m.silenceErr.Error() + "\n")
+	case m.silenceNotice != "":
+		s.WriteString(" " + SuccessStyle.Render(m.silenceNotice) + "\n")
+	}
+
+	// View tabs
+	tabStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
+	activeTabStyle := tabStyle.Bold(true).Background(lipgloss.Color("63")).Foreground(lipgloss.Color("231"))
+	tabs := make([]string, 0, alertmanagerViewCount)
+	for view := range alertmanagerViewCount {
+		style := tabStyle
+		if view == m.alertmanagerView {
+			style = activeTabStyle
+		}
+		tabs = append(tabs, style.Render(" "+view.String()+" "))
+	}
+	s.WriteString(" " + strings.Join(tabs, " ") + "\n")
+
+	tableStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("63")).
+		Padding(0, 1)
+
+	if m.legendFocused {
+		tableStyle = tableStyle.BorderForeground(lipgloss.Color("205"))
+	}
+
+	s.WriteString(tableStyle.Render(m.alertmanagerTable.View()))
+	s.WriteString("\n")
+	return s.String()
+}
+
+func (AlertmanagerMode) RenderResultsStatusBar(m *TUIModel) string {
+	var alerts, suppressed, silences int
+	for _, group := range m.alertGroups {
+		for _, alert := range group.Alerts {
+			alerts++
+			if alert.Status.State == "suppressed" {
+				suppressed++
+			}
+		}
+	}
+	for _, silence := range m.silences {
+		if silence.Status.State == silenceActive {
+			silences++
+		}
+	}
+	return fmt.Sprintf(" | Alerts: %d | Suppressed: %d | Active silences: %d", alerts, suppressed, silences)
+}
+
+func (AlertmanagerMode) OnSwitchTo(m *TUIModel) {
+	if m.currentState() == StateResults {
+		*m = m.refreshAlertmanager()
+	}
+}
+
+// refreshAlertmanager re-applies the filter to the fetched alerts and
+// silences and re-renders the table of the current view.
+func (m TUIModel) refreshAlertmanager() TUIModel {
+	filter := m.modeQueries[ModeAlertmanager]
+	m.alertRows = buildAlertRows(m.alertGroups, filter)
+	m.silenceRows = filterSilences(m.silences, filter)
+	if m.alertmanagerView == alertmanagerSilences {
+		m = m.renderSilencesTable()
+	} else {
+		m = m.renderAlertsTable()
+	}
+	return m.syncViewportContent()
+}
@@ -0,0 +1,329 @@
+package commands
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akasprzok/peat/internal/config"
+	"github.com/akasprzok/peat/internal/prometheus"
+	"github.com/akasprzok/peat/internal/prometheus/prometheustest"
+	tea "github.com/charmbracelet/bubbletea"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+)
+
+func testAlertGroups(now time.Time) []prometheus.AlertGroup {
+	return []prometheus.AlertGroup{
+		{
+			Labels:   map[string]string{"alertname": "DiskFull"},
+			Receiver: prometheus.Receiver{Name: "team-b"},
+			Alerts: []prometheus.Alert{
+				{Labels: map[string]string{"alertname": "DiskFull", "instance": "db:9100"}, StartsAt: now.Add(-time.Hour), Status: prometheus.AlertStatus{State: "active"}},
+			},
+		},
+		{
+			Labels:   map[string]string{"alertname": "HighLatency"},
+			Receiver: prometheus.Receiver{Name: "team-a"},
+			Alerts: []prometheus.Alert{
+				{Labels: map[string]string{"alertname": "HighLatency", "job": "web"}, Status: prometheus.AlertStatus{State: "suppressed", SilencedBy: []string{"abc"}}},
+				{Labels: map[string]string{"alertname": "HighLatency", "job": "api"}, Status: prometheus.AlertStatus{State: "active"}},
+			},
+		},
+	}
+}
+
+func TestBuildAlertRows(t *testing.T) {
+	groups := testAlertGroups(time.Now())
+
+	t.Run("groups are sorted by receiver with active alerts first", func(t *testing.T) {
+		rows := buildAlertRows(groups, "")
+		var got []string
+		for _, r := range rows {
+			if r.group {
+				got = append(got, r.name+" ("+r.state+")")
+			} else {
+				got = append(got, "  "+r.labels["job"]+r.labels["instance"])
+			}
+		}
+		want := []string{
+			`team-a {alertname="HighLatency"} (1 active, 1 suppressed)`, "  api", "  web",
+			`team-b {alertname="DiskFull"} (1 active)`, "  db:9100",
+		}
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("buildAlertRows() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+	})
+
+	t.Run("filter matches labels or receiver", func(t *testing.T) {
+		if rows := buildAlertRows(groups, `job="WEB"`); len(rows) != 2 || rows[1].labels["job"] != "web" {
+			t.Errorf("buildAlertRows(job=web) = %+v", rows)
+		}
+		if rows := buildAlertRows(groups, "team-b"); len(rows) != 2 || rows[1].name != "DiskFull" {
+			t.Errorf("buildAlertRows(team-b) = %+v", rows)
+		}
+	})
+}
+
+func TestFilterSilences(t *testing.T) {
+	now := time.Now()
+	silences := []prometheus.Silence{
+		{ID: "expired-old", EndsAt: now.Add(-2 * time.Hour), Status: prometheus.SilenceStatus{State: silenceExpired}},
+		{ID: "active-late", EndsAt: now.Add(2 * time.Hour), Status: prometheus.SilenceStatus{State: silenceActive}},
+		{ID: "expired-new", EndsAt: now.Add(-time.Hour), Status: prometheus.SilenceStatus{State: silenceExpired}},
+		{ID: "pending", EndsAt: now.Add(time.Hour), Status: prometheus.SilenceStatus{State: silencePending}},
+		{ID: "active-soon", EndsAt: now.Add(time.Hour), Status: prometheus.SilenceStatus{State: silenceActive}, Comment: "Deploy"},
+	}
+
+	var got []string
+	for _, s := range filterSilences(silences, "") {
+		got = append(got, s.ID)
+	}
+	want := "active-soon active-late pending expired-new expired-old"
+	if strings.Join(got, " ") != want {
+		t.Errorf("filterSilences() = %v, want %s", got, want)
+	}
+
+	if filtered := filterSilences(silences, "deploy"); len(filtered) != 1 || filtered[0].ID != "active-soon" {
+		t.Errorf("filterSilences(deploy) = %+v", filtered)
+	}
+}
+
+func TestParseSilenceMatchers(t *testing.T) {
+	matchers, err := parseSilenceMatchers(`alertname="HighLatency", job=~"api.*", env!="dev"`)
+	if err != nil {
+		t.Fatalf("parseSilenceMatchers() error = %v", err)
+	}
+	if got := formatMatchers(matchers); got != `{alertname="HighLatency", job=~"api.*", env!="dev"}` {
+		t.Errorf("parseSilenceMatchers() = %s", got)
+	}
+
+	for _, input := range []string{"", "{}", `{job="api"`} {
+		if _, err := parseSilenceMatchers(input); err == nil {
+			t.Errorf("parseSilenceMatchers(%q) error = nil, want error", input)
+		}
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{42 * time.Second, "42s"},
+		{3*time.Minute + 5*time.Second, "3m5s"},
+		{2*time.Hour + 30*time.Minute, "2h30m"},
+		{50 * time.Hour, "2d2h"},
+	}
+	for _, tt := range tests {
+		if got := formatAge(tt.d); got != tt.want {
+			t.Errorf("formatAge(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestAlertmanagerMode(t *testing.T) {
+	now := time.Now()
+	fake := prometheustest.NewAlertmanager(testAlertGroups(now), []prometheus.Silence{{
+		ID:        "11111111-2222-3333-4444-555555555555",
+		Matchers:  []prometheus.Matcher{{Name: "job", Value: "web", IsEqual: true}},
+		StartsAt:  now.Add(-time.Hour),
+		EndsAt:    now.Add(time.Hour),
+		CreatedBy: "bob",
+		Comment:   "maintenance",
+		Status:    prometheus.SilenceStatus{State: silenceActive},
+	}})
+	defer fake.Close()
+
+	seriesClient := &prometheus.MockClient{
+		SeriesFunc: func(_ context.Context, _ []string, _, _ time.Time, _ uint64, _ time.Duration) ([]model.LabelSet, v1.Warnings, error) {
+			return []model.LabelSet{{"__name__": "up", "job": "api", "instance": "api:8080"}}, nil, nil
+		},
+	}
+
+	newModel := func(t *testing.T) TUIModel {
+		t.Helper()
+		t.Setenv("USER", "alice")
+		m := NewTUIModel(seriesClient, time.Hour, 15*time.Second, 100, 60*time.Second).
+			WithDatasources([]config.Datasource{{Name: "local", URL: "http://localhost:9090", AlertmanagerURL: fake.URL}}, 0, nil)
+		m.width = 160
+		m.height = 50
+		m.insertMode = false
+		return m
+	}
+
+	// run executes cmd and feeds the query and silence results back into
+	// the model, like the Bubble Tea runtime. Other messages are dropped.
+	var run func(m TUIModel, cmd tea.Cmd) TUIModel
+	run = func(m TUIModel, cmd tea.Cmd) TUIModel {
+		if cmd == nil {
+			return m
+		}
+		switch msg := cmd().(type) {
+		case tea.BatchMsg:
+			for _, c := range msg {
+				m = run(m, c)
+			}
+		case tuiAlertmanagerResultMsg, tuiSeriesResultMsg, tuiSilenceWriteMsg:
+			updated, next := m.Update(msg)
+			m = run(updated.(TUIModel), next)
+		}
+		return m
+	}
+
+	press := func(m TUIModel, keys ...tea.KeyMsg) TUIModel {
+		for _, key := range keys {
+			updated, cmd := m.Update(key)
+			m = run(updated.(TUIModel), cmd)
+		}
+		return m
+	}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+	typeText := func(s string) []tea.KeyMsg {
+		keys := make([]tea.KeyMsg, 0, len(s))
+		for _, r := range s {
+			keys = append(keys, runes(string(r)))
+		}
+		return keys
+	}
+
+	t.Run("lists alerts and silences", func(t *testing.T) {
+		m := press(newModel(t), runes("0"), tea.KeyMsg{Type: tea.KeyEnter})
+		if m.mode != ModeAlertmanager || m.currentState() != StateResults {
+			t.Fatalf("mode = %v, state = %v, want /alertmanager results", m.mode, m.currentState())
+		}
+		if len(m.alertRows) != 5 || len(m.silenceRows) != 1 {
+			t.Fatalf("alertRows = %d, silenceRows = %d, want 5 and 1", len(m.alertRows), len(m.silenceRows))
+		}
+		if status := m.renderResultsStatusBar(); !strings.Contains(status, "Alerts: 3 | Suppressed: 1 | Active silences: 1") {
+			t.Errorf("status bar = %q", status)
+		}
+	})
+
+	t.Run("creating a silence from an alert requires confirmation", func(t *testing.T) {
+		m := press(newModel(t), runes("0"), tea.KeyMsg{Type: tea.KeyEnter})
+		m = press(m, runes("i"), runes("j"), runes("S"))
+		if !m.showSilenceForm {
+			t.Fatal("silence form not shown")
+		}
+		if got := m.silenceForm.inputs[silenceFieldMatchers].Value(); got != `{alertname="HighLatency", job="api"}` {
+			t.Errorf("matchers = %s, want the alert's labels", got)
+		}
+		if got := m.silenceForm.inputs[silenceFieldAuthor].Value(); got != "alice" {
+			t.Errorf("author = %q, want alice", got)
+		}
+
+		// Submitting without a comment is rejected
+		m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+		if m.confirm != nil || m.silenceForm.err == nil {
+			t.Fatal("silence without a comment was accepted")
+		}
+
+		m = press(m, typeText("deploying")...)
+		m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+		if m.confirm == nil || !strings.Contains(m.confirm.prompt, `job="api"`) {
+			t.Fatalf("confirm = %+v, want a confirmation prompt", m.confirm)
+		}
+
+		// Declining keeps the form open and writes nothing
+		m = press(m, runes("n"))
+		if m.confirm != nil || !m.showSilenceForm || len(fake.Silences()) != 1 {
+			t.Fatal("declining the confirmation did not cancel the write")
+		}
+
+		m = press(m, tea.KeyMsg{Type: tea.KeyEnter}, runes("y"))
+		silences := fake.Silences()
+		if len(silences) != 2 {
+			t.Fatalf("silences = %d, want 2", len(silences))
+		}
+		created := silences[1]
+		if created.CreatedBy != "alice" || created.Comment != "deploying" || created.EndsAt.Sub(created.StartsAt) != 2*time.Hour {
+			t.Errorf("created silence = %+v", created)
+		}
+		if m.showSilenceForm || m.alertmanagerView != alertmanagerSilences || len(m.silenceRows) != 2 {
+			t.Errorf("after creating: form = %v, view = %v, silences = %d", m.showSilenceForm, m.alertmanagerView, len(m.silenceRows))
+		}
+		if !strings.Contains(m.silenceNotice, created.ID) {
+			t.Errorf("notice = %q, want the silence ID", m.silenceNotice)
+		}
+	})
+
+	t.Run("expiring a silence requires confirmation", func(t *testing.T) {
+		m := press(newModel(t), runes("0"), tea.KeyMsg{Type: tea.KeyEnter})
+		m = press(m, runes("i"), runes("]"), runes("x"))
+		if m.confirm == nil || !strings.Contains(m.confirm.prompt, "11111111") {
+			t.Fatalf("confirm = %+v, want an expire prompt", m.confirm)
+		}
+		for _, r := range fake.Requests() {
+			if r.Method == http.MethodDelete {
+				t.Fatal("silence expired before confirmation")
+			}
+		}
+
+		m = press(m, runes("y"))
+		if state := fake.Silences()[0].Status.State; state != silenceExpired {
+			t.Errorf("state = %q, want expired", state)
+		}
+		if m.silenceErr != nil || !strings.Contains(m.silenceNotice, "Expired") {
+			t.Errorf("notice = %q, err = %v", m.silenceNotice, m.silenceErr)
+		}
+	})
+
+	t.Run("silencing a series drops the metric name", func(t *testing.T) {
+		m := newModel(t)
+		m = press(m, runes("3"), runes("/"))
+		m = press(m, typeText("up")...)
+		m = press(m, tea.KeyMsg{Type: tea.KeyEnter}, runes("i"), runes("S"))
+		if !m.showSilenceForm {
+			t.Fatal("silence form not shown")
+		}
+		if got := m.silenceForm.inputs[silenceFieldMatchers].Value(); got != `{instance="api:8080", job="api"}` {
+			t.Errorf("matchers = %s", got)
+		}
+	})
+
+	t.Run("without an Alertmanager the mode reports an error", func(t *testing.T) {
+		m := NewTUIModel(seriesClient, time.Hour, 15*time.Second, 100, 60*time.Second)
+		m.insertMode = false
+		m = press(m, runes("0"), tea.KeyMsg{Type: tea.KeyEnter})
+		if m.currentState() != StateError || m.currentError() != errNoAlertmanager {
+			t.Errorf("state = %v, err = %v, want errNoAlertmanager", m.currentState(), m.currentError())
+		}
+	})
+
+	t.Run("an invalid Alertmanager config is reported", func(t *testing.T) {
+		m := NewTUIModel(seriesClient, time.Hour, 15*time.Second, 100, 60*time.Second).
+			WithDatasources([]config.Datasource{{
+				Name:             "local",
+				URL:              "http://localhost:9090",
+				AlertmanagerURL:  fake.URL,
+				AlertmanagerAuth: config.AuthConfig{BearerToken: "secret", BearerTokenFile: "/var/run/token"},
+			}}, 0, nil)
+		m.insertMode = false
+		m = press(m, runes("0"), tea.KeyMsg{Type: tea.KeyEnter})
+		if err := m.currentError(); m.currentState() != StateError || err == nil || !strings.Contains(err.Error(), "alertmanager: bearer token") {
+			t.Errorf("state = %v, err = %v, want the Alertmanager config error", m.currentState(), err)
+		}
+	})
+
+	t.Run("the datasource's credentials aren't sent to the Alertmanager", func(t *testing.T) {
+		m := NewTUIModel(seriesClient, time.Hour, 15*time.Second, 100, 60*time.Second).
+			WithDatasources([]config.Datasource{{
+				Name:            "local",
+				URL:             "http://localhost:9090",
+				Auth:            config.AuthConfig{BearerToken: "prometheus-secret"},
+				AlertmanagerURL: fake.URL,
+			}}, 0, nil)
+		m.insertMode = false
+		m = press(m, runes("0"), tea.KeyMsg{Type: tea.KeyEnter})
+		if m.currentState() != StateResults {
+			t.Fatalf("state = %v, err = %v", m.currentState(), m.currentError())
+		}
+		requests := fake.Requests()
+		if got := requests[len(requests)-1].Header.Get("Authorization"); got != "" {
+			t.Errorf("Authorization = %q sent to the Alertmanager, want none", got)
+		}
+	})
+}
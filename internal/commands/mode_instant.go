@@ -1,11 +1,13 @@
 package commands
 
 import (
+	"context"
+	"fmt"
 	"strings"
 
-	"github.com/akasprzok/peat/internal/charts"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/prometheus/common/model"
 )
 
 // InstantMode handles instant query mode (/query)
@@ -25,13 +27,12 @@ func (InstantMode) HandleLegendKey(m *TUIModel, msg tea.KeyMsg) tea.Cmd {
 	return nil
 }
 
-func (InstantMode) ExecuteQuery(m *TUIModel) tea.Cmd {
-	return m.executeInstantQuery()
+func (InstantMode) ExecuteQuery(ctx context.Context, m *TUIModel) tea.Cmd {
+	return m.executeInstantQuery(ctx)
 }
 
 func (InstantMode) RenderStatusParams(m *TUIModel) string {
-	// No additional params for instant mode
-	return ""
+	return "   Time: " + m.evalTime.String()
 }
 
 func (InstantMode) RenderResultsContent(m *TUIModel) string {
@@ -62,13 +63,36 @@ func (InstantMode) RenderResultsContent(m *TUIModel) string {
 }
 
 func (InstantMode) RenderResultsStatusBar(m *TUIModel) string {
-	// No additional status bar content for instant mode
-	return ""
+	switch v := m.instantValue.(type) {
+	case model.Vector:
+		return fmt.Sprintf(" | Samples: %d", len(v))
+	case model.Matrix:
+		return fmt.Sprintf(" | Series: %d", len(v))
+	case nil:
+		return ""
+	default:
+		return " | Type: " + v.Type().String()
+	}
+}
+
+// valueSize returns the number of samples or series of an instant query
+// result, 1 for a scalar or string.
+func valueSize(value model.Value) int {
+	switch v := value.(type) {
+	case model.Vector:
+		return len(v)
+	case model.Matrix:
+		return len(v)
+	case nil:
+		return 0
+	default:
+		return 1
+	}
 }
 
 func (InstantMode) OnSwitchTo(m *TUIModel) {
 	if m.currentState() == StateResults {
-		m.chartContent = charts.Barchart(m.vector, m.getChartWidth())
+		*m = m.renderInstantChart()
 		*m = m.syncViewportContent()
 	}
 }
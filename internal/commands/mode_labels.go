@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -61,8 +62,8 @@ func (LabelsMode) HandleLegendKey(m *TUIModel, msg tea.KeyMsg) tea.Cmd {
 				if labelName, ok := highlightedRow.Data["label"].(string); ok {
 					m.selectedLabelIndex = m.labelsTable.GetHighlightedRowIndex()
 					m.selectedLabelName = labelName
-					m.modeStates[ModeLabels] = StateLoading
-					return m.executeLabelValuesQuery(labelName)
+					ctx := m.startLoading(ModeLabels)
+					return tea.Batch(m.executeLabelValuesQuery(ctx, labelName), m.spinner.Tick)
 				}
 			}
 		}
@@ -86,8 +87,8 @@ func (LabelsMode) HandleLegendKey(m *TUIModel, msg tea.KeyMsg) tea.Cmd {
 	return tableCmd
 }
 
-func (LabelsMode) ExecuteQuery(m *TUIModel) tea.Cmd {
-	return m.executeLabelsQuery()
+func (LabelsMode) ExecuteQuery(ctx context.Context, m *TUIModel) tea.Cmd {
+	return m.executeLabelsQuery(ctx)
 }
 
 func (LabelsMode) RenderStatusParams(m *TUIModel) string {
@@ -0,0 +1,138 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+)
+
+// metricMetadata is the HELP, TYPE and UNIT of a single metric.
+type metricMetadata struct {
+	name       string
+	metricType v1.MetricType
+	unit       string
+	help       string
+}
+
+// starterQuery returns a sensible first query to graph the metric with.
+func (md metricMetadata) starterQuery() string {
+	switch md.metricType {
+	case v1.MetricTypeCounter:
+		return fmt.Sprintf("rate(%s[5m])", md.name)
+	case v1.MetricTypeHistogram:
+		return fmt.Sprintf("histogram_quantile(0.95, sum by (le) (rate(%s_bucket[5m])))", md.name)
+	case v1.MetricTypeGaugeHistogram:
+		return fmt.Sprintf("histogram_quantile(0.95, sum by (le) (%s_bucket))", md.name)
+	case v1.MetricTypeSummary:
+		return fmt.Sprintf("rate(%[1]s_sum[5m]) / rate(%[1]s_count[5m])", md.name)
+	default:
+		return md.name
+	}
+}
+
+// MetadataMode handles metric metadata mode (/metadata)
+type MetadataMode struct{}
+
+func (MetadataMode) Name() string {
+	return "5) /metadata"
+}
+
+func (MetadataMode) HandleInteractiveToggle(m *TUIModel) tea.Cmd {
+	if len(m.metadata) == 0 {
+		return nil
+	}
+
+	m.legendFocused = !m.legendFocused
+	if m.legendFocused {
+		m.focusedPane = PaneLegend
+		m.queryInput.Blur()
+		m.metadataTable = m.metadataTable.Focused(true)
+	} else {
+		m.focusedPane = PaneQuery
+		// Stay in normal mode - don't focus query input
+		m.metadataTable = m.metadataTable.Focused(false)
+	}
+	return nil
+}
+
+func (MetadataMode) HandleLegendKey(m *TUIModel, msg tea.KeyMsg) tea.Cmd {
+	key := msg.String()
+
+	switch key {
+	case "q":
+		return tea.Quit
+	case "i", "esc":
+		// Exit interactive mode
+		m.legendFocused = false
+		m.focusedPane = PaneQuery
+		m.metadataTable = m.metadataTable.Focused(false)
+		return nil
+	case "enter":
+		// Jump to /query_range with a starter query for the selected metric
+		index := m.metadataTable.GetHighlightedRowIndex()
+		if index < 0 || index >= len(m.metadata) {
+			return nil
+		}
+		m.metadataTable = m.metadataTable.Focused(false)
+		return m.openInRangeMode(m.metadata[index].starterQuery())
+	}
+
+	// Handle table navigation
+	var tableCmd tea.Cmd
+	switch key {
+	case "j":
+		m.metadataTable, tableCmd = m.metadataTable.Update(tea.KeyMsg{Type: tea.KeyDown})
+	case "k":
+		m.metadataTable, tableCmd = m.metadataTable.Update(tea.KeyMsg{Type: tea.KeyUp})
+	case "h":
+		m.metadataTable, tableCmd = m.metadataTable.Update(tea.KeyMsg{Type: tea.KeyPgUp})
+	case "l":
+		m.metadataTable, tableCmd = m.metadataTable.Update(tea.KeyMsg{Type: tea.KeyPgDown})
+	default:
+		m.metadataTable, tableCmd = m.metadataTable.Update(msg)
+	}
+	return tableCmd
+}
+
+func (MetadataMode) ExecuteQuery(ctx context.Context, m *TUIModel) tea.Cmd {
+	return m.executeMetadataQuery(ctx)
+}
+
+func (MetadataMode) RenderStatusParams(m *TUIModel) string {
+	if filter := strings.TrimSpace(m.modeQueries[ModeMetadata]); filter != "" {
+		return fmt.Sprintf("   Filter: %s", filter)
+	}
+	return ""
+}
+
+func (MetadataMode) RenderResultsContent(m *TUIModel) string {
+	var s strings.Builder
+
+	tableStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("63")).
+		Padding(0, 1)
+
+	if m.legendFocused {
+		tableStyle = tableStyle.BorderForeground(lipgloss.Color("205"))
+	}
+
+	s.WriteString(tableStyle.Render(m.metadataTable.View()))
+	s.WriteString("\n")
+	return s.String()
+}
+
+func (MetadataMode) RenderResultsStatusBar(m *TUIModel) string {
+	return fmt.Sprintf(" | Metrics: %d", len(m.metadata))
+}
+
+func (MetadataMode) OnSwitchTo(m *TUIModel) {
+	if m.currentState() == StateResults {
+		*m = m.renderMetadataTable()
+		*m = m.syncViewportContent()
+	}
+}
@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/akasprzok/peat/internal/prometheus"
+	tea "github.com/charmbracelet/bubbletea"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+)
+
+func TestMetricMetadataStarterQuery(t *testing.T) {
+	tests := []struct {
+		metricType v1.MetricType
+		want       string
+	}{
+		{v1.MetricTypeCounter, "rate(x[5m])"},
+		{v1.MetricTypeGauge, "x"},
+		{v1.MetricTypeHistogram, "histogram_quantile(0.95, sum by (le) (rate(x_bucket[5m])))"},
+		{v1.MetricTypeGaugeHistogram, "histogram_quantile(0.95, sum by (le) (x_bucket))"},
+		{v1.MetricTypeSummary, "rate(x_sum[5m]) / rate(x_count[5m])"},
+		{v1.MetricTypeUnknown, "x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.metricType), func(t *testing.T) {
+			md := metricMetadata{name: "x", metricType: tt.metricType}
+			if got := md.starterQuery(); got != tt.want {
+				t.Errorf("starterQuery() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterMetadata(t *testing.T) {
+	metadata := map[string][]v1.Metadata{
+		"up":                        {{Type: v1.MetricTypeGauge, Help: "Whether the target is up."}},
+		"http_requests_total":       {{Type: v1.MetricTypeCounter, Help: "Total HTTP requests."}},
+		"http_request_duration_sec": {{Type: v1.MetricTypeHistogram, Help: "Request latency.", Unit: "seconds"}},
+		"empty":                     {},
+	}
+
+	tests := []struct {
+		name   string
+		filter string
+		want   []string
+	}{
+		{"no filter", "", []string{"http_request_duration_sec", "http_requests_total", "up"}},
+		{"name match", "HTTP", []string{"http_request_duration_sec", "http_requests_total"}},
+		{"help match", "target", []string{"up"}},
+		{"no match", "nope", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filterMetadata(metadata, tt.filter)
+			if len(got) != len(tt.want) {
+				t.Fatalf("filterMetadata() returned %d metrics, want %d", len(got), len(tt.want))
+			}
+			for i, md := range got {
+				if md.name != tt.want[i] {
+					t.Errorf("filterMetadata()[%d] = %q, want %q", i, md.name, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMetadataJumpToRange(t *testing.T) {
+	mockClient := &prometheus.MockClient{
+		MetadataFunc: func(_ context.Context, _ string, _ time.Duration) (map[string][]v1.Metadata, error) {
+			return map[string][]v1.Metadata{
+				"a_seconds":     {{Type: v1.MetricTypeHistogram}},
+				"b_bytes_total": {{Type: v1.MetricTypeCounter}},
+			}, nil
+		},
+	}
+	m := NewTUIModel(mockClient, time.Hour, 15*time.Second, 100, 60*time.Second)
+	m.insertMode = false
+
+	updated, _ := m.switchToMode(ModeMetadata)
+	m = updated.(TUIModel)
+	updated, cmd := m.handleEnterKey()
+	m = updated.(TUIModel)
+	if cmd == nil {
+		t.Fatal("handleEnterKey() returned no command for an empty /metadata filter")
+	}
+
+	ctx := m.startLoading(ModeMetadata)
+	updated, _ = m.Update(m.executeMetadataQuery(ctx)())
+	m = updated.(TUIModel)
+	if m.currentState() != StateResults {
+		t.Fatalf("currentState() = %v, want %v", m.currentState(), StateResults)
+	}
+
+	for _, key := range []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune("i")},
+		{Type: tea.KeyRunes, Runes: []rune("j")},
+		{Type: tea.KeyEnter},
+	} {
+		updated, _ = m.Update(key)
+		m = updated.(TUIModel)
+	}
+
+	if m.mode != ModeRange {
+		t.Fatalf("mode = %v, want %v", m.mode, ModeRange)
+	}
+	if want := "rate(b_bytes_total[5m])"; m.queryInput.Value() != want {
+		t.Errorf("queryInput.Value() = %q, want %q", m.queryInput.Value(), want)
+	}
+	if !m.insertMode {
+		t.Error("insertMode = false, want true")
+	}
+}
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -100,8 +101,8 @@ func (RangeMode) HandleLegendKey(m *TUIModel, msg tea.KeyMsg) tea.Cmd {
 	return tableCmd
 }
 
-func (RangeMode) ExecuteQuery(m *TUIModel) tea.Cmd {
-	return m.executeRangeQuery()
+func (RangeMode) ExecuteQuery(ctx context.Context, m *TUIModel) tea.Cmd {
+	return m.executeRangeQuery(ctx)
 }
 
 func (RangeMode) RenderStatusParams(m *TUIModel) string {
@@ -147,12 +148,23 @@ func (RangeMode) RenderResultsContent(m *TUIModel) string {
 
 		s.WriteString(legendStyle.Render(m.legendTable.View()))
 	}
+
+	if m.showExemplars {
+		s.WriteString("\n")
+		s.WriteString(m.renderExemplarList())
+	}
 	return s.String()
 }
 
 func (RangeMode) RenderResultsStatusBar(m *TUIModel) string {
-	// No additional status bar content for range mode
-	return ""
+	var status string
+	if hasHistograms(m.matrix) {
+		status += " | Histograms: " + m.currentHistogramValue().Name
+	}
+	if m.showExemplars {
+		status += fmt.Sprintf(" | Exemplars: %d", len(m.exemplarRows()))
+	}
+	return status
 }
 
 func (RangeMode) OnSwitchTo(m *TUIModel) {
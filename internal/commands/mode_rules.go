@@ -0,0 +1,394 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/akasprzok/peat/internal/prometheus"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+)
+
+// alertStateFilter restricts the rules view to alerting rules in a single state.
+type alertStateFilter int
+
+const (
+	alertStateAll alertStateFilter = iota
+	alertStateFiring
+	alertStatePending
+	alertStateInactive
+
+	// alertStateFilterCount is the number of filters. Keep it last.
+	alertStateFilterCount
+)
+
+func (f alertStateFilter) String() string {
+	switch f {
+	case alertStateFiring:
+		return string(v1.AlertStateFiring)
+	case alertStatePending:
+		return string(v1.AlertStatePending)
+	case alertStateInactive:
+		return string(v1.AlertStateInactive)
+	default:
+		return "all"
+	}
+}
+
+// ruleRowKind is the kind of entry shown in a rules table row.
+type ruleRowKind int
+
+const (
+	ruleRowGroup ruleRowKind = iota
+	ruleRowRecording
+	ruleRowAlerting
+	ruleRowAlert
+)
+
+func (k ruleRowKind) String() string {
+	switch k {
+	case ruleRowGroup:
+		return "group"
+	case ruleRowRecording:
+		return "record"
+	case ruleRowAlerting:
+		return "alert"
+	default:
+		return "instance"
+	}
+}
+
+// ruleRow is a single row of the rules table: a rule group, a rule,
+// or an active alert of the alerting rule above it.
+type ruleRow struct {
+	kind           ruleRowKind
+	name           string // group name, rule name, or the alert's labels
+	state          string // rule health for groups and recording rules, alert state otherwise
+	lastEvaluation time.Time
+	evaluationTime time.Duration
+	lastError      string
+
+	// Group details
+	file     string
+	interval time.Duration
+
+	// Rule and alert details
+	expr        string
+	labels      model.LabelSet
+	annotations model.LabelSet
+	activeAt    time.Time
+	value       string
+}
+
+// buildRuleRows flattens rule groups into table rows. Rules are kept when
+// their name or group name contains filter (case-insensitive). When a state
+// filter is set, only alerting rules in that state are kept. Groups without
+// remaining rules are dropped. A group's health is bad when one of its rules
+// failed.
+func buildRuleRows(rules prometheus.RulesResult, filter string, state alertStateFilter) []ruleRow {
+	filter = strings.ToLower(strings.TrimSpace(filter))
+
+	var rows []ruleRow
+	for _, group := range rules.Groups {
+		groupRow := ruleRow{
+			kind:           ruleRowGroup,
+			name:           group.Name,
+			state:          string(v1.RuleHealthGood),
+			lastEvaluation: group.LastEvaluation,
+			evaluationTime: group.EvaluationTime.Duration(),
+			file:           group.File,
+			interval:       time.Duration(group.Interval * float64(time.Second)),
+		}
+		groupMatches := filter == "" || strings.Contains(strings.ToLower(group.Name), filter)
+
+		var ruleRows []ruleRow
+		for _, rule := range group.Rules {
+			var r ruleRow
+			var alerts []*v1.Alert
+			switch rule := rule.(type) {
+			case v1.AlertingRule:
+				r = ruleRow{
+					kind:           ruleRowAlerting,
+					name:           rule.Name,
+					state:          rule.State,
+					lastEvaluation: rule.LastEvaluation,
+					evaluationTime: time.Duration(rule.EvaluationTime * float64(time.Second)),
+					lastError:      rule.LastError,
+					expr:           rule.Query,
+					labels:         rule.Labels,
+					annotations:    rule.Annotations,
+				}
+				alerts = rule.Alerts
+			case v1.RecordingRule:
+				r = ruleRow{
+					kind:           ruleRowRecording,
+					name:           rule.Name,
+					state:          string(rule.Health),
+					lastEvaluation: rule.LastEvaluation,
+					evaluationTime: time.Duration(rule.EvaluationTime * float64(time.Second)),
+					lastError:      rule.LastError,
+					expr:           rule.Query,
+					labels:         rule.Labels,
+				}
+			default:
+				continue
+			}
+
+			if r.lastError != "" && groupRow.lastError == "" {
+				groupRow.state = string(v1.RuleHealthBad)
+				groupRow.lastError = r.lastError
+			}
+
+			if state != alertStateAll && (r.kind != ruleRowAlerting || r.state != state.String()) {
+				continue
+			}
+			if !groupMatches && !strings.Contains(strings.ToLower(r.name), filter) {
+				continue
+			}
+
+			ruleRows = append(ruleRows, r)
+			for _, alert := range alerts {
+				ruleRows = append(ruleRows, ruleRow{
+					kind:        ruleRowAlert,
+					name:        alert.Labels.String(),
+					state:       string(alert.State),
+					expr:        r.expr,
+					labels:      alert.Labels,
+					annotations: alert.Annotations,
+					activeAt:    alert.ActiveAt,
+					value:       alert.Value,
+				})
+			}
+		}
+
+		if len(ruleRows) > 0 {
+			rows = append(rows, groupRow)
+			rows = append(rows, ruleRows...)
+		}
+	}
+	return rows
+}
+
+// RulesMode handles rules and alerts mode (/rules)
+type RulesMode struct{}
+
+func (RulesMode) Name() string {
+	return "7) /rules"
+}
+
+func (RulesMode) HandleInteractiveToggle(m *TUIModel) tea.Cmd {
+	if len(m.ruleRows) == 0 {
+		return nil
+	}
+
+	m.legendFocused = !m.legendFocused
+	if m.legendFocused {
+		m.focusedPane = PaneLegend
+		m.queryInput.Blur()
+		m.rulesTable = m.rulesTable.Focused(true)
+	} else {
+		m.focusedPane = PaneQuery
+		// Stay in normal mode - don't focus query input
+		m.rulesTable = m.rulesTable.Focused(false)
+	}
+	return nil
+}
+
+func (RulesMode) HandleLegendKey(m *TUIModel, msg tea.KeyMsg) tea.Cmd {
+	key := msg.String()
+
+	switch key {
+	case "q":
+		return tea.Quit
+	case "i", "esc":
+		// Exit interactive mode
+		m.legendFocused = false
+		m.focusedPane = PaneQuery
+		m.rulesTable = m.rulesTable.Focused(false)
+		return nil
+	case "s":
+		m.rulesState = (m.rulesState + 1) % alertStateFilterCount
+		*m = m.refreshRules()
+		return nil
+	case "S":
+		// Silence the selected alert instance
+		row, ok := m.selectedRuleRow()
+		if !ok || row.kind != ruleRowAlert {
+			return nil
+		}
+		return m.openSilenceForm(seriesSilenceMatchers(row.labels))
+	case "enter":
+		// Graph the selected rule's expression
+		row, ok := m.selectedRuleRow()
+		if !ok || row.expr == "" {
+			return nil
+		}
+		m.rulesTable = m.rulesTable.Focused(false)
+		return m.openInRangeMode(row.expr)
+	}
+
+	// Handle table navigation
+	var tableCmd tea.Cmd
+	switch key {
+	case "j":
+		m.rulesTable, tableCmd = m.rulesTable.Update(tea.KeyMsg{Type: tea.KeyDown})
+	case "k":
+		m.rulesTable, tableCmd = m.rulesTable.Update(tea.KeyMsg{Type: tea.KeyUp})
+	case "h":
+		m.rulesTable, tableCmd = m.rulesTable.Update(tea.KeyMsg{Type: tea.KeyPgUp})
+	case "l":
+		m.rulesTable, tableCmd = m.rulesTable.Update(tea.KeyMsg{Type: tea.KeyPgDown})
+	default:
+		m.rulesTable, tableCmd = m.rulesTable.Update(msg)
+	}
+	return tableCmd
+}
+
+func (RulesMode) ExecuteQuery(ctx context.Context, m *TUIModel) tea.Cmd {
+	return m.executeRulesQuery(ctx)
+}
+
+func (RulesMode) RenderStatusParams(m *TUIModel) string {
+	return fmt.Sprintf("   State: %s", m.rulesState)
+}
+
+func (RulesMode) RenderResultsContent(m *TUIModel) string {
+	var s strings.Builder
+
+	tableStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("63")).
+		Padding(0, 1)
+
+	if m.legendFocused {
+		tableStyle = tableStyle.BorderForeground(lipgloss.Color("205"))
+	}
+
+	s.WriteString(tableStyle.Render(m.rulesTable.View()))
+	s.WriteString("\n")
+
+	if row, ok := m.selectedRuleRow(); ok {
+		s.WriteString(renderRuleDetails(row))
+		s.WriteString("\n")
+	}
+	return s.String()
+}
+
+func (RulesMode) RenderResultsStatusBar(m *TUIModel) string {
+	var rules, firing, pending int
+	for _, group := range m.rules.Groups {
+		for _, rule := range group.Rules {
+			rules++
+			if alerting, ok := rule.(v1.AlertingRule); ok {
+				switch v1.AlertState(alerting.State) {
+				case v1.AlertStateFiring:
+					firing++
+				case v1.AlertStatePending:
+					pending++
+				}
+			}
+		}
+	}
+	return fmt.Sprintf(" | Groups: %d | Rules: %d | Firing: %d | Pending: %d",
+		len(m.rules.Groups), rules, firing, pending)
+}
+
+func (RulesMode) OnSwitchTo(m *TUIModel) {
+	if m.currentState() == StateResults {
+		*m = m.renderRulesTable()
+		*m = m.syncViewportContent()
+	}
+}
+
+// selectedRuleRow returns the row highlighted in the rules table.
+func (m TUIModel) selectedRuleRow() (ruleRow, bool) {
+	index := m.rulesTable.GetHighlightedRowIndex()
+	if index < 0 || index >= len(m.ruleRows) {
+		return ruleRow{}, false
+	}
+	return m.ruleRows[index], true
+}
+
+// refreshRules re-applies the filters to the fetched rules and re-renders the table.
+func (m TUIModel) refreshRules() TUIModel {
+	m.ruleRows = buildRuleRows(m.rules, m.modeQueries[ModeRules], m.rulesState)
+	m = m.renderRulesTable()
+	return m.syncViewportContent()
+}
+
+// ruleStateStyle returns the style for a rule health or alert state.
+func ruleStateStyle(state string) lipgloss.Style {
+	switch state {
+	case string(v1.AlertStateFiring), string(v1.RuleHealthBad):
+		return ErrorStyle
+	case string(v1.AlertStatePending), string(v1.RuleHealthUnknown):
+		return WarningStyle
+	default:
+		return SuccessStyle
+	}
+}
+
+// renderRuleDetails renders the details of a rules table row below the table.
+func renderRuleDetails(row ruleRow) string {
+	keyStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("252"))
+	valueStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+
+	var s strings.Builder
+	field := func(key, value string) {
+		if value == "" {
+			return
+		}
+		s.WriteString(keyStyle.Render(fmt.Sprintf("%-12s", key)))
+		s.WriteString(valueStyle.Render(value))
+		s.WriteString("\n")
+	}
+	labelSet := func(key string, labels model.LabelSet) {
+		if len(labels) == 0 {
+			return
+		}
+		names := make([]string, 0, len(labels))
+		for name := range labels {
+			names = append(names, string(name))
+		}
+		sort.Strings(names)
+		s.WriteString(keyStyle.Render(key))
+		s.WriteString("\n")
+		for _, name := range names {
+			s.WriteString(valueStyle.Render(fmt.Sprintf("  %s: %s", name, labels[model.LabelName(name)])))
+			s.WriteString("\n")
+		}
+	}
+
+	switch row.kind {
+	case ruleRowGroup:
+		field("File", row.file)
+		if row.interval > 0 {
+			field("Interval", row.interval.String())
+		}
+	case ruleRowAlert:
+		if !row.activeAt.IsZero() {
+			field("Active since", fmt.Sprintf("%s (%s ago)",
+				row.activeAt.UTC().Format(time.RFC3339),
+				formatDuration(time.Since(row.activeAt).Truncate(time.Second))))
+		}
+		field("Value", row.value)
+		labelSet("Labels", row.labels)
+		labelSet("Annotations", row.annotations)
+	default:
+		field("Expr", row.expr)
+		labelSet("Labels", row.labels)
+		labelSet("Annotations", row.annotations)
+	}
+	field("Error", row.lastError)
+
+	detailsStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("240")).
+		Padding(0, 1)
+	return detailsStyle.Render(strings.TrimSuffix(s.String(), "\n"))
+}
@@ -0,0 +1,168 @@
+package commands
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akasprzok/peat/internal/prometheus"
+	tea "github.com/charmbracelet/bubbletea"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+)
+
+func testRules() prometheus.RulesResult {
+	evaluated := time.Now().Add(-10 * time.Second)
+	return prometheus.RulesResult{
+		Groups: []prometheus.RuleGroup{
+			{
+				EvaluationTime: 0.005,
+				LastEvaluation: evaluated,
+				RuleGroup: v1.RuleGroup{
+					Name:     "node",
+					File:     "/etc/prometheus/node.yml",
+					Interval: 30,
+					Rules: v1.Rules{
+						v1.RecordingRule{
+							Name:           "instance:node_cpu:rate5m",
+							Query:          `rate(node_cpu_seconds_total[5m])`,
+							Health:         v1.RuleHealthGood,
+							EvaluationTime: 0.002,
+							LastEvaluation: evaluated,
+						},
+						v1.AlertingRule{
+							Name:           "NodeDown",
+							Query:          `up{job="node"} == 0`,
+							State:          string(v1.AlertStateFiring),
+							Health:         v1.RuleHealthGood,
+							Annotations:    model.LabelSet{"summary": "Node is down"},
+							EvaluationTime: 0.001,
+							LastEvaluation: evaluated.Add(time.Second),
+							Alerts: []*v1.Alert{
+								{
+									Labels:   model.LabelSet{"alertname": "NodeDown", "instance": "a:9100"},
+									State:    v1.AlertStateFiring,
+									ActiveAt: evaluated.Add(-time.Hour),
+									Value:    "0",
+								},
+							},
+						},
+					},
+				},
+			},
+			{
+				RuleGroup: v1.RuleGroup{
+					Name: "api",
+					Rules: v1.Rules{
+						v1.AlertingRule{
+							Name:      "HighLatency",
+							Query:     `histogram_quantile(0.99, rate(http_duration_seconds_bucket[5m])) > 1`,
+							State:     string(v1.AlertStateInactive),
+							Health:    v1.RuleHealthBad,
+							LastError: "many-to-many matching not allowed",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestBuildRuleRows(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		state  alertStateFilter
+		want   []string
+	}{
+		{"all", "", alertStateAll, []string{"node", "instance:node_cpu:rate5m", "NodeDown", `{alertname="NodeDown", instance="a:9100"}`, "api", "HighLatency"}},
+		{"firing", "", alertStateFiring, []string{"node", "NodeDown", `{alertname="NodeDown", instance="a:9100"}`}},
+		{"inactive", "", alertStateInactive, []string{"api", "HighLatency"}},
+		{"pending", "", alertStatePending, nil},
+		{"filter on rule name", "latency", alertStateAll, []string{"api", "HighLatency"}},
+		{"filter on group name", "NODE", alertStateAll, []string{"node", "instance:node_cpu:rate5m", "NodeDown", `{alertname="NodeDown", instance="a:9100"}`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := buildRuleRows(testRules(), tt.filter, tt.state)
+			if len(rows) != len(tt.want) {
+				t.Fatalf("buildRuleRows() returned %d rows, want %d", len(rows), len(tt.want))
+			}
+			for i, row := range rows {
+				if row.name != tt.want[i] {
+					t.Errorf("rows[%d].name = %q, want %q", i, row.name, tt.want[i])
+				}
+			}
+		})
+	}
+
+	t.Run("group statistics", func(t *testing.T) {
+		rows := buildRuleRows(testRules(), "", alertStateAll)
+		node, api := rows[0], rows[4]
+		if node.evaluationTime != 5*time.Millisecond {
+			t.Errorf("node evaluationTime = %v, want the group's %v", node.evaluationTime, 5*time.Millisecond)
+		}
+		if node.state != string(v1.RuleHealthGood) {
+			t.Errorf("node state = %q, want %q", node.state, v1.RuleHealthGood)
+		}
+		if api.state != string(v1.RuleHealthBad) || api.lastError == "" {
+			t.Errorf("api state = %q, lastError = %q, want the rule's error", api.state, api.lastError)
+		}
+		if node.interval != 30*time.Second {
+			t.Errorf("node interval = %v, want %v", node.interval, 30*time.Second)
+		}
+	})
+}
+
+func TestRulesMode(t *testing.T) {
+	mockClient := &prometheus.MockClient{
+		RulesFunc: func(_ context.Context, _ time.Duration) (prometheus.RulesResult, error) {
+			return testRules(), nil
+		},
+	}
+	m := NewTUIModel(mockClient, time.Hour, 15*time.Second, 100, 60*time.Second)
+	m.width = 160
+	m.height = 50
+	m.insertMode = false
+
+	updated, _ := m.switchToMode(ModeRules)
+	m = updated.(TUIModel)
+	ctx := m.startLoading(ModeRules)
+	updated, _ = m.Update(m.executeRulesQuery(ctx)())
+	m = updated.(TUIModel)
+	if m.currentState() != StateResults {
+		t.Fatalf("currentState() = %v, want %v", m.currentState(), StateResults)
+	}
+
+	press := func(msg tea.KeyMsg) {
+		updated, _ := m.Update(msg)
+		m = updated.(TUIModel)
+	}
+	down := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")}
+
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("i")})
+	press(down)
+	press(down)
+	press(down)
+
+	t.Run("details follow the selection", func(t *testing.T) {
+		content := m.resultsViewport.View()
+		for _, want := range []string{"Active since", "instance: a:9100"} {
+			if !strings.Contains(content, want) {
+				t.Errorf("results do not contain %q", want)
+			}
+		}
+	})
+
+	t.Run("enter opens the rule expression in /query_range", func(t *testing.T) {
+		press(tea.KeyMsg{Type: tea.KeyEnter})
+		if m.mode != ModeRange {
+			t.Fatalf("mode = %v, want %v", m.mode, ModeRange)
+		}
+		if want := `up{job="node"} == 0`; m.queryInput.Value() != want {
+			t.Errorf("queryInput.Value() = %q, want %q", m.queryInput.Value(), want)
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -45,6 +46,13 @@ func (SeriesMode) HandleLegendKey(m *TUIModel, msg tea.KeyMsg) tea.Cmd {
 		m.focusedPane = PaneQuery
 		m.seriesTable = m.seriesTable.Focused(false)
 		return nil
+	case "S":
+		// Silence alerts carrying the highlighted series' labels
+		index := m.seriesTable.GetHighlightedRowIndex()
+		if index < 0 || index >= len(m.series) {
+			return nil
+		}
+		return m.openSilenceForm(seriesSilenceMatchers(m.series[index]))
 	}
 
 	// Handle table navigation
@@ -64,8 +72,8 @@ func (SeriesMode) HandleLegendKey(m *TUIModel, msg tea.KeyMsg) tea.Cmd {
 	return tableCmd
 }
 
-func (SeriesMode) ExecuteQuery(m *TUIModel) tea.Cmd {
-	return m.executeSeriesQuery()
+func (SeriesMode) ExecuteQuery(ctx context.Context, m *TUIModel) tea.Cmd {
+	return m.executeSeriesQuery(ctx)
 }
 
 func (SeriesMode) RenderStatusParams(m *TUIModel) string {
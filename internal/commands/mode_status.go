@@ -0,0 +1,186 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// StatusMode shows the server's build and runtime information, flags and
+// loaded configuration (/status)
+type StatusMode struct{}
+
+func (StatusMode) Name() string {
+	return "9) /status"
+}
+
+func (StatusMode) HandleInteractiveToggle(m *TUIModel) tea.Cmd {
+	m.legendFocused = !m.legendFocused
+	if m.legendFocused {
+		m.focusedPane = PaneLegend
+		m.queryInput.Blur()
+	} else {
+		m.focusedPane = PaneQuery
+		// Stay in normal mode - don't focus query input
+	}
+	return nil
+}
+
+func (StatusMode) HandleLegendKey(m *TUIModel, msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "q":
+		return tea.Quit
+	case "i", "esc":
+		// Exit interactive mode
+		m.legendFocused = false
+		m.focusedPane = PaneQuery
+	case "j", "down":
+		m.resultsViewport.ScrollDown(1)
+	case "k", "up":
+		m.resultsViewport.ScrollUp(1)
+	case "h", "pgup":
+		m.resultsViewport.PageUp()
+	case "l", "pgdown":
+		m.resultsViewport.PageDown()
+	case "g", "home":
+		m.resultsViewport.GotoTop()
+	case "G", "end":
+		m.resultsViewport.GotoBottom()
+	}
+	return nil
+}
+
+func (StatusMode) ExecuteQuery(ctx context.Context, m *TUIModel) tea.Cmd {
+	return m.executeStatusQuery(ctx)
+}
+
+func (StatusMode) RenderStatusParams(m *TUIModel) string {
+	if filter := strings.TrimSpace(m.modeQueries[ModeStatus]); filter != "" {
+		return fmt.Sprintf("   Filter: %s", filter)
+	}
+	return ""
+}
+
+func (StatusMode) RenderResultsContent(m *TUIModel) string {
+	var s strings.Builder
+
+	// Warnings
+	warnings := m.currentWarnings()
+	if len(warnings) > 0 {
+		s.WriteString("\n")
+		s.WriteString(WarningStyle.Render("Warnings:\n"))
+		for _, w := range warnings {
+			s.WriteString(WarningStyle.Render("  - " + w + "\n"))
+		}
+	}
+
+	build := m.buildinfo
+	writeStatusSection(&s, "Build info", [][2]string{
+		{"Version", build.Version},
+		{"Revision", build.Revision},
+		{"Branch", build.Branch},
+		{"Build user", build.BuildUser},
+		{"Build date", build.BuildDate},
+		{"Go version", build.GoVersion},
+	})
+
+	runtime := m.runtimeinfo
+	if !runtime.StartTime.IsZero() {
+		reload := SuccessStyle.Render("successful")
+		if !runtime.ReloadConfigSuccess {
+			reload = ErrorStyle.Render("failed")
+		}
+		if !runtime.LastConfigTime.IsZero() {
+			reload += " at " + runtime.LastConfigTime.UTC().Format(time.RFC3339)
+		}
+		corruptions := fmt.Sprint(runtime.CorruptionCount)
+		if runtime.CorruptionCount > 0 {
+			corruptions = ErrorStyle.Render(corruptions)
+		}
+		writeStatusSection(&s, "Runtime info", [][2]string{
+			{"Start time", fmt.Sprintf("%s (up %s)",
+				runtime.StartTime.UTC().Format(time.RFC3339),
+				time.Since(runtime.StartTime).Truncate(time.Minute))},
+			{"Config reload", reload},
+			{"Storage retention", runtime.StorageRetention},
+			{"Corruptions", corruptions},
+			{"Goroutines", fmt.Sprint(runtime.GoroutineCount)},
+			{"GOMAXPROCS", fmt.Sprint(runtime.GOMAXPROCS)},
+			{"GOGC", runtime.GOGC},
+			{"GODEBUG", runtime.GODEBUG},
+			{"Working directory", runtime.CWD},
+		})
+	}
+
+	flags := m.statusFlags()
+	if len(flags) > 0 {
+		writeStatusSection(&s, fmt.Sprintf("Flags (%d)", len(flags)), flags)
+	}
+
+	if m.serverConfig.YAML != "" {
+		s.WriteString(statusHeaderStyle.Render("Configuration"))
+		s.WriteString("\n")
+		s.WriteString(lipgloss.NewStyle().PaddingLeft(2).Render(highlightYAML(m.serverConfig.YAML)))
+		s.WriteString("\n")
+	}
+	return s.String()
+}
+
+func (StatusMode) RenderResultsStatusBar(m *TUIModel) string {
+	result := ""
+	if m.buildinfo.Version != "" {
+		result += " | Version: " + m.buildinfo.Version
+	}
+	if !m.runtimeinfo.StartTime.IsZero() && !m.runtimeinfo.ReloadConfigSuccess {
+		result += " | " + ErrorStyle.Render("Config reload failed")
+	}
+	return result + fmt.Sprintf(" | %.0f%%", m.resultsViewport.ScrollPercent()*100)
+}
+
+func (StatusMode) OnSwitchTo(m *TUIModel) {
+	if m.currentState() == StateResults {
+		*m = m.syncViewportContent()
+	}
+}
+
+var statusHeaderStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("63")).Padding(1, 1, 0)
+
+// writeStatusSection writes a titled list of name/value pairs, skipping pairs without a value.
+func writeStatusSection(s *strings.Builder, title string, pairs [][2]string) {
+	width := 0
+	for _, pair := range pairs {
+		width = max(width, lipgloss.Width(pair[0]))
+	}
+
+	s.WriteString(statusHeaderStyle.Render(title))
+	s.WriteString("\n")
+	nameStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("244")).Width(width + 2)
+	for _, pair := range pairs {
+		if pair[1] == "" {
+			continue
+		}
+		s.WriteString("  " + nameStyle.Render(pair[0]) + pair[1] + "\n")
+	}
+}
+
+// statusFlags returns the server flags whose name contains the filter
+// (case-insensitive), sorted by name.
+func (m TUIModel) statusFlags() [][2]string {
+	filter := strings.ToLower(strings.TrimSpace(m.modeQueries[ModeStatus]))
+
+	flags := make([][2]string, 0, len(m.flags))
+	for name, value := range m.flags {
+		if filter == "" || strings.Contains(strings.ToLower(name), filter) {
+			flags = append(flags, [2]string{"--" + name, value})
+		}
+	}
+	sort.Slice(flags, func(i, j int) bool {
+		return flags[i][0] < flags[j][0]
+	})
+	return flags
+}
@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akasprzok/peat/internal/prometheus"
+	tea "github.com/charmbracelet/bubbletea"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+)
+
+func TestStatusMode(t *testing.T) {
+	var buildinfoErr, flagsErr error
+	mockClient := &prometheus.MockClient{
+		BuildinfoFunc: func(_ context.Context, _ time.Duration) (v1.BuildinfoResult, error) {
+			return v1.BuildinfoResult{Version: "2.53.0", GoVersion: "go1.22.4"}, buildinfoErr
+		},
+		RuntimeinfoFunc: func(_ context.Context, _ time.Duration) (v1.RuntimeinfoResult, error) {
+			return v1.RuntimeinfoResult{
+				StartTime:           time.Now().Add(-time.Hour),
+				ReloadConfigSuccess: false,
+				StorageRetention:    "15d",
+			}, nil
+		},
+		FlagsFunc: func(_ context.Context, _ time.Duration) (v1.FlagsResult, error) {
+			return v1.FlagsResult{
+				"storage.tsdb.retention.time": "15d",
+				"storage.tsdb.path":           "/prometheus",
+				"web.listen-address":          "0.0.0.0:9090",
+			}, flagsErr
+		},
+		ConfigFunc: func(_ context.Context, _ time.Duration) (v1.ConfigResult, error) {
+			return v1.ConfigResult{YAML: "global:\n  scrape_interval: 15s\n"}, nil
+		},
+	}
+
+	run := func(t *testing.T, filter string) TUIModel {
+		t.Helper()
+		m := NewTUIModel(mockClient, time.Hour, 15*time.Second, 100, 60*time.Second)
+		m.width = 120
+		m.height = 60
+		m.insertMode = false
+		updated, _ := m.switchToMode(ModeStatus)
+		m = updated.(TUIModel)
+		m.queryInput.SetValue(filter)
+		m.modeQueries[ModeStatus] = filter
+		ctx := m.startLoading(ModeStatus)
+		updated, _ = m.Update(m.executeStatusQuery(ctx)())
+		return updated.(TUIModel)
+	}
+
+	t.Run("shows build info, runtime info, flags and config", func(t *testing.T) {
+		m := run(t, "")
+		if m.currentState() != StateResults {
+			t.Fatalf("currentState() = %v, want %v", m.currentState(), StateResults)
+		}
+
+		content := m.renderResultsContent()
+		for _, want := range []string{"2.53.0", "go1.22.4", "15d", "failed", "Flags (3)", "--web.listen-address", "scrape_interval"} {
+			if !strings.Contains(content, want) {
+				t.Errorf("content does not contain %q", want)
+			}
+		}
+		if status := m.renderResultsStatusBar(); !strings.Contains(status, "Config reload failed") {
+			t.Errorf("status bar = %q, want a config reload warning", status)
+		}
+	})
+
+	t.Run("input filters flags", func(t *testing.T) {
+		m := run(t, "TSDB")
+		flags := m.statusFlags()
+		if len(flags) != 2 || flags[0][0] != "--storage.tsdb.path" || flags[1][0] != "--storage.tsdb.retention.time" {
+			t.Errorf("statusFlags() = %v, want the two storage.tsdb flags sorted by name", flags)
+		}
+	})
+
+	t.Run("optional endpoint errors are warnings", func(t *testing.T) {
+		flagsErr = errors.New("not found")
+		defer func() { flagsErr = nil }()
+
+		m := run(t, "")
+		if m.currentState() != StateResults {
+			t.Fatalf("currentState() = %v, want %v", m.currentState(), StateResults)
+		}
+		warnings := m.currentWarnings()
+		if len(warnings) != 1 || warnings[0] != "flags: not found" {
+			t.Errorf("warnings = %v, want the flags error", warnings)
+		}
+	})
+
+	t.Run("build info error fails the query", func(t *testing.T) {
+		buildinfoErr = errors.New("connection refused")
+		defer func() { buildinfoErr = nil }()
+
+		m := run(t, "")
+		if m.currentState() != StateError {
+			t.Errorf("currentState() = %v, want %v", m.currentState(), StateError)
+		}
+	})
+
+	t.Run("interactive mode scrolls the config", func(t *testing.T) {
+		m := run(t, "")
+		// A terminal too short for the config
+		m.height = ChromeHeightCollapsed + 5
+		m.resultsViewport.Height = 5
+		for _, key := range []string{"i", "j", "j"} {
+			updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+			m = updated.(TUIModel)
+		}
+		if m.resultsViewport.YOffset != 2 {
+			t.Errorf("YOffset = %d, want 2", m.resultsViewport.YOffset)
+		}
+	})
+}
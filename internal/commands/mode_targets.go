@@ -0,0 +1,259 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+)
+
+// healthDropped is the health shown for targets dropped by relabelling.
+const healthDropped = "dropped"
+
+// targetHealthFilter restricts the targets view to a single health state.
+type targetHealthFilter int
+
+const (
+	targetHealthAll targetHealthFilter = iota
+	targetHealthUp
+	targetHealthDown
+	targetHealthUnknown
+	targetHealthDropped
+
+	// targetHealthFilterCount is the number of filters. Keep it last.
+	targetHealthFilterCount
+)
+
+func (f targetHealthFilter) String() string {
+	switch f {
+	case targetHealthUp:
+		return string(v1.HealthGood)
+	case targetHealthDown:
+		return string(v1.HealthBad)
+	case targetHealthUnknown:
+		return string(v1.HealthUnknown)
+	case targetHealthDropped:
+		return healthDropped
+	default:
+		return "all"
+	}
+}
+
+// matches reports whether a target with the given health passes the filter.
+func (f targetHealthFilter) matches(health string) bool {
+	return f == targetHealthAll || f.String() == health
+}
+
+// scrapeTarget is a single row of the targets table.
+type scrapeTarget struct {
+	job            string
+	instance       string
+	health         string // up, down, unknown or dropped
+	lastScrape     time.Time
+	scrapeDuration time.Duration
+	lastError      string
+}
+
+// upQuery returns the query graphing the target's up series.
+func (t scrapeTarget) upQuery() string {
+	return fmt.Sprintf("up{job=%q,instance=%q}", t.job, t.instance)
+}
+
+// healthRank orders unhealthy targets first when targets aren't grouped.
+func healthRank(health string) int {
+	switch health {
+	case string(v1.HealthBad):
+		return 0
+	case string(v1.HealthUnknown):
+		return 1
+	case string(v1.HealthGood):
+		return 2
+	default:
+		return 3
+	}
+}
+
+// buildTargetRows flattens active and dropped targets into table rows,
+// keeping targets whose job or instance contains filter (case-insensitive)
+// and whose health passes the health filter. Grouped rows are sorted by
+// job and instance; otherwise unhealthy targets come first.
+func buildTargetRows(targets v1.TargetsResult, filter string, health targetHealthFilter, grouped bool) []scrapeTarget {
+	filter = strings.ToLower(strings.TrimSpace(filter))
+
+	rows := make([]scrapeTarget, 0, len(targets.Active)+len(targets.Dropped))
+	add := func(t scrapeTarget) {
+		if !health.matches(t.health) {
+			return
+		}
+		if filter != "" &&
+			!strings.Contains(strings.ToLower(t.job), filter) &&
+			!strings.Contains(strings.ToLower(t.instance), filter) {
+			return
+		}
+		rows = append(rows, t)
+	}
+
+	for _, t := range targets.Active {
+		add(scrapeTarget{
+			job:            string(t.Labels["job"]),
+			instance:       string(t.Labels["instance"]),
+			health:         string(t.Health),
+			lastScrape:     t.LastScrape,
+			scrapeDuration: time.Duration(t.LastScrapeDuration * float64(time.Second)),
+			lastError:      t.LastError,
+		})
+	}
+	for _, t := range targets.Dropped {
+		add(scrapeTarget{
+			job:      t.DiscoveredLabels["job"],
+			instance: t.DiscoveredLabels["__address__"],
+			health:   healthDropped,
+		})
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if !grouped && healthRank(a.health) != healthRank(b.health) {
+			return healthRank(a.health) < healthRank(b.health)
+		}
+		if a.job != b.job {
+			return a.job < b.job
+		}
+		return a.instance < b.instance
+	})
+	return rows
+}
+
+// TargetsMode handles scrape targets mode (/targets)
+type TargetsMode struct{}
+
+func (TargetsMode) Name() string {
+	return "6) /targets"
+}
+
+func (TargetsMode) HandleInteractiveToggle(m *TUIModel) tea.Cmd {
+	if len(m.targetRows) == 0 {
+		return nil
+	}
+
+	m.legendFocused = !m.legendFocused
+	if m.legendFocused {
+		m.focusedPane = PaneLegend
+		m.queryInput.Blur()
+		m.targetsTable = m.targetsTable.Focused(true)
+	} else {
+		m.focusedPane = PaneQuery
+		// Stay in normal mode - don't focus query input
+		m.targetsTable = m.targetsTable.Focused(false)
+	}
+	return nil
+}
+
+func (TargetsMode) HandleLegendKey(m *TUIModel, msg tea.KeyMsg) tea.Cmd {
+	key := msg.String()
+
+	switch key {
+	case "q":
+		return tea.Quit
+	case "i", "esc":
+		// Exit interactive mode
+		m.legendFocused = false
+		m.focusedPane = PaneQuery
+		m.targetsTable = m.targetsTable.Focused(false)
+		return nil
+	case "g":
+		m.targetsGrouped = !m.targetsGrouped
+		*m = m.refreshTargets()
+		return nil
+	case "s":
+		m.targetsHealth = (m.targetsHealth + 1) % targetHealthFilterCount
+		*m = m.refreshTargets()
+		return nil
+	case "enter":
+		// Graph the selected target's up series; dropped targets have none
+		index := m.targetsTable.GetHighlightedRowIndex()
+		if index < 0 || index >= len(m.targetRows) || m.targetRows[index].health == healthDropped {
+			return nil
+		}
+		m.targetsTable = m.targetsTable.Focused(false)
+		return m.openInRangeMode(m.targetRows[index].upQuery())
+	}
+
+	// Handle table navigation
+	var tableCmd tea.Cmd
+	switch key {
+	case "j":
+		m.targetsTable, tableCmd = m.targetsTable.Update(tea.KeyMsg{Type: tea.KeyDown})
+	case "k":
+		m.targetsTable, tableCmd = m.targetsTable.Update(tea.KeyMsg{Type: tea.KeyUp})
+	case "h":
+		m.targetsTable, tableCmd = m.targetsTable.Update(tea.KeyMsg{Type: tea.KeyPgUp})
+	case "l":
+		m.targetsTable, tableCmd = m.targetsTable.Update(tea.KeyMsg{Type: tea.KeyPgDown})
+	default:
+		m.targetsTable, tableCmd = m.targetsTable.Update(msg)
+	}
+	return tableCmd
+}
+
+func (TargetsMode) ExecuteQuery(ctx context.Context, m *TUIModel) tea.Cmd {
+	return m.executeTargetsQuery(ctx)
+}
+
+func (TargetsMode) RenderStatusParams(m *TUIModel) string {
+	params := fmt.Sprintf("   Health: %s", m.targetsHealth)
+	if m.targetsGrouped {
+		params += "   Grouped: job"
+	}
+	return params
+}
+
+func (TargetsMode) RenderResultsContent(m *TUIModel) string {
+	var s strings.Builder
+
+	tableStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("63")).
+		Padding(0, 1)
+
+	if m.legendFocused {
+		tableStyle = tableStyle.BorderForeground(lipgloss.Color("205"))
+	}
+
+	s.WriteString(tableStyle.Render(m.targetsTable.View()))
+	s.WriteString("\n")
+	return s.String()
+}
+
+func (TargetsMode) RenderResultsStatusBar(m *TUIModel) string {
+	var up, down int
+	for _, t := range m.targets.Active {
+		switch t.Health {
+		case v1.HealthGood:
+			up++
+		case v1.HealthBad:
+			down++
+		}
+	}
+	return fmt.Sprintf(" | Targets: %d shown | Up: %d | Down: %d | Dropped: %d",
+		len(m.targetRows), up, down, len(m.targets.Dropped))
+}
+
+func (TargetsMode) OnSwitchTo(m *TUIModel) {
+	if m.currentState() == StateResults {
+		*m = m.renderTargetsTable()
+		*m = m.syncViewportContent()
+	}
+}
+
+// refreshTargets re-applies the filters to the fetched targets and re-renders the table.
+func (m TUIModel) refreshTargets() TUIModel {
+	m.targetRows = buildTargetRows(m.targets, m.modeQueries[ModeTargets], m.targetsHealth, m.targetsGrouped)
+	m = m.renderTargetsTable()
+	return m.syncViewportContent()
+}
@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/akasprzok/peat/internal/prometheus"
+	tea "github.com/charmbracelet/bubbletea"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+)
+
+func testTargets() v1.TargetsResult {
+	return v1.TargetsResult{
+		Active: []v1.ActiveTarget{
+			{Labels: model.LabelSet{"job": "node", "instance": "b:9100"}, Health: v1.HealthGood, LastScrape: time.Now(), LastScrapeDuration: 0.012},
+			{Labels: model.LabelSet{"job": "node", "instance": "a:9100"}, Health: v1.HealthBad, LastError: "connection refused"},
+			{Labels: model.LabelSet{"job": "api", "instance": "c:8080"}, Health: v1.HealthGood},
+		},
+		Dropped: []v1.DroppedTarget{
+			{DiscoveredLabels: map[string]string{"job": "node", "__address__": "d:9100"}},
+		},
+	}
+}
+
+func TestBuildTargetRows(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  string
+		health  targetHealthFilter
+		grouped bool
+		want    []string
+	}{
+		{"unhealthy first", "", targetHealthAll, false, []string{"a:9100", "c:8080", "b:9100", "d:9100"}},
+		{"grouped by job", "", targetHealthAll, true, []string{"c:8080", "a:9100", "b:9100", "d:9100"}},
+		{"health up", "", targetHealthUp, false, []string{"c:8080", "b:9100"}},
+		{"health down", "", targetHealthDown, false, []string{"a:9100"}},
+		{"dropped", "", targetHealthDropped, false, []string{"d:9100"}},
+		{"filter on job", "API", targetHealthAll, false, []string{"c:8080"}},
+		{"filter on instance", "b:91", targetHealthAll, false, []string{"b:9100"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := buildTargetRows(testTargets(), tt.filter, tt.health, tt.grouped)
+			if len(rows) != len(tt.want) {
+				t.Fatalf("buildTargetRows() returned %d rows, want %d", len(rows), len(tt.want))
+			}
+			for i, row := range rows {
+				if row.instance != tt.want[i] {
+					t.Errorf("rows[%d].instance = %q, want %q", i, row.instance, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestScrapeTargetUpQuery(t *testing.T) {
+	target := scrapeTarget{job: "node", instance: "host:9100"}
+	if got, want := target.upQuery(), `up{job="node",instance="host:9100"}`; got != want {
+		t.Errorf("upQuery() = %q, want %q", got, want)
+	}
+}
+
+func TestTargetsMode(t *testing.T) {
+	mockClient := &prometheus.MockClient{
+		TargetsFunc: func(_ context.Context, _ time.Duration) (v1.TargetsResult, error) {
+			return testTargets(), nil
+		},
+	}
+	m := NewTUIModel(mockClient, time.Hour, 15*time.Second, 100, 60*time.Second)
+	m.insertMode = false
+
+	updated, _ := m.switchToMode(ModeTargets)
+	m = updated.(TUIModel)
+	ctx := m.startLoading(ModeTargets)
+	updated, _ = m.Update(m.executeTargetsQuery(ctx)())
+	m = updated.(TUIModel)
+	if m.currentState() != StateResults {
+		t.Fatalf("currentState() = %v, want %v", m.currentState(), StateResults)
+	}
+
+	press := func(key string) {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		if key == "enter" {
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		}
+		updated, _ := m.Update(msg)
+		m = updated.(TUIModel)
+	}
+
+	press("i")
+
+	t.Run("s cycles the health filter", func(t *testing.T) {
+		press("s")
+		press("s")
+		if m.targetsHealth != targetHealthDown {
+			t.Errorf("targetsHealth = %v, want %v", m.targetsHealth, targetHealthDown)
+		}
+		if len(m.targetRows) != 1 {
+			t.Errorf("len(targetRows) = %d, want 1", len(m.targetRows))
+		}
+	})
+
+	t.Run("g toggles grouping", func(t *testing.T) {
+		press("g")
+		if !m.targetsGrouped {
+			t.Error("targetsGrouped = false, want true")
+		}
+	})
+
+	t.Run("enter opens up in /query_range", func(t *testing.T) {
+		press("enter")
+		if m.mode != ModeRange {
+			t.Fatalf("mode = %v, want %v", m.mode, ModeRange)
+		}
+		if want := `up{job="node",instance="a:9100"}`; m.queryInput.Value() != want {
+			t.Errorf("queryInput.Value() = %q, want %q", m.queryInput.Value(), want)
+		}
+	})
+}
@@ -0,0 +1,269 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+)
+
+// tsdbSection is one of the cardinality tables of the TSDB status.
+type tsdbSection int
+
+const (
+	tsdbSeriesByMetric tsdbSection = iota
+	tsdbValuesByLabel
+	tsdbSeriesByLabelPair
+	tsdbMemoryByLabel
+
+	// tsdbSectionCount is the number of sections. Keep it last.
+	tsdbSectionCount
+)
+
+func (s tsdbSection) String() string {
+	switch s {
+	case tsdbSeriesByMetric:
+		return "Series by metric"
+	case tsdbValuesByLabel:
+		return "Values by label"
+	case tsdbSeriesByLabelPair:
+		return "Series by label pair"
+	default:
+		return "Memory by label"
+	}
+}
+
+// headers returns the name and value column headers of the section's table.
+func (s tsdbSection) headers() (string, string) {
+	switch s {
+	case tsdbSeriesByMetric:
+		return "Metric", "Series"
+	case tsdbValuesByLabel:
+		return "Label", "Values"
+	case tsdbSeriesByLabelPair:
+		return "Label pair", "Series"
+	default:
+		return "Label", "Bytes"
+	}
+}
+
+// stats returns the section's statistics from a TSDB status result.
+func (s tsdbSection) stats(result v1.TSDBResult) []v1.Stat {
+	switch s {
+	case tsdbSeriesByMetric:
+		return result.SeriesCountByMetricName
+	case tsdbValuesByLabel:
+		return result.LabelValueCountByLabelName
+	case tsdbSeriesByLabelPair:
+		return result.SeriesCountByLabelValuePair
+	default:
+		return result.MemoryInBytesByLabelName
+	}
+}
+
+// seriesSelector returns the /series selector matching a statistic's
+// series, or false when the section has no series to drill into.
+func (s tsdbSection) seriesSelector(stat v1.Stat) (string, bool) {
+	switch s {
+	case tsdbSeriesByMetric:
+		return fmt.Sprintf("{__name__=%q}", stat.Name), true
+	case tsdbSeriesByLabelPair:
+		name, value, ok := strings.Cut(stat.Name, "=")
+		if !ok {
+			return "", false
+		}
+		return fmt.Sprintf("{%s=%q}", name, value), true
+	default:
+		return "", false
+	}
+}
+
+// filterStats returns the stats whose name contains filter (case-insensitive),
+// sorted by descending value, or by name when byName is set.
+func filterStats(stats []v1.Stat, filter string, byName bool) []v1.Stat {
+	filter = strings.ToLower(strings.TrimSpace(filter))
+
+	result := make([]v1.Stat, 0, len(stats))
+	for _, stat := range stats {
+		if filter == "" || strings.Contains(strings.ToLower(stat.Name), filter) {
+			result = append(result, stat)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if byName || result[i].Value == result[j].Value {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].Value > result[j].Value
+	})
+	return result
+}
+
+// formatBytes formats a byte count with a binary unit suffix.
+func formatBytes(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%dB", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+// TSDBMode handles the TSDB cardinality explorer (/tsdb)
+type TSDBMode struct{}
+
+func (TSDBMode) Name() string {
+	return "8) /tsdb"
+}
+
+func (TSDBMode) HandleInteractiveToggle(m *TUIModel) tea.Cmd {
+	m.legendFocused = !m.legendFocused
+	if m.legendFocused {
+		m.focusedPane = PaneLegend
+		m.queryInput.Blur()
+		m.tsdbTable = m.tsdbTable.Focused(true)
+	} else {
+		m.focusedPane = PaneQuery
+		// Stay in normal mode - don't focus query input
+		m.tsdbTable = m.tsdbTable.Focused(false)
+	}
+	return nil
+}
+
+func (TSDBMode) HandleLegendKey(m *TUIModel, msg tea.KeyMsg) tea.Cmd {
+	key := msg.String()
+
+	switch key {
+	case "q":
+		return tea.Quit
+	case "i", "esc":
+		// Exit interactive mode
+		m.legendFocused = false
+		m.focusedPane = PaneQuery
+		m.tsdbTable = m.tsdbTable.Focused(false)
+		return nil
+	case "]":
+		m.tsdbSection = (m.tsdbSection + 1) % tsdbSectionCount
+		m.tsdbTable = m.tsdbTable.WithHighlightedRow(0)
+		*m = m.renderTSDBTable()
+		return nil
+	case "[":
+		m.tsdbSection = (m.tsdbSection + tsdbSectionCount - 1) % tsdbSectionCount
+		m.tsdbTable = m.tsdbTable.WithHighlightedRow(0)
+		*m = m.renderTSDBTable()
+		return nil
+	case "o":
+		m.tsdbSortByName = !m.tsdbSortByName
+		*m = m.renderTSDBTable()
+		return nil
+	case "enter":
+		// Drill into the series of the selected metric or label pair
+		stats := m.tsdbStats()
+		index := m.tsdbTable.GetHighlightedRowIndex()
+		if index < 0 || index >= len(stats) {
+			return nil
+		}
+		selector, ok := m.tsdbSection.seriesSelector(stats[index])
+		if !ok {
+			return nil
+		}
+		m.tsdbTable = m.tsdbTable.Focused(false)
+		return m.openInSeriesMode(selector)
+	}
+
+	// Handle table navigation
+	var tableCmd tea.Cmd
+	switch key {
+	case "j":
+		m.tsdbTable, tableCmd = m.tsdbTable.Update(tea.KeyMsg{Type: tea.KeyDown})
+	case "k":
+		m.tsdbTable, tableCmd = m.tsdbTable.Update(tea.KeyMsg{Type: tea.KeyUp})
+	case "h":
+		m.tsdbTable, tableCmd = m.tsdbTable.Update(tea.KeyMsg{Type: tea.KeyPgUp})
+	case "l":
+		m.tsdbTable, tableCmd = m.tsdbTable.Update(tea.KeyMsg{Type: tea.KeyPgDown})
+	default:
+		m.tsdbTable, tableCmd = m.tsdbTable.Update(msg)
+	}
+	return tableCmd
+}
+
+func (TSDBMode) ExecuteQuery(ctx context.Context, m *TUIModel) tea.Cmd {
+	return m.executeTSDBQuery(ctx)
+}
+
+func (TSDBMode) RenderStatusParams(m *TUIModel) string {
+	sortBy := "value"
+	if m.tsdbSortByName {
+		sortBy = "name"
+	}
+	return fmt.Sprintf("   Limit: %d   Sort: %s", m.seriesLimit, sortBy)
+}
+
+func (TSDBMode) RenderResultsContent(m *TUIModel) string {
+	var s strings.Builder
+
+	// Head stats
+	head := m.tsdb.HeadStats
+	headStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("252")).Padding(0, 1)
+	headText := fmt.Sprintf("Head: %d series | %d label pairs | %d chunks",
+		head.NumSeries, head.NumLabelPairs, head.ChunkCount)
+	if head.MinTime > 0 && head.MaxTime >= head.MinTime {
+		headText += fmt.Sprintf(" | %s to %s",
+			time.UnixMilli(int64(head.MinTime)).UTC().Format(time.RFC3339),
+			time.UnixMilli(int64(head.MaxTime)).UTC().Format(time.RFC3339))
+	}
+	s.WriteString(headStyle.Render(headText))
+	s.WriteString("\n")
+
+	// Section tabs
+	tabStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
+	activeTabStyle := tabStyle.Bold(true).Background(lipgloss.Color("63")).Foreground(lipgloss.Color("231"))
+	tabs := make([]string, 0, tsdbSectionCount)
+	for section := range tsdbSectionCount {
+		style := tabStyle
+		if section == m.tsdbSection {
+			style = activeTabStyle
+		}
+		tabs = append(tabs, style.Render(" "+section.String()+" "))
+	}
+	s.WriteString(" " + strings.Join(tabs, " ") + "\n")
+
+	tableStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("63")).
+		Padding(0, 1)
+
+	if m.legendFocused {
+		tableStyle = tableStyle.BorderForeground(lipgloss.Color("205"))
+	}
+
+	s.WriteString(tableStyle.Render(m.tsdbTable.View()))
+	s.WriteString("\n")
+	return s.String()
+}
+
+func (TSDBMode) RenderResultsStatusBar(m *TUIModel) string {
+	return fmt.Sprintf(" | %s: %d", m.tsdbSection, len(m.tsdbStats()))
+}
+
+func (TSDBMode) OnSwitchTo(m *TUIModel) {
+	if m.currentState() == StateResults {
+		*m = m.renderTSDBTable()
+		*m = m.syncViewportContent()
+	}
+}
+
+// tsdbStats returns the statistics shown in the current TSDB table.
+func (m TUIModel) tsdbStats() []v1.Stat {
+	return filterStats(m.tsdbSection.stats(m.tsdb), m.modeQueries[ModeTSDB], m.tsdbSortByName)
+}
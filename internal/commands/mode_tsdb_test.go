@@ -0,0 +1,143 @@
+package commands
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/akasprzok/peat/internal/prometheus"
+	tea "github.com/charmbracelet/bubbletea"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+)
+
+func TestFilterStats(t *testing.T) {
+	stats := []v1.Stat{
+		{Name: "b_metric", Value: 10},
+		{Name: "a_metric", Value: 10},
+		{Name: "c_other", Value: 50},
+	}
+
+	tests := []struct {
+		name   string
+		filter string
+		byName bool
+		want   []string
+	}{
+		{"by value", "", false, []string{"c_other", "a_metric", "b_metric"}},
+		{"by name", "", true, []string{"a_metric", "b_metric", "c_other"}},
+		{"filtered", "METRIC", false, []string{"a_metric", "b_metric"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filterStats(stats, tt.filter, tt.byName)
+			if len(got) != len(tt.want) {
+				t.Fatalf("filterStats() returned %d stats, want %d", len(got), len(tt.want))
+			}
+			for i, stat := range got {
+				if stat.Name != tt.want[i] {
+					t.Errorf("filterStats()[%d] = %q, want %q", i, stat.Name, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		bytes uint64
+		want  string
+	}{
+		{0, "0B"},
+		{1023, "1023B"},
+		{1024, "1.0KiB"},
+		{1536, "1.5KiB"},
+		{5 * 1024 * 1024, "5.0MiB"},
+		{3 << 30, "3.0GiB"},
+	}
+
+	for _, tt := range tests {
+		if got := formatBytes(tt.bytes); got != tt.want {
+			t.Errorf("formatBytes(%d) = %q, want %q", tt.bytes, got, tt.want)
+		}
+	}
+}
+
+func TestTSDBSectionSeriesSelector(t *testing.T) {
+	tests := []struct {
+		section tsdbSection
+		stat    string
+		want    string
+		wantOK  bool
+	}{
+		{tsdbSeriesByMetric, "up", `{__name__="up"}`, true},
+		{tsdbSeriesByLabelPair, `job=node "exporter"`, `{job="node \"exporter\""}`, true},
+		{tsdbSeriesByLabelPair, "malformed", "", false},
+		{tsdbValuesByLabel, "job", "", false},
+		{tsdbMemoryByLabel, "job", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.section.String(), func(t *testing.T) {
+			got, ok := tt.section.seriesSelector(v1.Stat{Name: tt.stat})
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("seriesSelector(%q) = %q, %v, want %q, %v", tt.stat, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTSDBDrillIntoSeries(t *testing.T) {
+	var seriesMatches []string
+	mockClient := &prometheus.MockClient{
+		TSDBFunc: func(_ context.Context, _ uint64, _ time.Duration) (v1.TSDBResult, error) {
+			return v1.TSDBResult{
+				HeadStats:                   v1.TSDBHeadStats{NumSeries: 100},
+				SeriesCountByMetricName:     []v1.Stat{{Name: "up", Value: 10}},
+				SeriesCountByLabelValuePair: []v1.Stat{{Name: "job=api", Value: 5}, {Name: "job=node", Value: 60}},
+			}, nil
+		},
+		SeriesFunc: func(_ context.Context, matches []string, _, _ time.Time, _ uint64, _ time.Duration) ([]model.LabelSet, v1.Warnings, error) {
+			seriesMatches = matches
+			return nil, nil, nil
+		},
+	}
+	m := NewTUIModel(mockClient, time.Hour, 15*time.Second, 100, 60*time.Second)
+	m.insertMode = false
+
+	updated, _ := m.switchToMode(ModeTSDB)
+	m = updated.(TUIModel)
+	ctx := m.startLoading(ModeTSDB)
+	updated, _ = m.Update(m.executeTSDBQuery(ctx)())
+	m = updated.(TUIModel)
+
+	var cmd tea.Cmd
+	for _, key := range []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune("i")},
+		{Type: tea.KeyRunes, Runes: []rune("]")},
+		{Type: tea.KeyRunes, Runes: []rune("]")},
+		{Type: tea.KeyEnter},
+	} {
+		updated, cmd = m.Update(key)
+		m = updated.(TUIModel)
+	}
+
+	if m.mode != ModeSeries {
+		t.Fatalf("mode = %v, want %v", m.mode, ModeSeries)
+	}
+	if m.currentState() != StateLoading {
+		t.Fatalf("currentState() = %v, want %v", m.currentState(), StateLoading)
+	}
+
+	// The series query runs as part of the returned batch
+	for _, msg := range cmd().(tea.BatchMsg) {
+		if msg != nil {
+			msg()
+			break
+		}
+	}
+	if len(seriesMatches) != 1 || seriesMatches[0] != `{job="node"}` {
+		t.Errorf("series matches = %q, want the largest label pair", seriesMatches)
+	}
+}
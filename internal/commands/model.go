@@ -1,10 +1,15 @@
 package commands
 
 import (
+	"context"
 	"fmt"
+	"text/template"
 	"time"
 
 	"github.com/akasprzok/peat/internal/charts"
+	"github.com/akasprzok/peat/internal/config"
+	"github.com/akasprzok/peat/internal/editor"
+	"github.com/akasprzok/peat/internal/history"
 	"github.com/akasprzok/peat/internal/prometheus"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
@@ -21,31 +26,102 @@ type TUIModel struct {
 	promClient prometheus.Client
 	timeout    time.Duration
 
+	// Datasources
+	datasources      []config.Datasource
+	activeDatasource int
+	connect          ConnectFunc
+	defaults         datasourceDefaults
+
 	// Input
-	queryInput textinput.Model
+	queryInput editor.Model
 
 	// Mode
 	mode QueryMode
 
 	// Per-mode state (indexed by QueryMode)
-	modeQueries   [4]string        // Query string for each mode
-	modeStates    [4]TUIState      // State for each mode
-	modeWarnings  [4]v1.Warnings   // Warnings for each mode
-	modeErrors    [4]error         // Errors for each mode
-	modeDurations [4]time.Duration // Query execution duration for each mode
+	modeQueries   [modeCount]string                 // Query string for each mode
+	modeStates    [modeCount]TUIState               // State for each mode
+	modeWarnings  [modeCount]v1.Warnings            // Warnings for each mode
+	modeErrors    [modeCount]error                  // Errors for each mode
+	modeDurations [modeCount]time.Duration          // Query execution duration for each mode
+	modeStats     [modeCount]*prometheus.QueryStats // Server-side query stats, when reported
+
+	// In-flight query state (indexed by QueryMode)
+	modeCancels    [modeCount]context.CancelFunc // Cancels the mode's in-flight query
+	modeStartTimes [modeCount]time.Time          // When the mode's in-flight query started
+	modeQueryIDs   [modeCount]uint64             // Identifies the mode's in-flight query, 0 if none
+	lastQueryID    uint64                        // ID of the last query started
 
 	// Range query parameters
 	rangeValue time.Duration
 	stepValue  time.Duration
 
+	// Exemplars
+	showExemplars bool                     // Overlay exemplars on range charts
+	exemplars     []v1.ExemplarQueryResult // Exemplars of the last range query
+	exemplarURL   *template.Template       // Renders an exemplar's trace URL from its labels
+
+	// Query stats
+	showStats bool // Show query stats, which are only requested while shown
+
+	// Native histograms
+	histogramValues    []charts.HistogramValue  // Values histograms can be plotted as in range charts
+	histogramValue     int                      // Index of the value histograms are plotted as
+	histogramQuantiles map[float64]model.Matrix // histogram_quantile results of the last range query, by quantile
+
+	// Instant query parameters
+	evalTime        EvalTime
+	evalTimeInput   textinput.Model
+	editingEvalTime bool
+	evalTimeErr     error
+
 	// Series query parameters
 	seriesLimit uint64
 
 	// Results (already per-mode by nature)
-	vector model.Vector     // For instant queries
-	matrix model.Matrix     // For range queries
-	series []model.LabelSet // For series queries
-	labels []string         // For labels queries
+	instantValue model.Value            // For instant queries (vector, scalar, string or matrix)
+	matrix       model.Matrix           // For range queries
+	series       []model.LabelSet       // For series queries
+	labels       []string               // For labels queries
+	metadata     []metricMetadata       // For metadata queries, sorted by metric name
+	targets      v1.TargetsResult       // For targets queries
+	rules        prometheus.RulesResult // For rules queries
+	tsdb         v1.TSDBResult          // For TSDB status queries
+
+	// Server status
+	buildinfo    v1.BuildinfoResult
+	runtimeinfo  v1.RuntimeinfoResult
+	flags        v1.FlagsResult
+	serverConfig v1.ConfigResult
+
+	// Alertmanager
+	alertmanager    prometheus.AlertmanagerClient // nil when the datasource has no Alertmanager
+	alertmanagerErr error                         // Why the configured Alertmanager's client couldn't be created
+	alertGroups     []prometheus.AlertGroup
+	silences        []prometheus.Silence
+
+	// Targets view state
+	targetRows     []scrapeTarget     // Targets shown in the table, after filtering and sorting
+	targetsGrouped bool               // True when targets are grouped by job
+	targetsHealth  targetHealthFilter // Only show targets with this health
+
+	// Rules view state
+	ruleRows   []ruleRow        // Groups, rules and alerts shown in the table
+	rulesState alertStateFilter // Only show alerting rules in this state
+
+	// TSDB view state
+	tsdbSection    tsdbSection // Statistics table currently shown
+	tsdbSortByName bool        // Sort statistics by name instead of by value
+
+	// Alertmanager view state
+	alertmanagerView alertmanagerView     // Table currently shown
+	alertRows        []alertRow           // Route groups and alerts shown in the alerts table
+	silenceRows      []prometheus.Silence // Silences shown in the silences table
+	silenceNotice    string               // Result of the last silence write
+	silenceErr       error                // Error of the last silence write
+	showSilenceForm  bool
+	silenceForm      silenceForm
+	confirm          *confirmation // Pending write awaiting confirmation, if any
 
 	// Label values state
 	labelValues        []string // Values for selected label
@@ -59,6 +135,11 @@ type TUIModel struct {
 	legendTable        teatable.Model
 	seriesTable        teatable.Model
 	labelsTable        teatable.Model
+	metadataTable      teatable.Model
+	targetsTable       teatable.Model
+	rulesTable         teatable.Model
+	tsdbTable          teatable.Model
+	alertmanagerTable  teatable.Model
 	selectedIndex      int          // -1 means no selection
 	highlightedIndices map[int]bool // pinned series indices for multi-series display
 
@@ -71,34 +152,89 @@ type TUIModel struct {
 	spinner              spinner.Model
 	legendFocused        bool
 	showShortcutsOverlay bool
+	showDatasourcePicker bool
+	showStatsOverlay     bool
+	datasourceCursor     int
+	datasourceErr        error
 	resultsViewport      viewport.Model
+
+	// Query completion state
+	completionCaches map[int]*completionCache // Names suggestions are made from, by datasource index
+	completions      []completion             // Suggestions for the word at the cursor, shown in a popup
+	completionCursor int                      // Index of the selected suggestion
+	completionCtx    completionContext        // Word the suggestions complete
+	completionErr    error                    // Why names suggestions are made from couldn't be fetched
+
+	// Query history
+	history            *history.Store  // nil when history is disabled
+	historyRecall      []history.Entry // Entries recalled with up/down, newest first
+	historyIndex       int             // Index of the recalled entry, -1 when editing a new query
+	historyDraft       string          // Query being edited before recalling entries
+	showHistorySearch  bool
+	historySearchInput textinput.Model
+	historyMatches     []historyMatch // Entries matching the search, best first
+	historyCursor      int
+
+	// Saved queries
+	libraryPath     string // Personal library queries are saved to, "" when unknown
+	teamLibraryPath string // Shared library browsed alongside the personal one, if any
+	showSaveForm    bool
+	saveForm        saveQueryForm
+	libraryNotice   string // Result of the last save
+	showLibrary     bool
+	libraryInput    textinput.Model
+	libraryQueries  []libraryQuery // Queries of both libraries
+	libraryMatches  []libraryQuery // Queries matching the filter
+	libraryCursor   int
+	libraryErr      error // Errors reading the libraries
 }
 
 // NewTUIModel creates a new TUI model.
 func NewTUIModel(client prometheus.Client, rangeValue, stepValue time.Duration, seriesLimit uint64, timeout time.Duration) TUIModel {
-	ti := textinput.New()
+	ti := editor.New()
 	ti.Placeholder = "Enter PromQL query..."
 	ti.Focus()
 	ti.Width = 60
+	ti.MaxHeight = QueryEditorMaxLines
+
+	eti := textinput.New()
+	eti.Placeholder = "now, 2024-01-02T03:04:05Z, -2h or 1700000000"
+	eti.Width = 40
+
+	hti := textinput.New()
+	hti.Prompt = "(reverse-i-search) "
+	hti.Placeholder = "fuzzy search"
+	hti.Width = 60
+
+	lti := textinput.New()
+	lti.Prompt = "Filter: "
+	lti.Placeholder = "name, tag, description or query"
+	lti.Width = 60
 
 	vp := viewport.New(DefaultTerminalWidth, DefaultTerminalHeight-ChromeHeightExpanded)
 	vp.Style = lipgloss.NewStyle().Background(lipgloss.Color("235"))
 
 	return TUIModel{
 		promClient:         client,
+		activeDatasource:   -1,
 		timeout:            timeout,
 		queryInput:         ti,
 		mode:               ModeInstant,
-		modeStates:         [4]TUIState{StateInput, StateInput, StateInput, StateInput},
+		evalTimeInput:      eti,
 		rangeValue:         rangeValue,
 		stepValue:          stepValue,
 		seriesLimit:        seriesLimit,
+		histogramValues:    histogramValues(DefaultHistogramQuantiles),
 		selectedIndex:      -1,
 		highlightedIndices: make(map[int]bool),
 		focusedPane:        PaneQuery,
 		insertMode:         true, // Start in insert mode so users can immediately type
 		spinner:            NewLoadingSpinner(),
 		resultsViewport:    vp,
+		completionCaches:   make(map[int]*completionCache),
+		historyIndex:       -1,
+		historySearchInput: hti,
+		libraryInput:       lti,
 	}
 }
 
@@ -119,6 +255,10 @@ func (m TUIModel) currentDuration() time.Duration {
 	return m.modeDurations[m.mode]
 }
 
+func (m TUIModel) currentElapsed() time.Duration {
+	return time.Since(m.modeStartTimes[m.mode])
+}
+
 // applyResultCommon applies common result handling for all query result handlers.
 func (m TUIModel) applyResultCommon(mode QueryMode, warnings v1.Warnings, err error, duration time.Duration) TUIModel {
 	m.modeWarnings[mode] = warnings
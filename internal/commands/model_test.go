@@ -1,12 +1,18 @@
 package commands
 
 import (
+	"context"
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/akasprzok/peat/internal/config"
 	"github.com/akasprzok/peat/internal/prometheus"
+	tea "github.com/charmbracelet/bubbletea"
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
 )
 
 func TestFormatDuration(t *testing.T) {
@@ -175,3 +181,189 @@ func TestCurrentStateAccessors(t *testing.T) {
 		}
 	})
 }
+
+func TestCancelQuery(t *testing.T) {
+	queryStarted := make(chan struct{})
+	mockClient := &prometheus.MockClient{
+		QueryFunc: func(ctx context.Context, _ string, _ time.Time, _ time.Duration, _ bool) (v1.Warnings, model.Value, *prometheus.QueryStats, error) {
+			close(queryStarted)
+			<-ctx.Done()
+			return nil, nil, nil, ctx.Err()
+		},
+	}
+	m := NewTUIModel(mockClient, time.Hour, 15*time.Second, 100, 60*time.Second)
+	m.queryInput.SetValue("up")
+	m.insertMode = false
+
+	ctx := m.startLoading(ModeInstant)
+	results := make(chan tea.Msg, 1)
+	go func() {
+		results <- m.executeInstantQuery(ctx)()
+	}()
+	<-queryStarted
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(TUIModel)
+
+	t.Run("request context is cancelled", func(t *testing.T) {
+		msg := (<-results).(tuiInstantResultMsg)
+		if !errors.Is(msg.err, context.Canceled) {
+			t.Errorf("err = %v, want %v", msg.err, context.Canceled)
+		}
+
+		// The late result of the cancelled query must not change state
+		updated, _ = m.Update(msg)
+		m = updated.(TUIModel)
+	})
+
+	t.Run("state returns to StateInput", func(t *testing.T) {
+		if m.currentState() != StateInput {
+			t.Errorf("currentState() = %v, want %v", m.currentState(), StateInput)
+		}
+	})
+
+	t.Run("query is kept", func(t *testing.T) {
+		if m.queryInput.Value() != "up" {
+			t.Errorf("queryInput.Value() = %q, want %q", m.queryInput.Value(), "up")
+		}
+	})
+
+	t.Run("cancel func is released", func(t *testing.T) {
+		if m.modeCancels[ModeInstant] != nil {
+			t.Error("modeCancels[ModeInstant] not cleared after cancel")
+		}
+	})
+}
+
+// TestStaleResults checks that results of a query that is no longer in
+// flight are ignored, even when the query itself succeeded.
+func TestStaleResults(t *testing.T) {
+	mockClient := &prometheus.MockClient{
+		QueryFunc: func(_ context.Context, _ string, _ time.Time, _ time.Duration, _ bool) (v1.Warnings, model.Value, *prometheus.QueryStats, error) {
+			return nil, model.Vector{&model.Sample{Value: 1}}, nil, nil
+		},
+	}
+	newModel := func() TUIModel {
+		m := NewTUIModel(mockClient, time.Hour, 15*time.Second, 100, 60*time.Second).
+			WithDatasources([]config.Datasource{{Name: "prod"}, {Name: "staging"}}, 0, func(config.Datasource) (prometheus.Client, error) {
+				return mockClient, nil
+			})
+		m.queryInput.SetValue("up")
+		m.insertMode = false
+		return m
+	}
+	update := func(m TUIModel, msg tea.Msg) TUIModel {
+		updated, _ := m.Update(msg)
+		return updated.(TUIModel)
+	}
+
+	t.Run("completed after cancelling", func(t *testing.T) {
+		m := newModel()
+		ctx := m.startLoading(ModeInstant)
+		msg := m.executeInstantQuery(ctx)()
+		m = update(m, tea.KeyMsg{Type: tea.KeyEsc})
+		if m = update(m, msg); m.currentState() != StateInput || m.instantValue != nil {
+			t.Errorf("currentState() = %v with value %v, want %v without results", m.currentState(), m.instantValue, StateInput)
+		}
+	})
+
+	t.Run("superseded by another query", func(t *testing.T) {
+		m := newModel()
+		ctx := m.startLoading(ModeInstant)
+		stale := m.executeInstantQuery(ctx)()
+		ctx = m.startLoading(ModeInstant)
+		fresh := m.executeInstantQuery(ctx)()
+		if m = update(m, stale); m.currentState() != StateLoading {
+			t.Errorf("currentState() = %v after the superseded result, want %v", m.currentState(), StateLoading)
+		}
+		if m = update(m, fresh); m.currentState() != StateResults {
+			t.Errorf("currentState() = %v after the current result, want %v", m.currentState(), StateResults)
+		}
+	})
+
+	t.Run("completed after switching datasource", func(t *testing.T) {
+		m := newModel()
+		ctx := m.startLoading(ModeInstant)
+		msg := m.executeInstantQuery(ctx)()
+		m = update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+		updated, _ := m.switchDatasource(1)
+		if m = update(updated.(TUIModel), msg); m.currentState() != StateInput || m.instantValue != nil {
+			t.Errorf("currentState() = %v with value %v, want the previous datasource's result ignored", m.currentState(), m.instantValue)
+		}
+	})
+}
+
+// TestReplaySession runs queries against a session recorded with --record.
+func TestCreateRecording(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	for range 2 {
+		f, err := createRecording(path)
+		if err != nil {
+			t.Fatalf("createRecording() error = %v", err)
+		}
+		if _, err := f.WriteString("{}\n"); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("recording mode = %v, want -rw-------", info.Mode().Perm())
+	}
+	if info.Size() != 3 {
+		t.Errorf("recording is %d bytes, want the previous session replaced", info.Size())
+	}
+}
+
+func TestReplaySession(t *testing.T) {
+	f, err := os.Open("testdata/session.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	replay, err := prometheus.LoadReplay(f)
+	if err != nil {
+		t.Fatalf("LoadReplay() error = %v", err)
+	}
+
+	ds := config.Datasource{Name: "replay", URL: replayURL}
+	client, err := connector(nil, replay, nil)(ds)
+	if err != nil {
+		t.Fatalf("connect() error = %v", err)
+	}
+	if err := checkConnection(context.Background(), client, ds, time.Minute); err != nil {
+		t.Fatalf("checkConnection() error = %v", err)
+	}
+
+	m := NewTUIModel(client, time.Hour, 15*time.Second, 100, 60*time.Second)
+	m.insertMode = false
+	m.showStats = true // The query was recorded with stats shown
+	m.queryInput.SetValue("up")
+
+	ctx := m.startLoading(ModeInstant)
+	updated, _ := m.Update(m.executeInstantQuery(ctx)())
+	m = updated.(TUIModel)
+	if err := m.modeErrors[ModeInstant]; err != nil {
+		t.Fatalf("instant query error = %v", err)
+	}
+	if vector, ok := m.instantValue.(model.Vector); !ok || len(vector) != 2 {
+		t.Errorf("instantValue = %v, want 2 samples", m.instantValue)
+	}
+	if m.modeStats[ModeInstant] == nil {
+		t.Error("modeStats[ModeInstant] = nil, want the recorded stats")
+	}
+
+	ctx = m.startLoading(ModeSeries)
+	updated, _ = m.Update(m.executeSeriesQuery(ctx)())
+	m = updated.(TUIModel)
+	if err := m.modeErrors[ModeSeries]; err != nil {
+		t.Fatalf("series query error = %v", err)
+	}
+	if len(m.series) != 2 {
+		t.Errorf("series = %v, want 2 series", m.series)
+	}
+}
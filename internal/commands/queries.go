@@ -1,64 +1,141 @@
 package commands
 
 import (
+	"context"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/akasprzok/peat/internal/prometheus"
 	tea "github.com/charmbracelet/bubbletea"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
 )
 
 func (m TUIModel) executeQuery() (tea.Model, tea.Cmd) {
 	// Save query for this mode
 	m.modeQueries[m.mode] = m.queryInput.Value()
-	m.modeStates[m.mode] = StateLoading
 	m.modeErrors[m.mode] = nil
 	m.modeWarnings[m.mode] = nil
+	m.libraryNotice = ""
 	m.queryInput.Blur()
 
-	cmd := m.currentMode().ExecuteQuery(&m)
-	return m, cmd
+	ctx := m.startLoading(m.mode)
+	cmd := m.currentMode().ExecuteQuery(ctx, &m)
+	return m, tea.Batch(cmd, m.spinner.Tick)
 }
 
-func (m TUIModel) executeInstantQuery() tea.Cmd {
+// startLoading puts the given mode into the loading state and returns a
+// context that is cancelled when the user aborts the query.
+func (m *TUIModel) startLoading(mode QueryMode) context.Context {
+	m.finishLoading(mode)
+	ctx, cancel := context.WithCancel(context.Background())
+	m.lastQueryID++
+	m.modeStates[mode] = StateLoading
+	m.modeCancels[mode] = cancel
+	m.modeQueryIDs[mode] = m.lastQueryID
+	m.modeStartTimes[mode] = time.Now()
+	return ctx
+}
+
+// finishLoading releases the cancel function of a mode's in-flight query.
+// Results of the query arriving later are ignored.
+func (m *TUIModel) finishLoading(mode QueryMode) {
+	if m.modeCancels[mode] != nil {
+		m.modeCancels[mode]()
+		m.modeCancels[mode] = nil
+	}
+	m.modeQueryIDs[mode] = 0
+}
+
+// cancelQuery aborts the current mode's in-flight query and returns
+// the mode to the input state, keeping the query text.
+func (m TUIModel) cancelQuery() (tea.Model, tea.Cmd) {
+	m.finishLoading(m.mode)
+	m.modeStates[m.mode] = StateInput
+	m.inputCollapsed = false
+	m.resultsViewport.Height = m.getAvailableResultsHeight()
+	return m, nil
+}
+
+// isStale reports whether a result of mode comes from another query than
+// the one in flight: a cancelled or superseded query, or one run against
+// the previous datasource. Such results must not overwrite the mode's state.
+func (m TUIModel) isStale(mode QueryMode, queryID uint64) bool {
+	return queryID == 0 || queryID != m.modeQueryIDs[mode]
+}
+
+func (m TUIModel) executeInstantQuery(ctx context.Context) tea.Cmd {
 	query := m.queryInput.Value()
+	queryID := m.modeQueryIDs[ModeInstant]
 	return func() tea.Msg {
 		start := time.Now()
-		warnings, vector, err := m.promClient.Query(query, m.timeout)
+		warnings, value, stats, err := m.promClient.Query(ctx, query, m.evalTime.Resolve(start), m.timeout, m.showStats)
 		duration := time.Since(start)
 		return tuiInstantResultMsg{
+			queryID:  queryID,
 			warnings: warnings,
-			vector:   vector,
+			value:    value,
+			stats:    stats,
 			err:      err,
 			duration: duration,
 		}
 	}
 }
 
-func (m TUIModel) executeRangeQuery() tea.Cmd {
+func (m TUIModel) executeRangeQuery(ctx context.Context) tea.Cmd {
 	query := m.queryInput.Value()
+	queryID := m.modeQueryIDs[ModeRange]
 	return func() tea.Msg {
 		start := time.Now()
 		end := start
 		rangeStart := end.Add(-m.rangeValue)
-		matrix, warnings, err := m.promClient.QueryRange(query, rangeStart, end, m.stepValue, m.timeout)
+		matrix, warnings, stats, err := m.promClient.QueryRange(ctx, query, rangeStart, end, m.stepValue, m.timeout, m.showStats)
+
+		// Quantiles of native histograms are computed by the datasource. Like
+		// exemplars, they are optional: a failure is reported as a warning
+		var quantiles map[float64]model.Matrix
+		if err == nil && hasHistograms(matrix) {
+			var quantileWarnings v1.Warnings
+			quantiles, quantileWarnings = m.queryHistogramQuantiles(ctx, query, rangeStart, end)
+			warnings = append(warnings, quantileWarnings...)
+		}
+
+		// Exemplars are optional: a failure is reported as a warning
+		var exemplars []v1.ExemplarQueryResult
+		if err == nil && m.showExemplars {
+			var exemplarErr error
+			exemplars, exemplarErr = m.promClient.QueryExemplars(ctx, query, rangeStart, end, m.timeout)
+			if exemplarErr != nil {
+				warnings = append(warnings, "exemplars: "+exemplarErr.Error())
+			}
+		}
+
 		duration := time.Since(start)
 		return tuiRangeResultMsg{
-			warnings: warnings,
-			matrix:   matrix,
-			err:      err,
-			duration: duration,
+			queryID:   queryID,
+			warnings:  warnings,
+			matrix:    matrix,
+			exemplars: exemplars,
+			quantiles: quantiles,
+			stats:     stats,
+			err:       err,
+			duration:  duration,
 		}
 	}
 }
 
-func (m TUIModel) executeSeriesQuery() tea.Cmd {
-	query := m.queryInput.Value()
+func (m TUIModel) executeSeriesQuery(ctx context.Context) tea.Cmd {
+	matches := splitSelectors(m.queryInput.Value())
+	queryID := m.modeQueryIDs[ModeSeries]
 	return func() tea.Msg {
 		start := time.Now()
 		end := start
 		rangeStart := end.Add(-m.rangeValue)
-		series, warnings, err := m.promClient.Series(query, rangeStart, end, m.seriesLimit, m.timeout)
+		series, warnings, err := m.promClient.Series(ctx, matches, rangeStart, end, m.seriesLimit, m.timeout)
 		duration := time.Since(start)
 		return tuiSeriesResultMsg{
+			queryID:  queryID,
 			warnings: warnings,
 			series:   series,
 			err:      err,
@@ -67,14 +144,17 @@ func (m TUIModel) executeSeriesQuery() tea.Cmd {
 	}
 }
 
-func (m TUIModel) executeLabelsQuery() tea.Cmd {
+func (m TUIModel) executeLabelsQuery(ctx context.Context) tea.Cmd {
+	matches := splitSelectors(m.queryInput.Value())
+	queryID := m.modeQueryIDs[ModeLabels]
 	return func() tea.Msg {
 		start := time.Now()
 		end := start
 		rangeStart := end.Add(-m.rangeValue)
-		labels, warnings, err := m.promClient.LabelNames(rangeStart, end, m.timeout)
+		labels, warnings, err := m.promClient.LabelNames(ctx, matches, rangeStart, end, m.timeout)
 		duration := time.Since(start)
 		return tuiLabelsResultMsg{
+			queryID:  queryID,
 			warnings: warnings,
 			labels:   labels,
 			err:      err,
@@ -83,14 +163,18 @@ func (m TUIModel) executeLabelsQuery() tea.Cmd {
 	}
 }
 
-func (m TUIModel) executeLabelValuesQuery(labelName string) tea.Cmd {
+func (m TUIModel) executeLabelValuesQuery(ctx context.Context, labelName string) tea.Cmd {
+	// Scope values to the selector the label names were fetched with
+	matches := splitSelectors(m.modeQueries[ModeLabels])
+	queryID := m.modeQueryIDs[ModeLabels]
 	return func() tea.Msg {
 		start := time.Now()
 		end := start
 		rangeStart := end.Add(-m.rangeValue)
-		values, warnings, err := m.promClient.LabelValues(labelName, rangeStart, end, m.timeout)
+		values, warnings, err := m.promClient.LabelValues(ctx, labelName, matches, rangeStart, end, m.timeout)
 		duration := time.Since(start)
 		return tuiLabelValuesResultMsg{
+			queryID:   queryID,
 			labelName: labelName,
 			warnings:  warnings,
 			values:    values,
@@ -100,9 +184,124 @@ func (m TUIModel) executeLabelValuesQuery(labelName string) tea.Cmd {
 	}
 }
 
+func (m TUIModel) executeMetadataQuery(ctx context.Context) tea.Cmd {
+	queryID := m.modeQueryIDs[ModeMetadata]
+	return func() tea.Msg {
+		start := time.Now()
+		metadata, err := m.promClient.Metadata(ctx, "", m.timeout)
+		duration := time.Since(start)
+		return tuiMetadataResultMsg{
+			queryID:  queryID,
+			metadata: metadata,
+			err:      err,
+			duration: duration,
+		}
+	}
+}
+
+func (m TUIModel) executeTargetsQuery(ctx context.Context) tea.Cmd {
+	queryID := m.modeQueryIDs[ModeTargets]
+	return func() tea.Msg {
+		start := time.Now()
+		targets, err := m.promClient.Targets(ctx, m.timeout)
+		duration := time.Since(start)
+		return tuiTargetsResultMsg{
+			queryID:  queryID,
+			targets:  targets,
+			err:      err,
+			duration: duration,
+		}
+	}
+}
+
+func (m TUIModel) executeRulesQuery(ctx context.Context) tea.Cmd {
+	queryID := m.modeQueryIDs[ModeRules]
+	return func() tea.Msg {
+		start := time.Now()
+		rules, err := m.promClient.Rules(ctx, m.timeout)
+		duration := time.Since(start)
+		return tuiRulesResultMsg{
+			queryID:  queryID,
+			rules:    rules,
+			err:      err,
+			duration: duration,
+		}
+	}
+}
+
+func (m TUIModel) executeTSDBQuery(ctx context.Context) tea.Cmd {
+	queryID := m.modeQueryIDs[ModeTSDB]
+	return func() tea.Msg {
+		start := time.Now()
+		tsdb, err := m.promClient.TSDB(ctx, m.seriesLimit, m.timeout)
+		duration := time.Since(start)
+		return tuiTSDBResultMsg{
+			queryID:  queryID,
+			tsdb:     tsdb,
+			err:      err,
+			duration: duration,
+		}
+	}
+}
+
+func (m TUIModel) executeStatusQuery(ctx context.Context) tea.Cmd {
+	queryID := m.modeQueryIDs[ModeStatus]
+	return func() tea.Msg {
+		start := time.Now()
+		msg := tuiStatusResultMsg{queryID: queryID}
+		msg.buildinfo, msg.err = m.promClient.Buildinfo(ctx, m.timeout)
+		if msg.err == nil {
+			var err error
+			if msg.runtimeinfo, err = m.promClient.Runtimeinfo(ctx, m.timeout); err != nil {
+				msg.warnings = append(msg.warnings, "runtimeinfo: "+err.Error())
+			}
+			if msg.flags, err = m.promClient.Flags(ctx, m.timeout); err != nil {
+				msg.warnings = append(msg.warnings, "flags: "+err.Error())
+			}
+			if msg.config, err = m.promClient.Config(ctx, m.timeout); err != nil {
+				msg.warnings = append(msg.warnings, "config: "+err.Error())
+			}
+			if ctx.Err() != nil {
+				msg.err = ctx.Err()
+			}
+		}
+		msg.duration = time.Since(start)
+		return msg
+	}
+}
+
+func (m TUIModel) executeAlertmanagerQuery(ctx context.Context) tea.Cmd {
+	client, clientErr := m.alertmanager, m.alertmanagerError()
+	queryID := m.modeQueryIDs[ModeAlertmanager]
+	return func() tea.Msg {
+		if client == nil {
+			return tuiAlertmanagerResultMsg{queryID: queryID, err: clientErr}
+		}
+		start := time.Now()
+		groups, err := client.AlertGroups(ctx, m.timeout)
+		var silences []prometheus.Silence
+		if err == nil {
+			silences, err = client.Silences(ctx, m.timeout)
+		}
+		duration := time.Since(start)
+		return tuiAlertmanagerResultMsg{
+			queryID:  queryID,
+			groups:   groups,
+			silences: silences,
+			err:      err,
+			duration: duration,
+		}
+	}
+}
+
 func (m TUIModel) handleInstantResult(msg tuiInstantResultMsg) (tea.Model, tea.Cmd) {
+	if m.isStale(ModeInstant, msg.queryID) {
+		return m, nil
+	}
+	m.finishLoading(ModeInstant)
 	m = m.applyResultCommon(ModeInstant, msg.warnings, msg.err, msg.duration)
-	m.vector = msg.vector
+	m.instantValue = msg.value
+	m.modeStats[ModeInstant] = msg.stats
 
 	if msg.err != nil {
 		m.modeStates[ModeInstant] = StateError
@@ -113,12 +312,20 @@ func (m TUIModel) handleInstantResult(msg tuiInstantResultMsg) (tea.Model, tea.C
 	m.resultsViewport.Height = m.getAvailableResultsHeight()
 	m = m.renderInstantChart()
 	m = m.syncViewportContent()
+	m = m.recordHistory(ModeInstant, msg.duration, valueSize(msg.value))
 	return m, nil
 }
 
 func (m TUIModel) handleRangeResult(msg tuiRangeResultMsg) (tea.Model, tea.Cmd) {
+	if m.isStale(ModeRange, msg.queryID) {
+		return m, nil
+	}
+	m.finishLoading(ModeRange)
 	m = m.applyResultCommon(ModeRange, msg.warnings, msg.err, msg.duration)
 	m.matrix = msg.matrix
+	m.exemplars = msg.exemplars
+	m.histogramQuantiles = msg.quantiles
+	m.modeStats[ModeRange] = msg.stats
 
 	if msg.err != nil {
 		m.modeStates[ModeRange] = StateError
@@ -131,10 +338,15 @@ func (m TUIModel) handleRangeResult(msg tuiRangeResultMsg) (tea.Model, tea.Cmd)
 	m.resultsViewport.Height = m.getAvailableResultsHeight()
 	m = m.renderRangeChart()
 	m = m.syncViewportContent()
+	m = m.recordHistory(ModeRange, msg.duration, len(msg.matrix))
 	return m, nil
 }
 
 func (m TUIModel) handleSeriesResult(msg tuiSeriesResultMsg) (tea.Model, tea.Cmd) {
+	if m.isStale(ModeSeries, msg.queryID) {
+		return m, nil
+	}
+	m.finishLoading(ModeSeries)
 	m = m.applyResultCommon(ModeSeries, msg.warnings, msg.err, msg.duration)
 	m.series = msg.series
 
@@ -147,10 +359,15 @@ func (m TUIModel) handleSeriesResult(msg tuiSeriesResultMsg) (tea.Model, tea.Cmd
 	m.resultsViewport.Height = m.getAvailableResultsHeight()
 	m = m.renderSeriesTable()
 	m = m.syncViewportContent()
+	m = m.recordHistory(ModeSeries, msg.duration, len(msg.series))
 	return m, nil
 }
 
 func (m TUIModel) handleLabelsResult(msg tuiLabelsResultMsg) (tea.Model, tea.Cmd) {
+	if m.isStale(ModeLabels, msg.queryID) {
+		return m, nil
+	}
+	m.finishLoading(ModeLabels)
 	m = m.applyResultCommon(ModeLabels, msg.warnings, msg.err, msg.duration)
 	m.labels = msg.labels
 	m.viewingLabelValues = false
@@ -164,10 +381,15 @@ func (m TUIModel) handleLabelsResult(msg tuiLabelsResultMsg) (tea.Model, tea.Cmd
 	m.resultsViewport.Height = m.getAvailableResultsHeight()
 	m = m.renderLabelsTable()
 	m = m.syncViewportContent()
+	m = m.recordHistory(ModeLabels, msg.duration, len(msg.labels))
 	return m, nil
 }
 
 func (m TUIModel) handleLabelValuesResult(msg tuiLabelValuesResultMsg) (tea.Model, tea.Cmd) {
+	if m.isStale(ModeLabels, msg.queryID) {
+		return m, nil
+	}
+	m.finishLoading(ModeLabels)
 	m.modeWarnings[ModeLabels] = msg.warnings
 	m.labelValues = msg.values
 	m.selectedLabelName = msg.labelName
@@ -186,3 +408,161 @@ func (m TUIModel) handleLabelValuesResult(msg tuiLabelValuesResultMsg) (tea.Mode
 	m = m.syncViewportContent()
 	return m, nil
 }
+
+func (m TUIModel) handleMetadataResult(msg tuiMetadataResultMsg) (tea.Model, tea.Cmd) {
+	if m.isStale(ModeMetadata, msg.queryID) {
+		return m, nil
+	}
+	m.finishLoading(ModeMetadata)
+	m = m.applyResultCommon(ModeMetadata, nil, msg.err, msg.duration)
+	m.metadata = filterMetadata(msg.metadata, m.modeQueries[ModeMetadata])
+
+	if msg.err != nil {
+		m.modeStates[ModeMetadata] = StateError
+		return m, nil
+	}
+
+	m.modeStates[ModeMetadata] = StateResults
+	m.resultsViewport.Height = m.getAvailableResultsHeight()
+	m = m.renderMetadataTable()
+	m = m.syncViewportContent()
+	m = m.recordHistory(ModeMetadata, msg.duration, len(m.metadata))
+	return m, nil
+}
+
+func (m TUIModel) handleTargetsResult(msg tuiTargetsResultMsg) (tea.Model, tea.Cmd) {
+	if m.isStale(ModeTargets, msg.queryID) {
+		return m, nil
+	}
+	m.finishLoading(ModeTargets)
+	m = m.applyResultCommon(ModeTargets, nil, msg.err, msg.duration)
+	m.targets = msg.targets
+
+	if msg.err != nil {
+		m.modeStates[ModeTargets] = StateError
+		return m, nil
+	}
+
+	m.modeStates[ModeTargets] = StateResults
+	m.resultsViewport.Height = m.getAvailableResultsHeight()
+	m = m.refreshTargets()
+	m = m.recordHistory(ModeTargets, msg.duration, len(m.targetRows))
+	return m, nil
+}
+
+func (m TUIModel) handleRulesResult(msg tuiRulesResultMsg) (tea.Model, tea.Cmd) {
+	if m.isStale(ModeRules, msg.queryID) {
+		return m, nil
+	}
+	m.finishLoading(ModeRules)
+	m = m.applyResultCommon(ModeRules, nil, msg.err, msg.duration)
+	m.rules = msg.rules
+
+	if msg.err != nil {
+		m.modeStates[ModeRules] = StateError
+		return m, nil
+	}
+
+	m.modeStates[ModeRules] = StateResults
+	m.resultsViewport.Height = m.getAvailableResultsHeight()
+	m = m.refreshRules()
+	m = m.recordHistory(ModeRules, msg.duration, len(m.ruleRows))
+	return m, nil
+}
+
+func (m TUIModel) handleTSDBResult(msg tuiTSDBResultMsg) (tea.Model, tea.Cmd) {
+	if m.isStale(ModeTSDB, msg.queryID) {
+		return m, nil
+	}
+	m.finishLoading(ModeTSDB)
+	m = m.applyResultCommon(ModeTSDB, nil, msg.err, msg.duration)
+	m.tsdb = msg.tsdb
+
+	if msg.err != nil {
+		m.modeStates[ModeTSDB] = StateError
+		return m, nil
+	}
+
+	m.modeStates[ModeTSDB] = StateResults
+	m.resultsViewport.Height = m.getAvailableResultsHeight()
+	m = m.renderTSDBTable()
+	m = m.syncViewportContent()
+	m = m.recordHistory(ModeTSDB, msg.duration, len(m.tsdbStats()))
+	return m, nil
+}
+
+func (m TUIModel) handleStatusResult(msg tuiStatusResultMsg) (tea.Model, tea.Cmd) {
+	if m.isStale(ModeStatus, msg.queryID) {
+		return m, nil
+	}
+	m.finishLoading(ModeStatus)
+	m = m.applyResultCommon(ModeStatus, msg.warnings, msg.err, msg.duration)
+	m.buildinfo = msg.buildinfo
+	m.runtimeinfo = msg.runtimeinfo
+	m.flags = msg.flags
+	m.serverConfig = msg.config
+
+	if msg.err != nil {
+		m.modeStates[ModeStatus] = StateError
+		return m, nil
+	}
+
+	m.modeStates[ModeStatus] = StateResults
+	m.resultsViewport.Height = m.getAvailableResultsHeight()
+	m = m.syncViewportContent()
+	m.resultsViewport.GotoTop()
+	m = m.recordHistory(ModeStatus, msg.duration, len(m.statusFlags()))
+	return m, nil
+}
+
+func (m TUIModel) handleAlertmanagerResult(msg tuiAlertmanagerResultMsg) (tea.Model, tea.Cmd) {
+	if m.isStale(ModeAlertmanager, msg.queryID) {
+		return m, nil
+	}
+	m.finishLoading(ModeAlertmanager)
+	m = m.applyResultCommon(ModeAlertmanager, nil, msg.err, msg.duration)
+	m.alertGroups = msg.groups
+	m.silences = msg.silences
+
+	if msg.err != nil {
+		m.modeStates[ModeAlertmanager] = StateError
+		return m, nil
+	}
+
+	m.modeStates[ModeAlertmanager] = StateResults
+	m.resultsViewport.Height = m.getAvailableResultsHeight()
+	m = m.refreshAlertmanager()
+	m = m.recordHistory(ModeAlertmanager, msg.duration, len(m.alertRows))
+	return m, nil
+}
+
+// filterMetadata flattens the metadata response into a list sorted by
+// metric name, keeping metrics whose name or help text contains filter
+// (case-insensitive). Only the first entry of each metric is kept.
+func filterMetadata(metadata map[string][]v1.Metadata, filter string) []metricMetadata {
+	filter = strings.ToLower(strings.TrimSpace(filter))
+
+	result := make([]metricMetadata, 0, len(metadata))
+	for name, entries := range metadata {
+		if len(entries) == 0 {
+			continue
+		}
+		entry := entries[0]
+		if filter != "" &&
+			!strings.Contains(strings.ToLower(name), filter) &&
+			!strings.Contains(strings.ToLower(entry.Help), filter) {
+			continue
+		}
+		result = append(result, metricMetadata{
+			name:       name,
+			metricType: entry.Type,
+			unit:       entry.Unit,
+			help:       entry.Help,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].name < result[j].name
+	})
+	return result
+}
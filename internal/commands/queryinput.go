@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// byteOffset returns the byte offset of the rune at index pos of s, as
+// editor cursor positions count runes.
+func byteOffset(s string, pos int) int {
+	for i := range s {
+		if pos == 0 {
+			return i
+		}
+		pos--
+	}
+	return len(s)
+}
+
+// queryParseError returns the first error parsing the PromQL expression being
+// edited in /query or /query_range, nil if it parses.
+func (m TUIModel) queryParseError() *parser.ParseErr {
+	if !m.insertMode || (m.mode != ModeInstant && m.mode != ModeRange) {
+		return nil
+	}
+	query := m.queryInput.Value()
+	if strings.TrimSpace(query) == "" {
+		return nil
+	}
+	_, err := parser.NewParser(parser.Options{}).ParseExpr(query)
+	var errs parser.ParseErrors
+	if errors.As(err, &errs) && len(errs) > 0 {
+		return &errs[0]
+	}
+	return nil
+}
+
+// highlightQuery returns the styles the query input is highlighted with:
+// PromQL colors with the range of parseErr, if any, underlined.
+func highlightQuery(parseErr *parser.ParseErr) func(string) []*lipgloss.Style {
+	return func(value string) []*lipgloss.Style {
+		styles := promqlStyles(value)
+		if parseErr != nil {
+			start, end := int(parseErr.PositionRange.Start), int(parseErr.PositionRange.End)
+			for i := start; i < max(end, start+1) && i < len(styles); i++ {
+				styles[i] = &promqlErrorStyle
+			}
+		}
+		return styles
+	}
+}
+
+// renderParseError renders a caret under the column of parseErr in the
+// query input, followed by the parser's message and, in queries of several
+// lines, the line of the error.
+func (m TUIModel) renderParseError(parseErr *parser.ParseErr) string {
+	value := m.queryInput.Value()
+	errPos := utf8.RuneCountInString(value[:min(int(parseErr.PositionRange.Start), len(value))])
+
+	_, column := m.queryInput.ScreenPosition(errPos)
+	column = max(0, column)
+	if width := m.queryInput.Width; width > 0 {
+		column = min(column, width)
+	}
+	// The input's border, padding and prompt precede the query
+	column += 2 + lipgloss.Width(m.queryInput.Prompt)
+
+	message := parseErr.Err.Error()
+	if m.queryInput.LineCount() > 1 {
+		message = fmt.Sprintf("line %d: %s", strings.Count(value[:min(int(parseErr.PositionRange.Start), len(value))], "\n")+1, message)
+	}
+	line := strings.Repeat(" ", column) + ErrorStyle.Render("^ "+message)
+	return ansi.Truncate(line, m.getTerminalWidth(), "…")
+}
@@ -0,0 +1,208 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akasprzok/peat/internal/prometheus"
+	"github.com/charmbracelet/bubbles/cursor"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+)
+
+func TestQueryParseError(t *testing.T) {
+	m := NewTUIModel(&prometheus.MockClient{}, time.Hour, 15*time.Second, 100, 60*time.Second)
+	m.width = 100
+	m.height = 30
+	m.queryInput.Width = 90
+	m.queryInput.Cursor.SetMode(cursor.CursorStatic)
+
+	height := strings.Count(m.View(), "\n")
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(`sum(rate(up[5m]) by job`)})
+	m = updated.(TUIModel)
+
+	parseErr := m.queryParseError()
+	if parseErr == nil {
+		t.Fatal("no parse error for a malformed query")
+	}
+	lines := strings.Split(ansi.Strip(m.View()), "\n")
+	if got := len(lines) - 1; got != height {
+		t.Errorf("view has %d lines with a parse error, want the %d it has without", got+1, height+1)
+	}
+
+	// The caret is under the error position, below the input box
+	caret := lines[4]
+	if !strings.Contains(caret, "^ "+parseErr.Err.Error()) {
+		t.Fatalf("line under the input = %q, want the parse error", caret)
+	}
+	prompt := ansi.StringWidth(lines[2][:strings.Index(lines[2], "> ")+2])
+	if got, want := strings.Index(caret, "^"), prompt+int(parseErr.PositionRange.Start); got != want {
+		t.Errorf("caret at column %d, want column %d under %q", got, want, lines[2])
+	}
+
+	// Valid queries, and queries that aren't being edited, show no error
+	m.queryInput.SetValue(`sum by (job) (rate(up[5m]))`)
+	if err := m.queryParseError(); err != nil {
+		t.Errorf("parse error for a valid query: %v", err)
+	}
+	m.queryInput.SetValue(`sum(`)
+	m.insertMode = false
+	if err := m.queryParseError(); err != nil {
+		t.Errorf("parse error outside insert mode: %v", err)
+	}
+}
+
+func TestQueryParseErrorResizesResults(t *testing.T) {
+	mockClient := &prometheus.MockClient{
+		QueryFunc: func(_ context.Context, _ string, _ time.Time, _ time.Duration, _ bool) (v1.Warnings, model.Value, *prometheus.QueryStats, error) {
+			return nil, model.Vector{&model.Sample{Metric: model.Metric{"__name__": "up"}, Value: 1}}, nil, nil
+		},
+	}
+	m := NewTUIModel(mockClient, time.Hour, 15*time.Second, 100, 60*time.Second)
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	m = updated.(TUIModel)
+	m.queryInput.Cursor.SetMode(cursor.CursorStatic)
+	for _, msg := range []tea.Msg{runes("up"), tea.KeyMsg{Type: tea.KeyEnter}, runes("/")} {
+		updated, cmd := m.Update(msg)
+		m = updated.(TUIModel)
+		for _, msg := range runCmd(cmd) {
+			if _, ok := msg.(tuiInstantResultMsg); ok {
+				updated, _ = m.Update(msg)
+				m = updated.(TUIModel)
+			}
+		}
+	}
+	height := m.resultsViewport.Height
+
+	// The parse error line is taken from the results, and given back once fixed
+	for _, tt := range []struct {
+		key  tea.KeyMsg
+		want int
+	}{
+		{runes(" +"), height - 1},
+		{tea.KeyMsg{Type: tea.KeyBackspace}, height},
+	} {
+		updated, _ = m.Update(tt.key)
+		m = updated.(TUIModel)
+		if m.resultsViewport.Height != tt.want {
+			t.Errorf("results height = %d after %q, want %d", m.resultsViewport.Height, m.queryInput.Value(), tt.want)
+		}
+		if got := strings.Count(m.resultsViewport.View(), "╰"); got != 1 {
+			t.Errorf("results for %q show %d bottom borders, want the chart's", m.queryInput.Value(), got)
+		}
+	}
+}
+
+func TestMultiLineQueryEditor(t *testing.T) {
+	var executed string
+	mockClient := &prometheus.MockClient{
+		QueryFunc: func(_ context.Context, query string, _ time.Time, _ time.Duration, _ bool) (v1.Warnings, model.Value, *prometheus.QueryStats, error) {
+			executed = query
+			return nil, model.Vector{}, nil, nil
+		},
+	}
+	m := NewTUIModel(mockClient, time.Hour, 15*time.Second, 100, 60*time.Second)
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	m = updated.(TUIModel)
+	m.queryInput.Cursor.SetMode(cursor.CursorStatic)
+	height := strings.Count(m.View(), "\n")
+	resultsHeight := m.getAvailableResultsHeight()
+
+	newline := tea.KeyMsg{Type: tea.KeyEnter, Alt: true}
+	for _, msg := range []tea.Msg{runes("sum("), newline, runes("up"), newline, runes(")")} {
+		updated, _ = m.Update(msg)
+		m = updated.(TUIModel)
+	}
+	if got, want := m.queryInput.Value(), "sum(\n  up\n)"; got != want {
+		t.Fatalf("query = %q, want %q", got, want)
+	}
+	if got := m.getAvailableResultsHeight(); got != resultsHeight-2 {
+		t.Errorf("results height = %d with 3 query lines, want %d", got, resultsHeight-2)
+	}
+	view := ansi.Strip(m.View())
+	if strings.Count(view, "\n") != height || !strings.Contains(view, "\n│     up") {
+		t.Errorf("view of a 3 line query:\n%s", view)
+	}
+
+	// Enter still runs the query
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(TUIModel)
+	for _, msg := range runCmd(cmd) {
+		if _, ok := msg.(tuiInstantResultMsg); ok {
+			updated, _ = m.Update(msg)
+			m = updated.(TUIModel)
+		}
+	}
+	if executed != "sum(\n  up\n)" || m.insertMode {
+		t.Errorf("enter ran %q, want the query of several lines", executed)
+	}
+
+	// Formatted queries keep their lines and the collapsed input shows them on one
+	m.queryInput.SetValue(`sum by (job, instance) (rate(http_requests_total{job="api-server", code=~"5.."}[5m])) / sum by (job, instance) (rate(http_requests_total[5m]))`)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("f")})
+	m = updated.(TUIModel)
+	if m.queryInput.LineCount() < 3 {
+		t.Errorf("formatted query has %d lines, want it split", m.queryInput.LineCount())
+	}
+	if view := ansi.Strip(m.View()); strings.Count(view, "\n") != height || !strings.Contains(view, "(rate(http_requests_total{code=~\"5..\",job=\"api-server\"}[5m])) / sum by") {
+		t.Errorf("view of the collapsed formatted query:\n%s", view)
+	}
+}
+
+func TestQueryEditorFitsTerminal(t *testing.T) {
+	var vector model.Vector
+	for i := range 30 {
+		vector = append(vector, &model.Sample{Metric: model.Metric{"__name__": "up", "i": model.LabelValue(fmt.Sprint(i))}, Value: model.SampleValue(i)})
+	}
+	mockClient := &prometheus.MockClient{
+		QueryFunc: func(_ context.Context, _ string, _ time.Time, _ time.Duration, _ bool) (v1.Warnings, model.Value, *prometheus.QueryStats, error) {
+			return nil, vector, nil, nil
+		},
+	}
+	m := NewTUIModel(mockClient, time.Hour, 15*time.Second, 100, 60*time.Second)
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	m = updated.(TUIModel)
+	m.queryInput.Cursor.SetMode(cursor.CursorStatic)
+
+	newline := tea.KeyMsg{Type: tea.KeyEnter, Alt: true}
+	keys := []tea.Msg{runes("sum(up)"), tea.KeyMsg{Type: tea.KeyEnter}, runes("/")}
+	for range QueryEditorMaxLines {
+		keys = append(keys, newline)
+	}
+	// Growing the editor, leaving insert mode and formatting the query
+	// each change the height of the input
+	keys = append(keys, tea.KeyMsg{Type: tea.KeyEsc}, runes("f"))
+	for _, msg := range keys {
+		updated, cmd := m.Update(msg)
+		m = updated.(TUIModel)
+		for _, msg := range runCmd(cmd) {
+			if _, ok := msg.(tuiInstantResultMsg); ok {
+				updated, _ = m.Update(msg)
+				m = updated.(TUIModel)
+			}
+		}
+
+		if m.currentState() != StateResults {
+			continue
+		}
+		// The chart is taller than the results, which scroll to leave
+		// the status and help bars on the terminal
+		view := ansi.Strip(m.View())
+		if lipgloss.Height(view) > m.height {
+			t.Errorf("view is %d lines high after %v, taller than the %d line terminal:\n%s", lipgloss.Height(view), msg, m.height, view)
+		}
+	}
+	if got := m.queryInput.Value(); got != "sum(up)" {
+		t.Errorf("query = %q, want the formatted query", got)
+	}
+}
+
+func runes(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
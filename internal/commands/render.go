@@ -4,26 +4,95 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/akasprzok/peat/internal/charts"
 	"github.com/charmbracelet/lipgloss"
 	teatable "github.com/evertras/bubble-table/table"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/common/model"
 	"golang.org/x/term"
 )
 
+// sampleTimeFormat is the timestamp layout used for raw samples.
+const sampleTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
 func (m TUIModel) renderInstantChart() TUIModel {
 	width := m.getChartWidth()
-	m.chartContent = charts.Barchart(m.vector, width)
+	switch v := m.instantValue.(type) {
+	case model.Vector:
+		m.chartContent = charts.Barchart(v, width) + renderHistogramSamples(v, width)
+	case *model.Scalar:
+		m.chartContent = renderSingleValue("scalar", charts.BigNumber(v.Value.String(), charts.SeriesStyle(0)), v.Timestamp)
+	case *model.String:
+		m.chartContent = renderSingleValue("string", lipgloss.NewStyle().Bold(true).Foreground(charts.SeriesColor(0)).Render(v.Value), v.Timestamp)
+	case model.Matrix:
+		m.chartContent = renderSampleTables(v)
+	default:
+		m.chartContent = ""
+	}
 	return m
 }
 
+// renderSingleValue renders a scalar or string result with its type and evaluation timestamp.
+func renderSingleValue(kind, value string, ts model.Time) string {
+	captionStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("245")).MarginTop(1)
+	caption := fmt.Sprintf("%s @ %s", kind, ts.Time().Format(sampleTimeFormat))
+	return lipgloss.NewStyle().Padding(1, 2).Render(value + "\n" + captionStyle.Render(caption))
+}
+
+// renderSampleTables renders the raw samples of a range vector as one table per series.
+func renderSampleTables(matrix model.Matrix) string {
+	var s strings.Builder
+	for i, stream := range matrix {
+		if i > 0 {
+			s.WriteString("\n")
+		}
+		s.WriteString(charts.SeriesStyle(i).Bold(true).Render(stream.Metric.String()))
+		s.WriteString("\n")
+
+		rows := make([]teatable.Row, 0, len(stream.Values)+len(stream.Histograms))
+		for _, sample := range stream.Values {
+			rows = append(rows, teatable.NewRow(teatable.RowData{
+				"timestamp": sample.Timestamp.Time().Format(sampleTimeFormat),
+				"value":     sample.Value.String(),
+			}))
+		}
+		valueWidth := 24
+		for _, sample := range stream.Histograms {
+			value := fmt.Sprintf("histogram count=%s sum=%s", sample.Histogram.Count, sample.Histogram.Sum)
+			valueWidth = max(valueWidth, len(value)+2)
+			rows = append(rows, teatable.NewRow(teatable.RowData{
+				"timestamp": sample.Timestamp.Time().Format(sampleTimeFormat),
+				"value":     value,
+			}))
+		}
+
+		columns := []teatable.Column{
+			teatable.NewColumn("timestamp", "Timestamp", len(sampleTimeFormat)+2),
+			teatable.NewColumn("value", "Value", valueWidth),
+		}
+
+		s.WriteString(teatable.
+			New(columns).
+			WithRows(rows).
+			WithPageSize(max(len(rows), 1)).
+			WithFooterVisibility(false).
+			Focused(false).
+			WithBaseStyle(lipgloss.NewStyle()).
+			View())
+		s.WriteString("\n")
+	}
+	return s.String()
+}
+
 func (m TUIModel) renderRangeChart() TUIModel {
 	width := m.getChartWidth()
 	availHeight := m.getAvailableResultsHeight()
 	legendRows := m.getLegendPageSize()
 	chartHeight := availHeight - legendRows - LegendBorderLines - ChartBorderLines
-	m.chartContent, m.legendEntries = charts.TimeseriesSplitWithSelection(m.matrix, width, chartHeight, m.selectedIndex, m.highlightedIndices)
+	m.chartContent, m.legendEntries = charts.TimeseriesSplitWithSelection(m.matrix, width, chartHeight, m.selectedIndex, m.highlightedIndices, m.rangeChartOptions()...)
 	m = m.createLegendTable()
 	return m
 }
@@ -189,12 +258,405 @@ func (m TUIModel) renderLabelsTable() TUIModel {
 	return m
 }
 
+func (m TUIModel) renderMetadataTable() TUIModel {
+	nameWidth := len("Metric")
+	unitWidth := len("Unit")
+	for _, md := range m.metadata {
+		nameWidth = max(nameWidth, len(md.name))
+		unitWidth = max(unitWidth, len(md.unit))
+	}
+	nameWidth = min(nameWidth, 50)
+	unitWidth = min(unitWidth, 12)
+	typeWidth := len("gaugehistogram")
+
+	// Give the help text whatever width is left, but keep it readable
+	helpWidth := m.getTerminalWidth() - nameWidth - typeWidth - unitWidth - 16
+	if helpWidth < 20 {
+		helpWidth = 20
+	}
+
+	columns := []teatable.Column{
+		teatable.NewColumn("metric", "Metric", nameWidth),
+		teatable.NewColumn("type", "Type", typeWidth),
+		teatable.NewColumn("unit", "Unit", unitWidth),
+		teatable.NewColumn("help", "Help", helpWidth),
+	}
+
+	rows := make([]teatable.Row, 0, len(m.metadata))
+	for _, md := range m.metadata {
+		rows = append(rows, teatable.NewRow(teatable.RowData{
+			"metric": md.name,
+			"type":   string(md.metricType),
+			"unit":   md.unit,
+			"help":   md.help,
+		}))
+	}
+
+	tablePageSize := m.getAvailableResultsHeight() - ChartBorderLines
+	if tablePageSize < 3 {
+		tablePageSize = 3
+	}
+
+	m.metadataTable = teatable.
+		New(columns).
+		WithRows(rows).
+		WithPageSize(tablePageSize).
+		Focused(m.legendFocused).
+		WithBaseStyle(lipgloss.NewStyle())
+
+	return m
+}
+
+func (m TUIModel) renderTargetsTable() TUIModel {
+	// Count healthy targets per job for the group headers
+	jobUp := make(map[string]int)
+	jobTotal := make(map[string]int)
+	if m.targetsGrouped {
+		for _, t := range m.targetRows {
+			jobTotal[t.job]++
+			if t.health == string(v1.HealthGood) {
+				jobUp[t.job]++
+			}
+		}
+	}
+
+	jobs := make([]string, len(m.targetRows))
+	jobWidth := len("Job")
+	instanceWidth := len("Instance")
+	for i, t := range m.targetRows {
+		jobs[i] = t.job
+		if m.targetsGrouped {
+			jobs[i] = ""
+			if i == 0 || m.targetRows[i-1].job != t.job {
+				jobs[i] = fmt.Sprintf("%s (%d/%d up)", t.job, jobUp[t.job], jobTotal[t.job])
+			}
+		}
+		jobWidth = max(jobWidth, len(jobs[i]))
+		instanceWidth = max(instanceWidth, len(t.instance))
+	}
+	jobWidth = min(jobWidth, 40)
+	instanceWidth = min(instanceWidth, 40)
+
+	const healthWidth, scrapeWidth, durationWidth = 8, 12, 10
+	errorWidth := m.getTerminalWidth() - jobWidth - instanceWidth - healthWidth - scrapeWidth - durationWidth - 20
+	if errorWidth < 20 {
+		errorWidth = 20
+	}
+
+	columns := []teatable.Column{
+		teatable.NewColumn("job", "Job", jobWidth),
+		teatable.NewColumn("instance", "Instance", instanceWidth),
+		teatable.NewColumn("health", "Health", healthWidth),
+		teatable.NewColumn("scrape", "Last Scrape", scrapeWidth),
+		teatable.NewColumn("duration", "Duration", durationWidth),
+		teatable.NewColumn("error", "Error", errorWidth),
+	}
+
+	healthStyles := map[string]lipgloss.Style{
+		string(v1.HealthGood):    SuccessStyle,
+		string(v1.HealthBad):     ErrorStyle,
+		string(v1.HealthUnknown): WarningStyle,
+		healthDropped:            lipgloss.NewStyle().Foreground(lipgloss.Color("245")),
+	}
+
+	now := time.Now()
+	rows := make([]teatable.Row, 0, len(m.targetRows))
+	for i, t := range m.targetRows {
+		lastScrape, duration := "", ""
+		if !t.lastScrape.IsZero() {
+			lastScrape = formatDuration(now.Sub(t.lastScrape).Truncate(time.Second)) + " ago"
+			duration = formatDuration(t.scrapeDuration)
+		}
+		rows = append(rows, teatable.NewRow(teatable.RowData{
+			"job":      jobs[i],
+			"instance": t.instance,
+			"health":   teatable.NewStyledCell(t.health, healthStyles[t.health]),
+			"scrape":   lastScrape,
+			"duration": duration,
+			"error":    t.lastError,
+		}))
+	}
+
+	tablePageSize := m.getAvailableResultsHeight() - ChartBorderLines
+	if tablePageSize < 3 {
+		tablePageSize = 3
+	}
+
+	m.targetsTable = teatable.
+		New(columns).
+		WithRows(rows).
+		WithPageSize(tablePageSize).
+		Focused(m.legendFocused).
+		WithBaseStyle(lipgloss.NewStyle()).
+		WithHighlightedRow(min(m.targetsTable.GetHighlightedRowIndex(), max(len(rows)-1, 0)))
+
+	return m
+}
+
+func (m TUIModel) renderRulesTable() TUIModel {
+	names := make([]string, len(m.ruleRows))
+	nameWidth := len("Name")
+	for i, r := range m.ruleRows {
+		switch r.kind {
+		case ruleRowGroup:
+			names[i] = r.name
+		case ruleRowAlert:
+			names[i] = "    " + r.name
+		default:
+			names[i] = "  " + r.name
+		}
+		nameWidth = max(nameWidth, len(names[i]))
+	}
+	nameWidth = min(nameWidth, 50)
+
+	const kindWidth, stateWidth, lastEvalWidth, evalTimeWidth = 8, 9, 10, 10
+	errorWidth := m.getTerminalWidth() - nameWidth - kindWidth - stateWidth - lastEvalWidth - evalTimeWidth - 20
+	if errorWidth < 20 {
+		errorWidth = 20
+	}
+
+	columns := []teatable.Column{
+		teatable.NewColumn("name", "Name", nameWidth),
+		teatable.NewColumn("kind", "Type", kindWidth),
+		teatable.NewColumn("state", "State", stateWidth),
+		teatable.NewColumn("last_eval", "Last Eval", lastEvalWidth),
+		teatable.NewColumn("eval_time", "Eval Time", evalTimeWidth),
+		teatable.NewColumn("error", "Error", errorWidth),
+	}
+
+	now := time.Now()
+	rows := make([]teatable.Row, 0, len(m.ruleRows))
+	for i, r := range m.ruleRows {
+		lastEval, evalTime := "", ""
+		if !r.lastEvaluation.IsZero() {
+			lastEval = formatDuration(now.Sub(r.lastEvaluation).Truncate(time.Second)) + " ago"
+			evalTime = formatDuration(r.evaluationTime)
+		}
+		name := teatable.NewStyledCell(names[i], lipgloss.NewStyle())
+		if r.kind == ruleRowGroup {
+			name = teatable.NewStyledCell(names[i], lipgloss.NewStyle().Bold(true))
+		}
+		rows = append(rows, teatable.NewRow(teatable.RowData{
+			"name":      name,
+			"kind":      r.kind.String(),
+			"state":     teatable.NewStyledCell(r.state, ruleStateStyle(r.state)),
+			"last_eval": lastEval,
+			"eval_time": evalTime,
+			"error":     r.lastError,
+		}))
+	}
+
+	// Leave room for the details of the selected row below the table
+	tablePageSize := m.getAvailableResultsHeight() - ChartBorderLines - RuleDetailsLines
+	if tablePageSize < 3 {
+		tablePageSize = 3
+	}
+
+	m.rulesTable = teatable.
+		New(columns).
+		WithRows(rows).
+		WithPageSize(tablePageSize).
+		Focused(m.legendFocused).
+		WithBaseStyle(lipgloss.NewStyle()).
+		WithHighlightedRow(min(m.rulesTable.GetHighlightedRowIndex(), max(len(rows)-1, 0)))
+
+	return m
+}
+
+func (m TUIModel) renderTSDBTable() TUIModel {
+	stats := m.tsdbStats()
+	nameHeader, valueHeader := m.tsdbSection.headers()
+
+	nameWidth := len(nameHeader)
+	for _, stat := range stats {
+		nameWidth = max(nameWidth, len(stat.Name))
+	}
+	nameWidth = min(nameWidth, 60)
+
+	// Series counts are also shown as a share of all head series
+	showShare := m.tsdbSection == tsdbSeriesByMetric || m.tsdbSection == tsdbSeriesByLabelPair
+	columns := []teatable.Column{
+		teatable.NewColumn("name", nameHeader, nameWidth),
+		teatable.NewColumn("value", valueHeader, 12),
+	}
+	if showShare {
+		columns = append(columns, teatable.NewColumn("share", "Share", 8))
+	}
+
+	rows := make([]teatable.Row, 0, len(stats))
+	for _, stat := range stats {
+		value := fmt.Sprintf("%d", stat.Value)
+		if m.tsdbSection == tsdbMemoryByLabel {
+			value = formatBytes(stat.Value)
+		}
+		data := teatable.RowData{
+			"name":  stat.Name,
+			"value": value,
+		}
+		if showShare && m.tsdb.HeadStats.NumSeries > 0 {
+			data["share"] = fmt.Sprintf("%.1f%%", 100*float64(stat.Value)/float64(m.tsdb.HeadStats.NumSeries))
+		}
+		rows = append(rows, teatable.NewRow(data))
+	}
+
+	// Leave room for the head stats and section tabs above the table
+	tablePageSize := m.getAvailableResultsHeight() - ChartBorderLines - 2
+	if tablePageSize < 3 {
+		tablePageSize = 3
+	}
+
+	m.tsdbTable = teatable.
+		New(columns).
+		WithRows(rows).
+		WithPageSize(tablePageSize).
+		Focused(m.legendFocused).
+		WithBaseStyle(lipgloss.NewStyle()).
+		WithHighlightedRow(min(m.tsdbTable.GetHighlightedRowIndex(), max(len(rows)-1, 0)))
+
+	return m
+}
+
+func (m TUIModel) renderAlertsTable() TUIModel {
+	names := make([]string, len(m.alertRows))
+	nameWidth := len("Alert")
+	for i, r := range m.alertRows {
+		names[i] = r.name
+		if !r.group {
+			names[i] = "  " + r.name
+		}
+		nameWidth = max(nameWidth, len(names[i]))
+	}
+	nameWidth = min(nameWidth, 50)
+
+	const stateWidth, startedWidth, silencedWidth = 24, 10, 10
+	labelsWidth := m.getTerminalWidth() - nameWidth - stateWidth - startedWidth - silencedWidth - 18
+	if labelsWidth < 20 {
+		labelsWidth = 20
+	}
+
+	columns := []teatable.Column{
+		teatable.NewColumn("name", "Alert", nameWidth),
+		teatable.NewColumn("state", "State", stateWidth),
+		teatable.NewColumn("started", "Started", startedWidth),
+		teatable.NewColumn("silenced", "Silenced By", silencedWidth),
+		teatable.NewColumn("labels", "Labels", labelsWidth),
+	}
+
+	stateStyles := map[string]lipgloss.Style{
+		"active":      ErrorStyle,
+		"suppressed":  lipgloss.NewStyle().Foreground(lipgloss.Color("245")),
+		"unprocessed": WarningStyle,
+	}
+
+	now := time.Now()
+	rows := make([]teatable.Row, 0, len(m.alertRows))
+	for i, r := range m.alertRows {
+		if r.group {
+			rows = append(rows, teatable.NewRow(teatable.RowData{
+				"name":  teatable.NewStyledCell(names[i], lipgloss.NewStyle().Bold(true)),
+				"state": r.state,
+			}))
+			continue
+		}
+
+		// The alertname is shown in its own column
+		others := make(map[string]string, len(r.labels))
+		for name, value := range r.labels {
+			if name != "alertname" {
+				others[name] = value
+			}
+		}
+		silencedBy := make([]string, len(r.silencedBy))
+		for j, id := range r.silencedBy {
+			silencedBy[j] = shortSilenceID(id)
+		}
+		rows = append(rows, teatable.NewRow(teatable.RowData{
+			"name":     names[i],
+			"state":    teatable.NewStyledCell(r.state, stateStyles[r.state]),
+			"started":  formatRelative(r.startsAt, now),
+			"silenced": strings.Join(silencedBy, ","),
+			"labels":   formatLabels(others),
+		}))
+	}
+
+	tablePageSize := m.getAvailableResultsHeight() - ChartBorderLines - 2
+	if tablePageSize < 3 {
+		tablePageSize = 3
+	}
+
+	m.alertmanagerTable = teatable.
+		New(columns).
+		WithRows(rows).
+		WithPageSize(tablePageSize).
+		Focused(m.legendFocused).
+		WithBaseStyle(lipgloss.NewStyle()).
+		WithHighlightedRow(min(m.alertmanagerTable.GetHighlightedRowIndex(), max(len(rows)-1, 0)))
+
+	return m
+}
+
+func (m TUIModel) renderSilencesTable() TUIModel {
+	const idWidth, stateWidth, endsWidth, authorWidth = 10, 8, 12, 16
+	matchersWidth := len("Matchers")
+	for _, silence := range m.silenceRows {
+		matchersWidth = max(matchersWidth, len(formatMatchers(silence.Matchers)))
+	}
+	matchersWidth = min(matchersWidth, 60)
+	commentWidth := m.getTerminalWidth() - idWidth - stateWidth - matchersWidth - endsWidth - authorWidth - 20
+	if commentWidth < 20 {
+		commentWidth = 20
+	}
+
+	columns := []teatable.Column{
+		teatable.NewColumn("id", "ID", idWidth),
+		teatable.NewColumn("state", "State", stateWidth),
+		teatable.NewColumn("matchers", "Matchers", matchersWidth),
+		teatable.NewColumn("ends", "Ends", endsWidth),
+		teatable.NewColumn("author", "Created By", authorWidth),
+		teatable.NewColumn("comment", "Comment", commentWidth),
+	}
+
+	stateStyles := map[string]lipgloss.Style{
+		silenceActive:  SuccessStyle,
+		silencePending: WarningStyle,
+		silenceExpired: lipgloss.NewStyle().Foreground(lipgloss.Color("245")),
+	}
+
+	now := time.Now()
+	rows := make([]teatable.Row, 0, len(m.silenceRows))
+	for _, silence := range m.silenceRows {
+		rows = append(rows, teatable.NewRow(teatable.RowData{
+			"id":       shortSilenceID(silence.ID),
+			"state":    teatable.NewStyledCell(silence.Status.State, stateStyles[silence.Status.State]),
+			"matchers": formatMatchers(silence.Matchers),
+			"ends":     formatRelative(silence.EndsAt, now),
+			"author":   silence.CreatedBy,
+			"comment":  silence.Comment,
+		}))
+	}
+
+	tablePageSize := m.getAvailableResultsHeight() - ChartBorderLines - 2
+	if tablePageSize < 3 {
+		tablePageSize = 3
+	}
+
+	m.alertmanagerTable = teatable.
+		New(columns).
+		WithRows(rows).
+		WithPageSize(tablePageSize).
+		Focused(m.legendFocused).
+		WithBaseStyle(lipgloss.NewStyle()).
+		WithHighlightedRow(min(m.alertmanagerTable.GetHighlightedRowIndex(), max(len(rows)-1, 0)))
+
+	return m
+}
+
 func (m TUIModel) regenerateRangeChart() TUIModel {
 	width := m.getChartWidth()
 	availHeight := m.getAvailableResultsHeight()
 	legendRows := m.getLegendPageSize()
 	chartHeight := availHeight - legendRows - LegendBorderLines - ChartBorderLines
-	m.chartContent, _ = charts.TimeseriesSplitWithSelection(m.matrix, width, chartHeight, m.selectedIndex, m.highlightedIndices)
+	m.chartContent, _ = charts.TimeseriesSplitWithSelection(m.matrix, width, chartHeight, m.selectedIndex, m.highlightedIndices, m.rangeChartOptions()...)
 	return m
 }
 
@@ -216,10 +678,15 @@ func (m TUIModel) getAvailableResultsHeight() int {
 	if h <= 0 {
 		h = DefaultTerminalHeight
 	}
-	chrome := ChromeHeightExpanded
-	if m.inputCollapsed && !m.insertMode {
+	chrome := ChromeHeightExpanded + m.queryInput.Height() - 1
+	if m.editingEvalTime {
+		chrome = ChromeHeightExpanded
+	} else if m.inputCollapsed && !m.insertMode {
 		chrome = ChromeHeightCollapsed
 	}
+	if m.queryParseError() != nil {
+		chrome++
+	}
 	avail := h - chrome
 	if avail < 1 {
 		avail = 1
@@ -239,6 +706,19 @@ func (m TUIModel) getLegendPageSize() int {
 	return size
 }
 
+// resizeResults fits the results viewport to the height the chrome leaves,
+// re-rendering the results when it changes: the query input grows and
+// shrinks, and its parse error line comes and goes, as the query is edited.
+func (m TUIModel) resizeResults() TUIModel {
+	height := m.getAvailableResultsHeight()
+	if height == m.resultsViewport.Height {
+		return m
+	}
+	m.resultsViewport.Height = height
+	m.currentMode().OnSwitchTo(&m)
+	return m
+}
+
 func (m TUIModel) syncViewportContent() TUIModel {
 	content := m.renderResultsContent()
 	m.resultsViewport.SetContent(content)
@@ -263,6 +743,7 @@ func (m TUIModel) createLegendTable() TUIModel {
 
 	rows := make([]teatable.Row, 0, len(m.legendEntries))
 	longestMetric := 0
+	histograms := false
 
 	for i, entry := range m.legendEntries {
 		if len(entry.Metric) > longestMetric {
@@ -277,9 +758,16 @@ func (m TUIModel) createLegendTable() TUIModel {
 			pin = "*"
 		}
 
+		kind := ""
+		if entry.Histogram {
+			kind = m.currentHistogramValue().Name
+			histograms = true
+		}
+
 		rows = append(rows, teatable.NewRow(teatable.RowData{
 			"color":  colorIndicator,
 			"pin":    pin,
+			"kind":   kind,
 			"metric": entry.Metric,
 		}))
 	}
@@ -287,8 +775,12 @@ func (m TUIModel) createLegendTable() TUIModel {
 	columns := []teatable.Column{
 		teatable.NewColumn("color", "", 3),
 		teatable.NewColumn("pin", "", 3),
-		teatable.NewColumn("metric", "Metric", max(longestMetric, 20)),
 	}
+	if histograms {
+		// Histogram series are plotted as a quantile, count or sum, shown here
+		columns = append(columns, teatable.NewColumn("kind", "Hist", 7))
+	}
+	columns = append(columns, teatable.NewColumn("metric", "Metric", max(longestMetric, 20)))
 
 	m.legendTable = teatable.
 		New(columns).
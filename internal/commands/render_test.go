@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akasprzok/peat/internal/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+func TestRenderInstantChart(t *testing.T) {
+	ts := model.TimeFromUnix(1700000000)
+
+	tests := []struct {
+		name  string
+		value model.Value
+		want  []string
+	}{
+		{
+			name:  "vector",
+			value: model.Vector{&model.Sample{Metric: model.Metric{"job": "node"}, Value: 3, Timestamp: ts}},
+			want:  []string{`{job="node"}`},
+		},
+		{
+			name:  "scalar",
+			value: &model.Scalar{Value: 42, Timestamp: ts},
+			want:  []string{"scalar @ "},
+		},
+		{
+			name:  "string",
+			value: &model.String{Value: "hello", Timestamp: ts},
+			want:  []string{"hello", "string @ "},
+		},
+		{
+			name: "matrix",
+			value: model.Matrix{
+				&model.SampleStream{
+					Metric: model.Metric{"__name__": "up", "job": "node"},
+					Values: []model.SamplePair{{Timestamp: ts, Value: 1}, {Timestamp: ts.Add(15 * time.Second), Value: 0}},
+				},
+			},
+			want: []string{`up{job="node"}`, "Timestamp", ts.Time().Format(sampleTimeFormat), ts.Add(15 * time.Second).Time().Format(sampleTimeFormat)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewTUIModel(&prometheus.MockClient{}, time.Hour, 15*time.Second, 100, 60*time.Second)
+			m.width = 120
+			m.instantValue = tt.value
+			m = m.renderInstantChart()
+
+			if m.chartContent == "" {
+				t.Fatal("chartContent is empty")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(m.chartContent, want) {
+					t.Errorf("chartContent does not contain %q", want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package commands
+
+import "strings"
+
+// splitSelectors splits the /series and /labels input into individual
+// series selectors, one per line or separated by ';'. Separators inside
+// quoted label values are kept. Empty selectors are dropped.
+func splitSelectors(input string) []string {
+	var (
+		selectors []string
+		current   strings.Builder
+		quote     rune
+		escaped   bool
+	)
+
+	flush := func() {
+		if s := strings.TrimSpace(current.String()); s != "" {
+			selectors = append(selectors, s)
+		}
+		current.Reset()
+	}
+
+	for _, r := range input {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0:
+			if r == '\\' && quote != '`' {
+				escaped = true
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'' || r == '`':
+			quote = r
+		case r == ';' || r == '\n':
+			flush()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	flush()
+
+	return selectors
+}
@@ -0,0 +1,33 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitSelectors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"empty", "", nil},
+		{"whitespace only", "  ;\n ", nil},
+		{"single", `up{job="node"}`, []string{`up{job="node"}`}},
+		{"semicolons", `up; process_start_time_seconds{job="node"} ;`, []string{"up", `process_start_time_seconds{job="node"}`}},
+		{"newlines", "up\n\nnode_load1\r\n", []string{"up", "node_load1"}},
+		{"separator in double quotes", `{path=~"/a;b"}; up`, []string{`{path=~"/a;b"}`, "up"}},
+		{"separator in single quotes", `{path='a;b'}`, []string{`{path='a;b'}`}},
+		{"escaped quote", `{path="a\";b"};up`, []string{`{path="a\";b"}`, "up"}},
+		{"backticks", "{path=`a\\`;b", []string{"{path=`a\\`", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitSelectors(tt.input)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitSelectors(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
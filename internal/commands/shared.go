@@ -10,6 +10,7 @@ var (
 	SpinnerStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
 	ErrorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Bold(true)
 	WarningStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	SuccessStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
 )
 
 // NewLoadingSpinner creates a spinner with consistent styling for loading states.
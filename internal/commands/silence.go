@@ -0,0 +1,314 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/akasprzok/peat/internal/prometheus"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// defaultSilenceDuration is the prefilled duration of new silences.
+const defaultSilenceDuration = "2h"
+
+// Fields of the silence form
+const (
+	silenceFieldMatchers = iota
+	silenceFieldDuration
+	silenceFieldComment
+	silenceFieldAuthor
+
+	silenceFieldCount
+)
+
+var silenceFieldLabels = [silenceFieldCount]string{"Matchers", "Duration", "Comment", "Author"}
+
+// silenceForm holds the inputs of a silence being created.
+type silenceForm struct {
+	inputs [silenceFieldCount]textinput.Model
+	focus  int
+	err    error
+}
+
+// newSilenceForm returns a form prefilled with matchers, the default
+// duration and the current user as author.
+func newSilenceForm(matchers string) silenceForm {
+	var form silenceForm
+	for i := range form.inputs {
+		input := textinput.New()
+		input.Width = 60
+		input.Prompt = ""
+		form.inputs[i] = input
+	}
+	form.inputs[silenceFieldMatchers].SetValue(matchers)
+	form.inputs[silenceFieldDuration].SetValue(defaultSilenceDuration)
+	form.inputs[silenceFieldComment].Placeholder = "Why is this silenced?"
+	form.inputs[silenceFieldAuthor].SetValue(os.Getenv("USER"))
+	return form
+}
+
+// silence validates the form and returns the silence it describes, starting at now.
+func (f silenceForm) silence(now time.Time) (prometheus.Silence, error) {
+	matchers, err := parseSilenceMatchers(f.inputs[silenceFieldMatchers].Value())
+	if err != nil {
+		return prometheus.Silence{}, err
+	}
+	duration, err := model.ParseDuration(strings.TrimSpace(f.inputs[silenceFieldDuration].Value()))
+	if err != nil || duration <= 0 {
+		return prometheus.Silence{}, fmt.Errorf("invalid duration %q", f.inputs[silenceFieldDuration].Value())
+	}
+	comment := strings.TrimSpace(f.inputs[silenceFieldComment].Value())
+	if comment == "" {
+		return prometheus.Silence{}, errors.New("comment is required")
+	}
+	author := strings.TrimSpace(f.inputs[silenceFieldAuthor].Value())
+	if author == "" {
+		return prometheus.Silence{}, errors.New("author is required")
+	}
+	return prometheus.Silence{
+		Matchers:  matchers,
+		StartsAt:  now,
+		EndsAt:    now.Add(time.Duration(duration)),
+		CreatedBy: author,
+		Comment:   comment,
+	}, nil
+}
+
+// parseSilenceMatchers parses a PromQL selector such as
+// {alertname="HighLatency", job=~"api.*"} into silence matchers.
+func parseSilenceMatchers(s string) ([]prometheus.Matcher, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "{") {
+		s = "{" + s + "}"
+	}
+	parsed, err := parser.NewParser(parser.Options{}).ParseMetricSelector(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid matchers: %w", err)
+	}
+	if len(parsed) == 0 {
+		return nil, errors.New("at least one matcher is required")
+	}
+
+	matchers := make([]prometheus.Matcher, len(parsed))
+	for i, matcher := range parsed {
+		matchers[i] = prometheus.Matcher{
+			Name:    matcher.Name,
+			Value:   matcher.Value,
+			IsRegex: matcher.Type == labels.MatchRegexp || matcher.Type == labels.MatchNotRegexp,
+			IsEqual: matcher.Type == labels.MatchEqual || matcher.Type == labels.MatchRegexp,
+		}
+	}
+	return matchers, nil
+}
+
+// seriesSilenceMatchers returns the matchers prefilled when silencing a
+// series: its labels without the metric name, which alerts don't carry.
+func seriesSilenceMatchers(series model.LabelSet) string {
+	ls := make(map[string]string, len(series))
+	for name, value := range series {
+		if name != model.MetricNameLabel {
+			ls[string(name)] = string(value)
+		}
+	}
+	return formatLabels(ls)
+}
+
+// confirmation is a yes/no prompt guarding a write to the Alertmanager.
+type confirmation struct {
+	prompt string
+	action tea.Cmd // Performs the write once confirmed
+}
+
+// openSilenceForm opens the silence form prefilled with matchers.
+func (m *TUIModel) openSilenceForm(matchers string) tea.Cmd {
+	m.silenceForm = newSilenceForm(matchers)
+	m.showSilenceForm = true
+	if m.alertmanager == nil {
+		m.silenceForm.err = m.alertmanagerError()
+	}
+	// Start at the comment, which is the only field without a default
+	m.silenceForm.focus = silenceFieldComment
+	return m.silenceForm.inputs[silenceFieldComment].Focus()
+}
+
+func (m TUIModel) handleSilenceFormKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	form := &m.silenceForm
+	switch msg.String() {
+	case "esc":
+		m.showSilenceForm = false
+		return m, nil
+	case "tab", "down", "shift+tab", "up":
+		form.inputs[form.focus].Blur()
+		if key := msg.String(); key == "tab" || key == "down" {
+			form.focus = (form.focus + 1) % silenceFieldCount
+		} else {
+			form.focus = (form.focus + silenceFieldCount - 1) % silenceFieldCount
+		}
+		return m, form.inputs[form.focus].Focus()
+	case "enter":
+		if m.alertmanager == nil {
+			form.err = m.alertmanagerError()
+			return m, nil
+		}
+		silence, err := form.silence(time.Now())
+		form.err = err
+		if err != nil {
+			return m, nil
+		}
+		m.confirm = &confirmation{
+			prompt: fmt.Sprintf("Create silence %s for %s?",
+				formatMatchers(silence.Matchers), strings.TrimSpace(form.inputs[silenceFieldDuration].Value())),
+			action: m.createSilence(silence),
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	form.inputs[form.focus], cmd = form.inputs[form.focus].Update(msg)
+	return m, cmd
+}
+
+// confirmExpireSilence asks for confirmation before expiring silence.
+func (m *TUIModel) confirmExpireSilence(silence prometheus.Silence) {
+	if silence.Status.State == silenceExpired || m.alertmanager == nil {
+		return
+	}
+	m.confirm = &confirmation{
+		prompt: fmt.Sprintf("Expire silence %s %s?", shortSilenceID(silence.ID), formatMatchers(silence.Matchers)),
+		action: m.expireSilence(silence.ID),
+	}
+}
+
+func (m TUIModel) handleConfirmKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		action := m.confirm.action
+		m.confirm = nil
+		return m, action
+	case "n", "N", "esc", "q":
+		m.confirm = nil
+	}
+	return m, nil
+}
+
+// tuiSilenceWriteMsg carries the result of creating or expiring a silence.
+type tuiSilenceWriteMsg struct {
+	created string // ID of the created silence
+	expired string // ID of the expired silence
+	err     error
+}
+
+func (m TUIModel) createSilence(silence prometheus.Silence) tea.Cmd {
+	client, timeout := m.alertmanager, m.timeout
+	return func() tea.Msg {
+		id, err := client.CreateSilence(context.Background(), silence, timeout)
+		return tuiSilenceWriteMsg{created: id, err: err}
+	}
+}
+
+func (m TUIModel) expireSilence(id string) tea.Cmd {
+	client, timeout := m.alertmanager, m.timeout
+	return func() tea.Msg {
+		err := client.ExpireSilence(context.Background(), id, timeout)
+		return tuiSilenceWriteMsg{expired: id, err: err}
+	}
+}
+
+// handleSilenceWrite reports the result of a silence write. A failed
+// creation keeps the form open so it can be corrected. After a successful
+// write, the silences are shown and refreshed.
+func (m TUIModel) handleSilenceWrite(msg tuiSilenceWriteMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil && m.showSilenceForm {
+		m.silenceForm.err = msg.err
+		return m, nil
+	}
+
+	m.silenceErr = msg.err
+	m.silenceNotice = ""
+	switch {
+	case msg.err != nil:
+	case msg.created != "":
+		m.silenceNotice = "Created silence " + msg.created
+	default:
+		m.silenceNotice = "Expired silence " + msg.expired
+	}
+	m.showSilenceForm = false
+	m.alertmanagerView = alertmanagerSilences
+
+	model, _ := m.switchToMode(ModeAlertmanager)
+	return model.(TUIModel).executeQuery()
+}
+
+// shortSilenceID returns the first block of a silence UUID.
+func shortSilenceID(id string) string {
+	if i := strings.IndexByte(id, '-'); i > 0 {
+		return id[:i]
+	}
+	return id
+}
+
+func (m TUIModel) renderSilenceForm() string {
+	accentColor := lipgloss.Color("205")
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(accentColor).
+		MarginBottom(1)
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("245")).Width(10)
+	focusedLabelStyle := labelStyle.Foreground(accentColor).Bold(true)
+	descStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+
+	var content strings.Builder
+	content.WriteString(titleStyle.Render("New silence"))
+	content.WriteString("\n")
+
+	for i, input := range m.silenceForm.inputs {
+		style := labelStyle
+		if i == m.silenceForm.focus {
+			style = focusedLabelStyle
+		}
+		content.WriteString(style.Render(silenceFieldLabels[i]) + " " + input.View() + "\n")
+	}
+
+	if m.silenceForm.err != nil {
+		content.WriteString("\n")
+		content.WriteString(ErrorStyle.Render("Error: ") + m.silenceForm.err.Error())
+		content.WriteString("\n")
+	}
+
+	content.WriteString("\n")
+	if m.confirm != nil {
+		content.WriteString(m.renderConfirmPrompt())
+	} else {
+		content.WriteString(descStyle.Render("tab: next field | enter: create | esc: cancel"))
+	}
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(accentColor).
+		Padding(1, 2)
+
+	return boxStyle.Render(content.String())
+}
+
+func (m TUIModel) renderConfirmPrompt() string {
+	return WarningStyle.Render(m.confirm.prompt) + " " +
+		lipgloss.NewStyle().Foreground(lipgloss.Color("245")).Render("[y/N]")
+}
+
+func (m TUIModel) renderConfirmDialog() string {
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("205")).
+		Padding(1, 2)
+	return boxStyle.Render(m.renderConfirmPrompt())
+}
@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// formatCount formats a count with a decimal unit suffix, e.g. 12.3k.
+func formatCount(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprint(n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit && exp < 4; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%c", float64(n)/float64(div), "kMGTP"[exp])
+}
+
+// renderStatsStatusBar summarizes the current mode's query stats for the
+// results status bar, or returns "" when stats aren't shown or the server
+// didn't report any.
+func (m TUIModel) renderStatsStatusBar() string {
+	stats := m.modeStats[m.mode]
+	if !m.showStats || stats == nil {
+		return ""
+	}
+	return fmt.Sprintf(" | Samples: %s (peak %s) | Queue: %s | Eval: %s",
+		formatCount(stats.Samples.TotalQueryableSamples),
+		formatCount(stats.Samples.PeakSamples),
+		formatDuration(stats.Timings.ExecQueueTime.Duration()),
+		formatDuration(stats.Timings.EvalTotalTime.Duration()))
+}
+
+// showQueryStats shows query stats in the status bar, and the stats overlay
+// once they are shown. Collecting stats costs the server extra work, so they
+// are only requested while shown and showing them re-runs the query.
+func (m TUIModel) showQueryStats() (tea.Model, tea.Cmd) {
+	if m.mode != ModeInstant && m.mode != ModeRange {
+		return m, nil
+	}
+	if m.showStats {
+		m.showStatsOverlay = m.modeStats[m.mode] != nil
+		return m, nil
+	}
+	m.showStats = true
+	if m.currentState() == StateResults && m.modeStats[m.mode] == nil {
+		return m.executeQuery()
+	}
+	return m, nil
+}
+
+func (m TUIModel) renderStatsOverlay() string {
+	accentColor := lipgloss.Color("205")
+	stats := m.modeStats[m.mode]
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(accentColor)
+	descStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("245"))
+
+	var content strings.Builder
+	content.WriteString(titleStyle.Render("Query stats"))
+	content.WriteString("\n")
+
+	samples := [][2]string{
+		{"Total queryable", fmt.Sprintf("%d", stats.Samples.TotalQueryableSamples)},
+		{"Peak in memory", fmt.Sprintf("%d", stats.Samples.PeakSamples)},
+	}
+	if steps := stats.Samples.TotalQueryableSamplesPerStep; len(steps) > 1 {
+		var peak int64
+		for _, step := range steps {
+			peak = max(peak, step.Samples)
+		}
+		samples = append(samples, [2]string{"Steps", fmt.Sprintf("%d (max %s per step)", len(steps), formatCount(peak))})
+	}
+	writeStatusSection(&content, "Samples", samples)
+
+	timings := stats.Timings
+	writeStatusSection(&content, "Server timings", [][2]string{
+		{"Queue", formatDuration(timings.ExecQueueTime.Duration())},
+		{"Preparation", formatDuration(timings.QueryPreparationTime.Duration())},
+		{"Inner eval", formatDuration(timings.InnerEvalTime.Duration())},
+		{"Result sort", formatDuration(timings.ResultSortTime.Duration())},
+		{"Eval total", formatDuration(timings.EvalTotalTime.Duration())},
+		{"Exec total", formatDuration(timings.ExecTotalTime.Duration())},
+	})
+	writeStatusSection(&content, "Client", [][2]string{
+		{"Latency", formatDuration(m.currentDuration())},
+	})
+
+	content.WriteString("\n")
+	content.WriteString(descStyle.Render("Press s to hide stats, any other key to close"))
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(accentColor).
+		Padding(1, 2)
+
+	return boxStyle.Render(content.String())
+}
@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akasprzok/peat/internal/prometheus"
+	tea "github.com/charmbracelet/bubbletea"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+)
+
+func TestFormatCount(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{1000, "1.0k"},
+		{12345, "12.3k"},
+		{4_500_000, "4.5M"},
+		{2_000_000_000, "2.0G"},
+	}
+
+	for _, tt := range tests {
+		if got := formatCount(tt.n); got != tt.want {
+			t.Errorf("formatCount(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestQueryStats(t *testing.T) {
+	var stats *prometheus.QueryStats
+	var requested bool
+	mockClient := &prometheus.MockClient{
+		QueryFunc: func(_ context.Context, _ string, _ time.Time, _ time.Duration, withStats bool) (v1.Warnings, model.Value, *prometheus.QueryStats, error) {
+			requested = withStats
+			if !withStats {
+				return nil, model.Vector{}, nil, nil
+			}
+			return nil, model.Vector{}, stats, nil
+		},
+	}
+	m := NewTUIModel(mockClient, time.Hour, 15*time.Second, 100, 60*time.Second)
+	m.queryInput.SetValue("up")
+	m.insertMode = false
+
+	run := func(m TUIModel) TUIModel {
+		ctx := m.startLoading(ModeInstant)
+		updated, _ := m.handleInstantResult(m.executeInstantQuery(ctx)().(tuiInstantResultMsg))
+		return updated.(TUIModel)
+	}
+	press := func(m TUIModel, key string) TUIModel {
+		updated, _ := m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		return updated.(TUIModel)
+	}
+
+	t.Run("not reported", func(t *testing.T) {
+		m := m
+		m.showStats = true
+		m = run(m)
+		if status := m.renderResultsStatusBar(); strings.Contains(status, "Queue") {
+			t.Errorf("status bar = %q, want no stats", status)
+		}
+		if m = press(m, "s"); m.showStatsOverlay {
+			t.Error("stats overlay opened without stats")
+		}
+	})
+
+	t.Run("reported", func(t *testing.T) {
+		stats = &prometheus.QueryStats{
+			Timings: prometheus.QueryTimings{ExecQueueTime: 0.002, EvalTotalTime: 0.25, InnerEvalTime: 0.2},
+			Samples: prometheus.QuerySamples{TotalQueryableSamples: 123456, PeakSamples: 789},
+		}
+		if m = run(m); requested {
+			t.Error("stats requested before they are shown")
+		}
+
+		// Showing stats re-runs the query to collect them
+		if m = press(m, "s"); !m.showStats || m.currentState() != StateLoading {
+			t.Fatalf("showing stats didn't re-run the query, state %v", m.currentState())
+		}
+		if m = run(m); !requested {
+			t.Error("stats not requested while shown")
+		}
+		status := m.renderResultsStatusBar()
+		for _, want := range []string{"Samples: 123.5k (peak 789)", "Queue: 2ms", "Eval: 250ms"} {
+			if !strings.Contains(status, want) {
+				t.Errorf("status bar = %q, want %q", status, want)
+			}
+		}
+
+		m = press(m, "s")
+		if !m.showStatsOverlay {
+			t.Fatal("stats overlay not shown")
+		}
+		overlay := m.View()
+		for _, want := range []string{"Query stats", "123456", "Inner eval", "200ms"} {
+			if !strings.Contains(overlay, want) {
+				t.Errorf("overlay missing %q", want)
+			}
+		}
+
+		if m = press(m, "j"); m.showStatsOverlay || !m.showStats {
+			t.Error("stats overlay not dismissed")
+		}
+
+		// s in the overlay stops showing and requesting stats
+		m = press(m, "s")
+		if m = press(m, "s"); m.showStatsOverlay || m.showStats {
+			t.Fatal("stats still shown")
+		}
+		if status := m.renderResultsStatusBar(); strings.Contains(status, "Queue") {
+			t.Errorf("status bar = %q, want no stats", status)
+		}
+		if m = run(m); requested {
+			t.Error("stats requested after hiding them")
+		}
+	})
+}
@@ -1,8 +1,10 @@
 package commands
 
 import (
+	"strconv"
 	"time"
 
+	"github.com/akasprzok/peat/internal/prometheus"
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/common/model"
 )
@@ -15,6 +17,15 @@ const (
 	ModeRange
 	ModeSeries
 	ModeLabels
+	ModeMetadata
+	ModeTargets
+	ModeRules
+	ModeTSDB
+	ModeStatus
+	ModeAlertmanager
+
+	// modeCount is the number of query modes. Keep it last.
+	modeCount
 )
 
 func (m QueryMode) String() string {
@@ -27,11 +38,38 @@ func (m QueryMode) String() string {
 		return "/series"
 	case ModeLabels:
 		return "/labels"
+	case ModeMetadata:
+		return "/metadata"
+	case ModeTargets:
+		return "/targets"
+	case ModeRules:
+		return "/rules"
+	case ModeTSDB:
+		return "/tsdb"
+	case ModeStatus:
+		return "/status"
+	case ModeAlertmanager:
+		return "/alertmanager"
 	default:
 		return "Unknown"
 	}
 }
 
+// parseQueryMode returns the mode whose endpoint is s, e.g. /query_range.
+func parseQueryMode(s string) (QueryMode, bool) {
+	for mode := range modeCount {
+		if mode.String() == s {
+			return mode, true
+		}
+	}
+	return 0, false
+}
+
+// Key returns the number key that switches to the mode. The tenth mode is on 0.
+func (m QueryMode) Key() string {
+	return strconv.Itoa((int(m) + 1) % 10)
+}
+
 // TUIState represents the current state of the TUI.
 type TUIState int
 
@@ -53,22 +91,29 @@ const (
 
 // tuiInstantResultMsg carries the result of an instant query.
 type tuiInstantResultMsg struct {
+	queryID  uint64
 	warnings v1.Warnings
-	vector   model.Vector
+	value    model.Value
+	stats    *prometheus.QueryStats
 	err      error
 	duration time.Duration
 }
 
 // tuiRangeResultMsg carries the result of a range query.
 type tuiRangeResultMsg struct {
-	warnings v1.Warnings
-	matrix   model.Matrix
-	err      error
-	duration time.Duration
+	queryID   uint64
+	warnings  v1.Warnings
+	matrix    model.Matrix
+	exemplars []v1.ExemplarQueryResult
+	quantiles map[float64]model.Matrix // histogram_quantile results, by quantile
+	stats     *prometheus.QueryStats
+	err       error
+	duration  time.Duration
 }
 
 // tuiSeriesResultMsg carries the result of a series query.
 type tuiSeriesResultMsg struct {
+	queryID  uint64
 	warnings v1.Warnings
 	series   []model.LabelSet
 	err      error
@@ -77,17 +122,84 @@ type tuiSeriesResultMsg struct {
 
 // tuiLabelsResultMsg carries the result of a labels query.
 type tuiLabelsResultMsg struct {
+	queryID  uint64
 	warnings v1.Warnings
 	labels   []string
 	err      error
 	duration time.Duration
 }
 
+// tuiMetadataResultMsg carries the result of a metadata query.
+type tuiMetadataResultMsg struct {
+	queryID  uint64
+	metadata map[string][]v1.Metadata
+	err      error
+	duration time.Duration
+}
+
+// tuiTargetsResultMsg carries the result of a targets query.
+type tuiTargetsResultMsg struct {
+	queryID  uint64
+	targets  v1.TargetsResult
+	err      error
+	duration time.Duration
+}
+
+// tuiRulesResultMsg carries the result of a rules query.
+type tuiRulesResultMsg struct {
+	queryID  uint64
+	rules    prometheus.RulesResult
+	err      error
+	duration time.Duration
+}
+
+// tuiTSDBResultMsg carries the result of a TSDB status query.
+type tuiTSDBResultMsg struct {
+	queryID  uint64
+	tsdb     v1.TSDBResult
+	err      error
+	duration time.Duration
+}
+
+// tuiStatusResultMsg carries the server's build and runtime information,
+// flags and configuration. Only a build info error fails the query; the
+// other endpoints are not served by every Prometheus-compatible server and
+// their errors are reported as warnings.
+type tuiStatusResultMsg struct {
+	queryID     uint64
+	buildinfo   v1.BuildinfoResult
+	runtimeinfo v1.RuntimeinfoResult
+	flags       v1.FlagsResult
+	config      v1.ConfigResult
+	warnings    v1.Warnings
+	err         error
+	duration    time.Duration
+}
+
+// tuiAlertmanagerResultMsg carries the alert groups and silences of an Alertmanager.
+type tuiAlertmanagerResultMsg struct {
+	queryID  uint64
+	groups   []prometheus.AlertGroup
+	silences []prometheus.Silence
+	err      error
+	duration time.Duration
+}
+
 // tuiLabelValuesResultMsg carries the result of a label values query.
 type tuiLabelValuesResultMsg struct {
+	queryID   uint64
 	labelName string
 	warnings  v1.Warnings
 	values    []string
 	err       error
 	duration  time.Duration
 }
+
+// tuiCompletionMsg carries the label names, or the values of a label, that
+// query suggestions are made from.
+type tuiCompletionMsg struct {
+	datasource int    // Index of the datasource the names were fetched from
+	list       string // Label whose values were fetched, "" for the label names
+	names      []string
+	err        error
+}
@@ -12,6 +12,12 @@ func TestQueryModeString(t *testing.T) {
 		{"ModeRange", ModeRange, "/query_range"},
 		{"ModeSeries", ModeSeries, "/series"},
 		{"ModeLabels", ModeLabels, "/labels"},
+		{"ModeMetadata", ModeMetadata, "/metadata"},
+		{"ModeTargets", ModeTargets, "/targets"},
+		{"ModeRules", ModeRules, "/rules"},
+		{"ModeTSDB", ModeTSDB, "/tsdb"},
+		{"ModeStatus", ModeStatus, "/status"},
+		{"ModeAlertmanager", ModeAlertmanager, "/alertmanager"},
 		{"Unknown mode", QueryMode(99), "Unknown"},
 	}
 
@@ -24,3 +30,14 @@ func TestQueryModeString(t *testing.T) {
 		})
 	}
 }
+
+func TestParseQueryMode(t *testing.T) {
+	for mode := range modeCount {
+		if got, ok := parseQueryMode(mode.String()); !ok || got != mode {
+			t.Errorf("parseQueryMode(%q) = %v, %v, want %v", mode.String(), got, ok, mode)
+		}
+	}
+	if _, ok := parseQueryMode("/unknown"); ok {
+		t.Error("parseQueryMode(/unknown) ok = true, want false")
+	}
+}
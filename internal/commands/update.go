@@ -12,6 +12,8 @@ func (m TUIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 		m.queryInput.Width = msg.Width - 10
+		// Leave most of the screen to the results
+		m.queryInput.MaxHeight = max(1, min(QueryEditorMaxLines, msg.Height/4))
 		m.resultsViewport.Width = msg.Width
 		m.resultsViewport.Height = m.getAvailableResultsHeight()
 		if m.currentState() == StateResults {
@@ -29,7 +31,9 @@ func (m TUIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
-		return m.handleKeyMsg(msg)
+		updated, cmd := m.handleKeyMsg(msg)
+		// Keys edit, format and recall the query, resizing its input
+		return updated.(TUIModel).resizeResults(), cmd
 
 	case tuiInstantResultMsg:
 		return m.handleInstantResult(msg)
@@ -46,6 +50,30 @@ func (m TUIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tuiLabelValuesResultMsg:
 		return m.handleLabelValuesResult(msg)
 
+	case tuiMetadataResultMsg:
+		return m.handleMetadataResult(msg)
+
+	case tuiTargetsResultMsg:
+		return m.handleTargetsResult(msg)
+
+	case tuiRulesResultMsg:
+		return m.handleRulesResult(msg)
+
+	case tuiTSDBResultMsg:
+		return m.handleTSDBResult(msg)
+
+	case tuiStatusResultMsg:
+		return m.handleStatusResult(msg)
+
+	case tuiAlertmanagerResultMsg:
+		return m.handleAlertmanagerResult(msg)
+
+	case tuiSilenceWriteMsg:
+		return m.handleSilenceWrite(msg)
+
+	case tuiCompletionMsg:
+		return m.handleCompletionResult(msg)
+
 	case spinner.TickMsg:
 		if m.currentState() == StateLoading {
 			var cmd tea.Cmd
@@ -80,10 +108,54 @@ func (m TUIModel) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	// Dismiss the stats overlay on any key, like the shortcuts overlay; s also
+	// stops showing stats
+	if m.showStatsOverlay {
+		if msg.String() == "q" {
+			return m, tea.Quit
+		}
+		if msg.String() == "s" {
+			m.showStats = false
+		}
+		m.showStatsOverlay = false
+		return m, nil
+	}
+
+	if m.showDatasourcePicker {
+		return m.handleDatasourcePickerKey(msg)
+	}
+
+	if m.showHistorySearch {
+		return m.handleHistorySearchKey(msg)
+	}
+
+	if m.showLibrary {
+		return m.handleLibraryKey(msg)
+	}
+
+	if m.showSaveForm {
+		return m.handleSaveFormKey(msg)
+	}
+
+	if m.confirm != nil {
+		return m.handleConfirmKey(msg)
+	}
+
+	if m.showSilenceForm {
+		return m.handleSilenceFormKey(msg)
+	}
+
+	if m.editingEvalTime {
+		return m.handleEvalTimeKey(msg)
+	}
+
 	// State-dependent keys
 	switch m.currentState() {
 	case StateLoading:
-		// Only allow quit during loading
+		// Only allow quit or cancelling the query during loading
+		if msg.String() == "esc" {
+			return m.cancelQuery()
+		}
 		return m, nil
 
 	case StateInput, StateResults, StateError:
@@ -101,26 +173,50 @@ func (m TUIModel) handleInputOrResultsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	// INSERT MODE: Route most keys to text input
 	if m.insertMode {
+		// Navigate and accept suggestions while they are shown
+		if updated, ok := m.handleCompletionKey(msg); ok {
+			return updated, nil
+		}
+
 		switch msg.String() {
 		case "esc":
 			// Exit insert mode
 			m.insertMode = false
+			m.completions = nil
 			m.queryInput.Blur()
 			return m, nil
 		case "enter":
 			// Execute and exit insert mode
 			m.insertMode = false
+			m.completions = nil
 			m.queryInput.Blur()
 			return m.handleEnterKey()
 		case "tab":
 			// Allow mode switching even in insert mode
 			return m.handleTabKey()
-		default:
-			// All other keys go to text input
-			var cmd tea.Cmd
-			m.queryInput, cmd = m.queryInput.Update(msg)
-			return m, cmd
+		case "ctrl+r":
+			return m.openHistorySearch()
+		case "up":
+			// Recall older queries from the first line of the query
+			if m.queryInput.Line() == 0 && m.history != nil {
+				return m.recallHistory(1), nil
+			}
+		case "down":
+			// and newer ones from its last line
+			if m.queryInput.Line() == m.queryInput.LineCount()-1 && m.historyIndex >= 0 {
+				return m.recallHistory(-1), nil
+			}
 		}
+
+		// All other keys go to text input. Only moving between the
+		// lines of a recalled query keeps recalling from its entry.
+		if msg.String() != "up" && msg.String() != "down" {
+			m.historyIndex = -1
+		}
+		var cmd, completionCmd tea.Cmd
+		m.queryInput, cmd = m.queryInput.Update(msg)
+		m, completionCmd = m.updateCompletions()
+		return m, tea.Batch(cmd, completionCmd)
 	}
 
 	// NORMAL MODE: Handle shortcuts
@@ -143,11 +239,27 @@ func (m TUIModel) handleNormalModeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleFormatKey()
 	case "esc":
 		return m.handleEscapeKey()
-	case "1", "2", "3", "4":
+	case "1", "2", "3", "4", "5", "6", "7", "8", "9", "0":
 		return m.handleNumberKey(msg.String())
 	case "?":
 		m.showShortcutsOverlay = true
 		return m, nil
+	case "d":
+		return m.openDatasourcePicker()
+	case "t":
+		return m.openEvalTimePrompt()
+	case "x":
+		return m.toggleExemplars()
+	case "H":
+		return m.cycleHistogramValue()
+	case "s":
+		return m.showQueryStats()
+	case "ctrl+r":
+		return m.openHistorySearch()
+	case "w":
+		return m.openSaveForm()
+	case "L":
+		return m.openLibrary()
 	case "ctrl+d", "ctrl+u":
 		if m.currentState() == StateResults {
 			var cmd tea.Cmd
@@ -160,12 +272,8 @@ func (m TUIModel) handleNormalModeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 }
 
 func (m TUIModel) handleTabKey() (tea.Model, tea.Cmd) {
-	// Cycle through modes: Instant -> Range -> Series -> Labels -> Instant
-	nextMode := m.mode + 1
-	if nextMode > ModeLabels {
-		nextMode = ModeInstant
-	}
-	return m.switchToMode(nextMode)
+	// Cycle through modes: Instant -> Range -> Series -> Labels -> Metadata -> Targets -> Rules -> TSDB -> Status -> Alertmanager -> Instant
+	return m.switchToMode((m.mode + 1) % modeCount)
 }
 
 func (m TUIModel) handleNumberKey(key string) (tea.Model, tea.Cmd) {
@@ -174,6 +282,12 @@ func (m TUIModel) handleNumberKey(key string) (tea.Model, tea.Cmd) {
 		"2": ModeRange,
 		"3": ModeSeries,
 		"4": ModeLabels,
+		"5": ModeMetadata,
+		"6": ModeTargets,
+		"7": ModeRules,
+		"8": ModeTSDB,
+		"9": ModeStatus,
+		"0": ModeAlertmanager,
 	}
 	if mode, ok := modeMap[key]; ok {
 		return m.switchToMode(mode)
@@ -191,6 +305,7 @@ func (m TUIModel) switchToMode(newMode QueryMode) (tea.Model, tea.Cmd) {
 
 	// Switch to new mode
 	m.mode = newMode
+	m.historyIndex = -1
 
 	// Restore new mode's query
 	m.queryInput.SetValue(m.modeQueries[m.mode])
@@ -206,9 +321,29 @@ func (m TUIModel) switchToMode(newMode QueryMode) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// openInRangeMode switches to /query_range with query pre-filled in
+// insert mode, so it can be adjusted before running it.
+func (m *TUIModel) openInRangeMode(query string) tea.Cmd {
+	m.modeQueries[ModeRange] = query
+	model, _ := m.switchToMode(ModeRange)
+	model, cmd := model.(TUIModel).enterInsertMode()
+	*m = model.(TUIModel)
+	m.queryInput.CursorEnd()
+	return cmd
+}
+
+// openInSeriesMode switches to /series and runs query.
+func (m *TUIModel) openInSeriesMode(query string) tea.Cmd {
+	m.modeQueries[ModeSeries] = query
+	model, _ := m.switchToMode(ModeSeries)
+	model, cmd := model.(TUIModel).executeQuery()
+	*m = model.(TUIModel)
+	return cmd
+}
+
 func (m TUIModel) handleEnterKey() (tea.Model, tea.Cmd) {
-	// Execute query
-	if m.queryInput.Value() != "" {
+	// Execute query; the /labels selector and the /metadata, /targets, /rules, /tsdb, /status and /alertmanager filters are optional
+	if m.queryInput.Value() != "" || m.mode == ModeLabels || m.mode == ModeMetadata || m.mode == ModeTargets || m.mode == ModeRules || m.mode == ModeTSDB || m.mode == ModeStatus || m.mode == ModeAlertmanager {
 		return m.executeQuery()
 	}
 	return m, nil
@@ -219,8 +354,7 @@ func (m TUIModel) enterInsertMode() (tea.Model, tea.Cmd) {
 	m.inputCollapsed = false
 	m.focusedPane = PaneQuery
 	m.queryInput.Focus()
-	m.resultsViewport.Height = m.getAvailableResultsHeight()
-	return m, nil
+	return m.resizeResults(), nil
 }
 
 func (m TUIModel) handleInteractiveKey() (tea.Model, tea.Cmd) {
@@ -259,5 +393,9 @@ func (m TUIModel) handleFormatKey() (tea.Model, tea.Cmd) {
 
 func (m TUIModel) handleLegendKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	cmd := m.currentMode().HandleLegendKey(&m, msg)
+	// Re-render so table navigation is visible
+	if m.currentState() == StateResults {
+		m = m.syncViewportContent()
+	}
 	return m, cmd
 }
@@ -3,8 +3,11 @@ package commands
 import (
 	"fmt"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 func (m TUIModel) View() string {
@@ -20,6 +23,64 @@ func (m TUIModel) View() string {
 		)
 	}
 
+	if m.showStatsOverlay {
+		return lipgloss.Place(
+			m.getTerminalWidth(),
+			m.height,
+			lipgloss.Center,
+			lipgloss.Center,
+			m.renderStatsOverlay(),
+		)
+	}
+
+	if m.showDatasourcePicker {
+		return lipgloss.Place(
+			m.getTerminalWidth(),
+			m.height,
+			lipgloss.Center,
+			lipgloss.Center,
+			m.renderDatasourcePicker(),
+		)
+	}
+
+	if m.showHistorySearch {
+		return lipgloss.Place(
+			m.getTerminalWidth(),
+			m.height,
+			lipgloss.Center,
+			lipgloss.Center,
+			m.renderHistorySearch(),
+		)
+	}
+
+	if m.showLibrary || m.showSaveForm {
+		dialog := m.renderLibrary
+		if m.showSaveForm {
+			dialog = m.renderSaveForm
+		}
+		return lipgloss.Place(
+			m.getTerminalWidth(),
+			m.height,
+			lipgloss.Center,
+			lipgloss.Center,
+			dialog(),
+		)
+	}
+
+	if m.showSilenceForm || m.confirm != nil {
+		dialog := m.renderConfirmDialog
+		if m.showSilenceForm {
+			dialog = m.renderSilenceForm
+		}
+		return lipgloss.Place(
+			m.getTerminalWidth(),
+			m.height,
+			lipgloss.Center,
+			lipgloss.Center,
+			dialog(),
+		)
+	}
+
 	var s strings.Builder
 
 	// Status bar
@@ -30,8 +91,15 @@ func (m TUIModel) View() string {
 	s.WriteString(m.renderQueryInput())
 	s.WriteString("\n")
 
-	// Results area
-	s.WriteString(m.renderResults())
+	// Results area, below the suggestions for the query input
+	results := m.renderResults()
+	if popup := m.renderCompletions(); popup != "" {
+		// Align the popup with the word being completed
+		_, column := m.queryInput.ScreenPosition(utf8.RuneCountInString(m.queryInput.Value()[:m.completionCtx.start]))
+		left := min(column+lipgloss.Width(m.queryInput.Prompt), m.getTerminalWidth()-lipgloss.Width(popup))
+		results = overlayLines(results, popup, max(left, 0))
+	}
+	s.WriteString(results)
 
 	// Results status bar (latency, etc.)
 	s.WriteString(m.renderResultsStatusBar())
@@ -46,33 +114,36 @@ func (m TUIModel) View() string {
 func (m TUIModel) renderStatusBar() string {
 	// Mode indicator
 	modeStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("244"))
-	instantStyle := modeStyle
-	rangeStyle := modeStyle
-	seriesStyle := modeStyle
-	labelsStyle := modeStyle
-
 	activeStyle := modeStyle.Background(lipgloss.Color("63")).Foreground(lipgloss.Color("231"))
 
-	switch m.mode {
-	case ModeInstant:
-		instantStyle = activeStyle
-	case ModeRange:
-		rangeStyle = activeStyle
-	case ModeSeries:
-		seriesStyle = activeStyle
-	case ModeLabels:
-		labelsStyle = activeStyle
+	renderModes := func(compact bool) string {
+		modeLabels := make([]string, 0, modeCount)
+		for mode := range modeCount {
+			switch {
+			case mode == m.mode:
+				modeLabels = append(modeLabels, activeStyle.Render(fmt.Sprintf(" %s %s ", mode.Key(), mode)))
+			case compact:
+				modeLabels = append(modeLabels, modeStyle.Render(fmt.Sprintf(" %s ", mode.Key())))
+			default:
+				modeLabels = append(modeLabels, modeStyle.Render(fmt.Sprintf(" %s %s ", mode.Key(), mode)))
+			}
+		}
+		return "  Mode: " + strings.Join(modeLabels, " | ")
 	}
 
-	modeText := fmt.Sprintf("  Mode: %s | %s | %s | %s",
-		instantStyle.Render(" 1 /query "),
-		rangeStyle.Render(" 2 /query_range "),
-		seriesStyle.Render(" 3 /series "),
-		labelsStyle.Render(" 4 /labels "))
-
 	// Get mode-specific parameters
 	paramsText := m.currentMode().RenderStatusParams(&m)
 
+	if name := m.activeDatasourceName(); name != "" {
+		paramsText += "   Datasource: " + name
+	}
+
+	// Only name the active mode when the full list doesn't fit on one line
+	modeText := renderModes(false)
+	if lipgloss.Width(modeText+paramsText) > m.getTerminalWidth()-2 {
+		modeText = renderModes(true)
+	}
+
 	statusStyle := lipgloss.NewStyle().
 		Background(lipgloss.Color("236")).
 		Foreground(lipgloss.Color("252")).
@@ -83,17 +154,23 @@ func (m TUIModel) renderStatusBar() string {
 }
 
 func (m TUIModel) renderQueryInput() string {
+	if m.editingEvalTime {
+		return m.renderEvalTimePrompt()
+	}
+
 	if m.inputCollapsed && !m.insertMode {
 		collapsedStyle := lipgloss.NewStyle().
 			Background(lipgloss.Color("237")).
 			Foreground(lipgloss.Color("252")).
 			Width(m.getTerminalWidth()).
 			Padding(0, 1)
-		queryText := m.queryInput.Value()
+		// Queries of several lines are shown on one
+		queryText := strings.Join(strings.Fields(m.queryInput.Value()), " ")
 		if queryText == "" {
 			queryText = "Enter PromQL query..."
 		}
-		return collapsedStyle.Render("  " + queryText)
+		// The collapsed input takes a single line of the chrome
+		return collapsedStyle.Render(ansi.Truncate("  "+queryText, m.getTerminalWidth()-2, "…"))
 	}
 
 	inputStyle := lipgloss.NewStyle().
@@ -107,7 +184,15 @@ func (m TUIModel) renderQueryInput() string {
 		inputStyle = inputStyle.BorderForeground(lipgloss.Color("63"))
 	}
 
-	return inputStyle.Render(m.queryInput.View())
+	input := m.queryInput
+	parseErr := m.queryParseError()
+	if completesPromQL(m.mode) {
+		input.Highlight = highlightQuery(parseErr)
+	}
+	if parseErr != nil {
+		return inputStyle.Render(input.View()) + "\n" + m.renderParseError(parseErr)
+	}
+	return inputStyle.Render(input.View())
 }
 
 func (m TUIModel) renderResults() string {
@@ -144,7 +229,8 @@ func renderEmptyState() string {
 
 func (m TUIModel) renderLoadingState() string {
 	loadingStyle := lipgloss.NewStyle().Padding(2, 4)
-	return loadingStyle.Render(fmt.Sprintf("%s Executing query: %s", m.spinner.View(), m.queryInput.Value()))
+	elapsed := formatDuration(m.currentElapsed().Truncate(100 * time.Millisecond))
+	return loadingStyle.Render(fmt.Sprintf("%s Executing query (%s): %s", m.spinner.View(), elapsed, m.queryInput.Value()))
 }
 
 func (m TUIModel) renderErrorState() string {
@@ -168,6 +254,10 @@ func (m TUIModel) renderResultsStatusBar() string {
 	if duration != 0 {
 		content = " Latency: " + formatDuration(duration)
 	}
+	content += m.renderStatsStatusBar()
+	if m.libraryNotice != "" {
+		content += " | " + m.libraryNotice
+	}
 
 	// Add mode-specific status bar content
 	content += m.currentMode().RenderResultsStatusBar(&m)
@@ -184,8 +274,12 @@ func (m TUIModel) renderHelpBar() string {
 
 	var helpText string
 	switch {
+	case m.insertMode && len(m.completions) > 0:
+		helpText = "↑/↓: select | tab: complete | esc: dismiss | enter: run"
 	case m.insertMode:
-		helpText = "esc: normal | ?: shortcuts | ctrl+c/q: quit"
+		helpText = "enter: run | alt+enter: newline | ↑/↓: history | ctrl+r: search | esc: normal"
+	case m.currentState() == StateLoading:
+		helpText = "esc: cancel query | ctrl+c: quit"
 	case m.currentState() == StateResults:
 		helpText = "/: edit | ctrl+d/u: scroll | ?: shortcuts | q: quit"
 	default:
@@ -225,8 +319,10 @@ func renderShortcutsOverlay() string {
 	content.WriteString("\n")
 	shortcuts := []struct{ key, desc string }{
 		{"Tab", "Cycle through modes"},
-		{"1-4", "Switch to mode directly"},
+		{"0-9", "Switch to mode directly"},
 		{"Enter", "Execute query"},
+		{"Esc", "Cancel running query"},
+		{"d", "Switch datasource"},
 		{"q", "Quit"},
 		{"Ctrl+C", "Force quit"},
 	}
@@ -240,7 +336,18 @@ func renderShortcutsOverlay() string {
 	editShortcuts := []struct{ key, desc string }{
 		{"/", "Enter insert mode"},
 		{"Esc", "Exit insert mode"},
+		{"Ctrl+J", "New line (insert mode, also Alt+Enter)"},
+		{"↑/↓", "Select suggestion (insert mode)"},
+		{"Tab", "Complete suggestion (insert mode)"},
+		{"↑/↓", "Recall previous/next query (insert mode)"},
+		{"Ctrl+R", "Search query history"},
+		{"w", "Save query to the library"},
+		{"L", "Browse saved queries"},
 		{"f", "Format PromQL query"},
+		{"t", "Set evaluation time (/query)"},
+		{"x", "Toggle exemplars (/query_range)"},
+		{"H", "Cycle histogram quantile/count/sum (/query_range)"},
+		{"s", "Show query stats, again for details (/query, /query_range)"},
 	}
 	for _, s := range editShortcuts {
 		content.WriteString(fmt.Sprintf("  %s  %s\n", keyStyle.Render(fmt.Sprintf("%-8s", s.key)), descStyle.Render(s.desc)))
@@ -264,6 +371,14 @@ func renderShortcutsOverlay() string {
 		{"i", "Toggle interactive mode"},
 		{"j/k", "Navigate up/down"},
 		{"h/l", "Page up/down"},
+		{"Enter", "Open selection in /query_range or /series"},
+		{"[/]", "Previous/next table (/tsdb)"},
+		{"o", "Sort by value or name (/tsdb)"},
+		{"g", "Group targets by job (/targets)"},
+		{"s", "Cycle health/state filter (/targets, /rules)"},
+		{"g/G", "Scroll to top/bottom (/status)"},
+		{"S", "Silence selected series or alert"},
+		{"x", "Expire selected silence (/alertmanager)"},
 		{"Esc", "Exit interactive mode"},
 	}
 	for _, s := range interactiveShortcuts {
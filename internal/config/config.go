@@ -0,0 +1,289 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/akasprzok/peat/internal/prometheus"
+	"go.yaml.in/yaml/v3"
+)
+
+// appName is the directory name used below the XDG base directories.
+const appName = "peat"
+
+// Config is the contents of the peat configuration file.
+type Config struct {
+	// DefaultDatasource is the name of the datasource selected at startup
+	// when --datasource is not given. Defaults to the first datasource.
+	DefaultDatasource string       `yaml:"default_datasource"`
+	Datasources       []Datasource `yaml:"datasources"`
+
+	// Library is the file queries are saved to. Defaults to
+	// $XDG_CONFIG_HOME/peat/queries.yaml.
+	Library string `yaml:"library"`
+
+	// TeamLibrary is a shared query file browsed alongside Library, e.g.
+	// checked into a runbooks repository. It is never written to.
+	TeamLibrary string `yaml:"team_library"`
+}
+
+// Datasource is a named Prometheus-compatible endpoint.
+type Datasource struct {
+	Name string     `yaml:"name"`
+	URL  string     `yaml:"url"`
+	Auth AuthConfig `yaml:"auth"`
+	TLS  TLSConfig  `yaml:"tls"`
+
+	// Files are Prometheus text or OpenMetrics files queried locally
+	// instead of a server at URL.
+	Files []string `yaml:"files"`
+
+	// TSDBDir is a Prometheus data directory opened read-only and queried
+	// locally instead of a server at URL.
+	TSDBDir string `yaml:"tsdb_dir"`
+
+	// RemoteReadURL is a remote-read endpoint whose raw series are queried
+	// locally instead of a server at URL. Auth and TLS settings apply.
+	RemoteReadURL string `yaml:"remote_read_url"`
+
+	// AlertmanagerURL is the base URL of the Alertmanager receiving the
+	// datasource's alerts.
+	AlertmanagerURL string `yaml:"alertmanager_url"`
+
+	// AlertmanagerAuth and AlertmanagerTLS are the settings the Alertmanager
+	// is reached with. The datasource's own are never sent to it.
+	AlertmanagerAuth AuthConfig `yaml:"alertmanager_auth"`
+	AlertmanagerTLS  TLSConfig  `yaml:"alertmanager_tls"`
+
+	// ExemplarURL is a text/template rendering the trace URL of an exemplar
+	// from its labels, e.g. https://tempo.example.com/trace/{{.trace_id}}.
+	ExemplarURL string `yaml:"exemplar_url"`
+
+	// Timeout, Range and Step override the CLI defaults when non-zero.
+	Timeout time.Duration `yaml:"timeout"`
+	Range   time.Duration `yaml:"range"`
+	Step    time.Duration `yaml:"step"`
+}
+
+// AuthConfig holds a datasource's credentials and extra headers.
+type AuthConfig struct {
+	BasicAuthUser         string            `yaml:"basic_auth_user"`
+	BasicAuthPassword     string            `yaml:"basic_auth_password"`
+	BasicAuthPasswordFile string            `yaml:"basic_auth_password_file"`
+	BearerToken           string            `yaml:"bearer_token"`
+	BearerTokenFile       string            `yaml:"bearer_token_file"`
+	Headers               map[string]string `yaml:"headers"`
+}
+
+// TLSConfig holds a datasource's TLS settings.
+type TLSConfig struct {
+	CAFile             string `yaml:"ca_file"`
+	CertFile           string `yaml:"cert_file"`
+	KeyFile            string `yaml:"key_file"`
+	ServerName         string `yaml:"server_name"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+// Location describes where the datasource's samples come from: its URL,
+// its files, its TSDB directory or its remote-read URL.
+func (d Datasource) Location() string {
+	switch {
+	case len(d.Files) > 0:
+		return strings.Join(d.Files, ", ")
+	case d.TSDBDir != "":
+		return d.TSDBDir
+	case d.RemoteReadURL != "":
+		return d.RemoteReadURL
+	}
+	return d.URL
+}
+
+// sources counts the sample sources set on the datasource, of which there
+// must be exactly one.
+func (d Datasource) sources() int {
+	n := 0
+	for _, set := range []bool{d.URL != "", len(d.Files) > 0, d.TSDBDir != "", d.RemoteReadURL != ""} {
+		if set {
+			n++
+		}
+	}
+	return n
+}
+
+// ClientConfig returns the Prometheus client configuration for the datasource.
+func (d Datasource) ClientConfig() prometheus.Config {
+	return clientConfig(d.URL, d.Auth, d.TLS)
+}
+
+// AlertmanagerConfig returns the Alertmanager client configuration for the
+// datasource, or false when it has no Alertmanager.
+func (d Datasource) AlertmanagerConfig() (prometheus.Config, bool) {
+	if d.AlertmanagerURL == "" {
+		return prometheus.Config{}, false
+	}
+	return clientConfig(d.AlertmanagerURL, d.AlertmanagerAuth, d.AlertmanagerTLS), true
+}
+
+// clientConfig returns the configuration of a client of the endpoint at url.
+func clientConfig(url string, auth AuthConfig, tls TLSConfig) prometheus.Config {
+	return prometheus.Config{
+		URL: url,
+		Auth: prometheus.AuthConfig{
+			BasicAuthUser:         auth.BasicAuthUser,
+			BasicAuthPassword:     auth.BasicAuthPassword,
+			BasicAuthPasswordFile: auth.BasicAuthPasswordFile,
+			BearerToken:           auth.BearerToken,
+			BearerTokenFile:       auth.BearerTokenFile,
+			Headers:               auth.Headers,
+		},
+		TLS: prometheus.TLSConfig{
+			CAFile:             tls.CAFile,
+			CertFile:           tls.CertFile,
+			KeyFile:            tls.KeyFile,
+			ServerName:         tls.ServerName,
+			InsecureSkipVerify: tls.InsecureSkipVerify,
+		},
+	}
+}
+
+// ExemplarTemplate parses the datasource's exemplar URL template.
+// It returns nil when no template is configured.
+func (d Datasource) ExemplarTemplate() (*template.Template, error) {
+	if d.ExemplarURL == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New(d.Name).Option("missingkey=error").Parse(d.ExemplarURL)
+	if err != nil {
+		return nil, fmt.Errorf("datasource %q: invalid exemplar_url: %w", d.Name, err)
+	}
+	return tmpl, nil
+}
+
+// Load reads the configuration file at path.
+// A missing file yields an empty configuration and an error wrapping os.ErrNotExist.
+func Load(path string) (Config, error) {
+	var cfg Config
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return cfg, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	cfg.resolvePaths(filepath.Dir(path))
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// resolvePaths makes the relative file paths of the configuration relative
+// to dir, the directory of the configuration file, as Prometheus does.
+func (c *Config) resolvePaths(dir string) {
+	for _, p := range []*string{&c.Library, &c.TeamLibrary} {
+		*p = resolvePath(dir, *p)
+	}
+	for i := range c.Datasources {
+		ds := &c.Datasources[i]
+		for j := range ds.Files {
+			ds.Files[j] = resolvePath(dir, ds.Files[j])
+		}
+		for _, p := range []*string{
+			&ds.TSDBDir,
+			&ds.Auth.BasicAuthPasswordFile,
+			&ds.Auth.BearerTokenFile,
+			&ds.TLS.CAFile,
+			&ds.TLS.CertFile,
+			&ds.TLS.KeyFile,
+			&ds.AlertmanagerAuth.BasicAuthPasswordFile,
+			&ds.AlertmanagerAuth.BearerTokenFile,
+			&ds.AlertmanagerTLS.CAFile,
+			&ds.AlertmanagerTLS.CertFile,
+			&ds.AlertmanagerTLS.KeyFile,
+		} {
+			*p = resolvePath(dir, *p)
+		}
+	}
+}
+
+// resolvePath returns path joined to dir, unless it is empty or absolute.
+func resolvePath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// Validate checks that every datasource has a unique name and exactly one
+// of a URL, files, a TSDB directory or a remote-read URL.
+func (c Config) Validate() error {
+	seen := make(map[string]bool, len(c.Datasources))
+	for i, ds := range c.Datasources {
+		if ds.Name == "" {
+			return fmt.Errorf("datasource %d has no name", i+1)
+		}
+		if n := ds.sources(); n == 0 {
+			return fmt.Errorf("datasource %q has no url, files, tsdb_dir or remote_read_url", ds.Name)
+		} else if n > 1 {
+			return fmt.Errorf("datasource %q sets more than one of url, files, tsdb_dir and remote_read_url", ds.Name)
+		}
+		if seen[ds.Name] {
+			return fmt.Errorf("duplicate datasource %q", ds.Name)
+		}
+		if _, err := ds.ExemplarTemplate(); err != nil {
+			return err
+		}
+		seen[ds.Name] = true
+	}
+	if c.DefaultDatasource != "" && !seen[c.DefaultDatasource] {
+		return fmt.Errorf("default datasource %q is not defined", c.DefaultDatasource)
+	}
+	return nil
+}
+
+// DefaultPath returns the default configuration file path,
+// $XDG_CONFIG_HOME/peat/config.yaml (~/.config/peat/config.yaml).
+func DefaultPath() (string, error) {
+	dir, err := xdgDir("XDG_CONFIG_HOME", ".config")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appName, "config.yaml"), nil
+}
+
+// DefaultLibraryPath returns the default saved query file path,
+// $XDG_CONFIG_HOME/peat/queries.yaml (~/.config/peat/queries.yaml).
+func DefaultLibraryPath() (string, error) {
+	dir, err := xdgDir("XDG_CONFIG_HOME", ".config")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appName, "queries.yaml"), nil
+}
+
+// DefaultHistoryPath returns the default query history file path,
+// $XDG_STATE_HOME/peat/history.jsonl (~/.local/state/peat/history.jsonl).
+func DefaultHistoryPath() (string, error) {
+	dir, err := xdgDir("XDG_STATE_HOME", filepath.Join(".local", "state"))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appName, "history.jsonl"), nil
+}
+
+// xdgDir returns the XDG base directory named by env, falling back to
+// fallback below the user's home directory.
+func xdgDir(env, fallback string) (string, error) {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine home directory: %w", err)
+	}
+	return filepath.Join(home, fallback), nil
+}
@@ -0,0 +1,229 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	path := writeConfig(t, `
+default_datasource: staging
+library: /home/peat/queries.yaml
+team_library: /srv/runbooks/peat/queries.yaml
+datasources:
+  - name: prod
+    url: https://prometheus.prod.example.com
+    alertmanager_url: https://alertmanager.prod.example.com
+    alertmanager_auth:
+      basic_auth_user: peat
+      basic_auth_password_file: /etc/peat/alertmanager-password
+    timeout: 30s
+    range: 6h
+    step: 5m
+    auth:
+      bearer_token_file: /var/run/token
+      headers:
+        X-Scope-OrgID: team-a
+    tls:
+      ca_file: /etc/ssl/ca.pem
+  - name: staging
+    url: http://prometheus.staging:9090
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.DefaultDatasource != "staging" {
+		t.Errorf("DefaultDatasource = %q, want %q", cfg.DefaultDatasource, "staging")
+	}
+	if cfg.Library != "/home/peat/queries.yaml" || cfg.TeamLibrary != "/srv/runbooks/peat/queries.yaml" {
+		t.Errorf("Library, TeamLibrary = %q, %q", cfg.Library, cfg.TeamLibrary)
+	}
+	if len(cfg.Datasources) != 2 {
+		t.Fatalf("len(Datasources) = %d, want 2", len(cfg.Datasources))
+	}
+
+	prod := cfg.Datasources[0]
+	if prod.Timeout != 30*time.Second || prod.Range != 6*time.Hour || prod.Step != 5*time.Minute {
+		t.Errorf("durations = %v/%v/%v, want 30s/6h/5m", prod.Timeout, prod.Range, prod.Step)
+	}
+
+	clientCfg := prod.ClientConfig()
+	if clientCfg.URL != "https://prometheus.prod.example.com" {
+		t.Errorf("ClientConfig().URL = %q", clientCfg.URL)
+	}
+	if clientCfg.Auth.BearerTokenFile != "/var/run/token" {
+		t.Errorf("ClientConfig().Auth.BearerTokenFile = %q", clientCfg.Auth.BearerTokenFile)
+	}
+	if clientCfg.Auth.Headers["X-Scope-OrgID"] != "team-a" {
+		t.Errorf("ClientConfig().Auth.Headers = %v", clientCfg.Auth.Headers)
+	}
+	if clientCfg.TLS.CAFile != "/etc/ssl/ca.pem" {
+		t.Errorf("ClientConfig().TLS.CAFile = %q", clientCfg.TLS.CAFile)
+	}
+
+	amCfg, ok := prod.AlertmanagerConfig()
+	if !ok || amCfg.URL != "https://alertmanager.prod.example.com" {
+		t.Errorf("AlertmanagerConfig() = %q, %v", amCfg.URL, ok)
+	}
+	if amCfg.Auth.BearerTokenFile != "" || amCfg.Auth.Headers != nil || amCfg.TLS.CAFile != "" {
+		t.Errorf("AlertmanagerConfig() = %+v, want none of the datasource's auth and TLS settings", amCfg)
+	}
+	if amCfg.Auth.BasicAuthUser != "peat" {
+		t.Errorf("AlertmanagerConfig().Auth.BasicAuthUser = %q, want its own", amCfg.Auth.BasicAuthUser)
+	}
+	if _, ok := cfg.Datasources[1].AlertmanagerConfig(); ok {
+		t.Error("AlertmanagerConfig() ok = true for a datasource without alertmanager_url")
+	}
+}
+
+func TestLoadRelativePaths(t *testing.T) {
+	path := writeConfig(t, `
+library: queries.yaml
+datasources:
+  - name: prod
+    url: https://prometheus.prod.example.com
+    auth:
+      bearer_token_file: secrets/token
+    tls:
+      ca_file: ca.pem
+      cert_file: /etc/ssl/client.pem
+  - name: offline
+    files: [dumps/node.prom]
+`)
+	dir := filepath.Dir(path)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if want := filepath.Join(dir, "queries.yaml"); cfg.Library != want {
+		t.Errorf("Library = %q, want %q", cfg.Library, want)
+	}
+	prod := cfg.Datasources[0]
+	if want := filepath.Join(dir, "secrets", "token"); prod.Auth.BearerTokenFile != want {
+		t.Errorf("Auth.BearerTokenFile = %q, want %q", prod.Auth.BearerTokenFile, want)
+	}
+	if want := filepath.Join(dir, "ca.pem"); prod.TLS.CAFile != want {
+		t.Errorf("TLS.CAFile = %q, want %q", prod.TLS.CAFile, want)
+	}
+	if prod.TLS.CertFile != "/etc/ssl/client.pem" || prod.TLS.KeyFile != "" {
+		t.Errorf("TLS.CertFile, TLS.KeyFile = %q, %q, want absolute and empty paths kept", prod.TLS.CertFile, prod.TLS.KeyFile)
+	}
+	if want := filepath.Join(dir, "dumps", "node.prom"); cfg.Datasources[1].Files[0] != want {
+		t.Errorf("Files[0] = %q, want %q", cfg.Datasources[1].Files[0], want)
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	_, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Load() error = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"missing name", "datasources:\n  - url: http://localhost:9090\n"},
+		{"missing url", "datasources:\n  - name: local\n"},
+		{"url and files", "datasources:\n  - name: local\n    url: http://localhost:9090\n    files: [metrics.txt]\n"},
+		{"files and tsdb dir", "datasources:\n  - name: local\n    files: [metrics.txt]\n    tsdb_dir: /data\n"},
+		{"url and remote read url", "datasources:\n  - name: local\n    url: http://localhost:9090\n    remote_read_url: http://localhost:9090/api/v1/read\n"},
+		{"duplicate name", "datasources:\n  - name: a\n    url: http://a\n  - name: a\n    url: http://b\n"},
+		{"unknown default", "default_datasource: b\ndatasources:\n  - name: a\n    url: http://a\n"},
+		{"invalid yaml", "datasources: [\n"},
+		{"invalid exemplar url", "datasources:\n  - name: a\n    url: http://a\n    exemplar_url: https://tempo/{{.trace_id\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Load(writeConfig(t, tt.content)); err == nil {
+				t.Error("Load() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestDefaultPath(t *testing.T) {
+	t.Run("uses XDG_CONFIG_HOME", func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", "/xdg/config")
+		got, err := DefaultPath()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := filepath.Join("/xdg/config", "peat", "config.yaml"); got != want {
+			t.Errorf("DefaultPath() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("falls back to ~/.config", func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", "")
+		t.Setenv("HOME", "/home/peat")
+		got, err := DefaultPath()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := filepath.Join("/home/peat", ".config", "peat", "config.yaml"); got != want {
+			t.Errorf("DefaultPath() = %q, want %q", got, want)
+		}
+	})
+}
+
+func TestDefaultHistoryPath(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/xdg/state")
+	got, err := DefaultHistoryPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join("/xdg/state", "peat", "history.jsonl"); got != want {
+		t.Errorf("DefaultHistoryPath() = %q, want %q", got, want)
+	}
+
+	t.Setenv("XDG_STATE_HOME", "")
+	t.Setenv("HOME", "/home/peat")
+	if got, _ := DefaultHistoryPath(); got != filepath.Join("/home/peat", ".local", "state", "peat", "history.jsonl") {
+		t.Errorf("DefaultHistoryPath() without XDG_STATE_HOME = %q", got)
+	}
+}
+
+func TestExemplarTemplate(t *testing.T) {
+	ds := Datasource{Name: "a", ExemplarURL: "https://tempo.example.com/trace/{{.trace_id}}"}
+	tmpl, err := ds.ExemplarTemplate()
+	if err != nil {
+		t.Fatalf("ExemplarTemplate() error = %v", err)
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, map[string]string{"trace_id": "abc123"}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got, want := b.String(), "https://tempo.example.com/trace/abc123"; got != want {
+		t.Errorf("Execute() = %q, want %q", got, want)
+	}
+
+	if err := tmpl.Execute(&b, map[string]string{}); err == nil {
+		t.Error("Execute() with a missing label error = nil, want error")
+	}
+
+	if tmpl, err := (Datasource{Name: "b"}).ExemplarTemplate(); tmpl != nil || err != nil {
+		t.Errorf("ExemplarTemplate() without a URL = %v, %v, want nil, nil", tmpl, err)
+	}
+}
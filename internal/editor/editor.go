@@ -0,0 +1,608 @@
+// Package editor implements a multi-line text editor for queries, used like
+// the bubbles text input it replaces. It auto-indents new lines and
+// highlights the bracket matching the one at the cursor.
+package editor
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// IndentWidth is the number of spaces a line is indented by after an
+// opening bracket.
+const IndentWidth = 2
+
+// brackets maps opening brackets to their closing ones.
+var brackets = map[rune]rune{'(': ')', '{': '}', '[': ']'}
+
+// KeyMap defines the keys of the editor.
+type KeyMap struct {
+	CharacterForward        key.Binding
+	CharacterBackward       key.Binding
+	WordForward             key.Binding
+	WordBackward            key.Binding
+	LineUp                  key.Binding
+	LineDown                key.Binding
+	LineStart               key.Binding
+	LineEnd                 key.Binding
+	InputStart              key.Binding
+	InputEnd                key.Binding
+	DeleteCharacterBackward key.Binding
+	DeleteCharacterForward  key.Binding
+	DeleteWordBackward      key.Binding
+	DeleteWordForward       key.Binding
+	DeleteBeforeCursor      key.Binding
+	DeleteAfterCursor       key.Binding
+	InsertNewline           key.Binding
+}
+
+// DefaultKeyMap is the default set of key bindings, matching the bubbles
+// text input where they overlap. Enter is left to the caller, so a newline
+// is inserted with alt+enter or ctrl+j.
+var DefaultKeyMap = KeyMap{
+	CharacterForward:        key.NewBinding(key.WithKeys("right", "ctrl+f")),
+	CharacterBackward:       key.NewBinding(key.WithKeys("left", "ctrl+b")),
+	WordForward:             key.NewBinding(key.WithKeys("alt+right", "ctrl+right", "alt+f")),
+	WordBackward:            key.NewBinding(key.WithKeys("alt+left", "ctrl+left", "alt+b")),
+	LineUp:                  key.NewBinding(key.WithKeys("up")),
+	LineDown:                key.NewBinding(key.WithKeys("down")),
+	LineStart:               key.NewBinding(key.WithKeys("home", "ctrl+a")),
+	LineEnd:                 key.NewBinding(key.WithKeys("end", "ctrl+e")),
+	InputStart:              key.NewBinding(key.WithKeys("ctrl+home", "alt+<")),
+	InputEnd:                key.NewBinding(key.WithKeys("ctrl+end", "alt+>")),
+	DeleteCharacterBackward: key.NewBinding(key.WithKeys("backspace", "ctrl+h")),
+	DeleteCharacterForward:  key.NewBinding(key.WithKeys("delete", "ctrl+d")),
+	DeleteWordBackward:      key.NewBinding(key.WithKeys("alt+backspace", "ctrl+w")),
+	DeleteWordForward:       key.NewBinding(key.WithKeys("alt+delete", "alt+d")),
+	DeleteBeforeCursor:      key.NewBinding(key.WithKeys("ctrl+u")),
+	DeleteAfterCursor:       key.NewBinding(key.WithKeys("ctrl+k")),
+	InsertNewline:           key.NewBinding(key.WithKeys("alt+enter", "ctrl+j")),
+}
+
+// Model is the state of the editor.
+type Model struct {
+	Prompt           string // Shown before the first line, and as indentation before the others
+	PromptStyle      lipgloss.Style
+	Placeholder      string
+	PlaceholderStyle lipgloss.Style
+	MatchStyle       lipgloss.Style // Style of the brackets at the cursor and its match
+	Cursor           cursor.Model
+	KeyMap           KeyMap
+
+	// Width is the number of columns lines are shown in, scrolling
+	// horizontally with the cursor. 0 shows lines in full.
+	Width int
+
+	// MaxHeight is the maximum number of lines shown, scrolling vertically
+	// with the cursor. 0 shows every line.
+	MaxHeight int
+
+	// Highlight returns the style of each byte of the value, nil for bytes
+	// shown as they are. Nil shows the value unstyled.
+	Highlight func(value string) []*lipgloss.Style
+
+	lines [][]rune // Never empty
+	row   int      // Line of the cursor
+	col   int      // Rune of the cursor within its line
+	focus bool
+}
+
+// New creates an empty editor.
+func New() Model {
+	return Model{
+		Prompt:           "> ",
+		PlaceholderStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("240")),
+		MatchStyle:       lipgloss.NewStyle().Bold(true).Background(lipgloss.Color("238")),
+		Cursor:           cursor.New(),
+		KeyMap:           DefaultKeyMap,
+		lines:            [][]rune{{}},
+	}
+}
+
+// Value returns the text of the editor, lines separated by "\n".
+func (m Model) Value() string {
+	lines := make([]string, len(m.lines))
+	for i, line := range m.lines {
+		lines[i] = string(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SetValue replaces the text of the editor and moves the cursor to its end.
+// Tabs are replaced by spaces and other control characters dropped, like the
+// text input does.
+func (m *Model) SetValue(s string) {
+	s = whitespace.Replace(s)
+	m.lines = m.lines[:0:0]
+	for _, line := range strings.Split(s, "\n") {
+		m.lines = append(m.lines, []rune(strings.Map(dropControl, line)))
+	}
+	m.CursorEnd()
+}
+
+// whitespace normalizes line breaks to "\n" and tabs to spaces.
+var whitespace = strings.NewReplacer("\r\n", "\n", "\r", "\n", "\t", " ")
+
+// dropControl drops control characters other than line breaks.
+func dropControl(r rune) rune {
+	if r != '\n' && unicode.IsControl(r) {
+		return -1
+	}
+	return r
+}
+
+// LineCount returns the number of lines of the text.
+func (m Model) LineCount() int {
+	return len(m.lines)
+}
+
+// Line returns the line of the cursor, starting at 0.
+func (m Model) Line() int {
+	return m.row
+}
+
+// Height returns the number of lines shown.
+func (m Model) Height() int {
+	if m.MaxHeight > 0 {
+		return min(len(m.lines), m.MaxHeight)
+	}
+	return len(m.lines)
+}
+
+// Position returns the cursor position as a rune index into Value.
+func (m Model) Position() int {
+	pos := m.col
+	for _, line := range m.lines[:m.row] {
+		pos += len(line) + 1
+	}
+	return pos
+}
+
+// SetCursor moves the cursor to the rune index pos of Value, clamped to the text.
+func (m *Model) SetCursor(pos int) {
+	m.row, m.col = 0, 0
+	for pos > len(m.lines[m.row]) && m.row < len(m.lines)-1 {
+		pos -= len(m.lines[m.row]) + 1
+		m.row++
+	}
+	m.col = max(0, min(pos, len(m.lines[m.row])))
+}
+
+// CursorStart moves the cursor to the start of the text.
+func (m *Model) CursorStart() {
+	m.row, m.col = 0, 0
+}
+
+// CursorEnd moves the cursor to the end of the text.
+func (m *Model) CursorEnd() {
+	m.row = len(m.lines) - 1
+	m.col = len(m.lines[m.row])
+}
+
+// Focused reports whether the editor receives keys.
+func (m Model) Focused() bool {
+	return m.focus
+}
+
+// Focus makes the editor receive keys and shows the cursor.
+func (m *Model) Focus() tea.Cmd {
+	m.focus = true
+	return m.Cursor.Focus()
+}
+
+// Blur stops the editor from receiving keys and hides the cursor.
+func (m *Model) Blur() {
+	m.focus = false
+	m.Cursor.Blur()
+}
+
+// Update handles editing keys while the editor is focused, and cursor blinks.
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	if !m.focus {
+		return m, nil
+	}
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		line := m.lines[m.row]
+		switch {
+		case (msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace) && !msg.Alt:
+			// Typed text like "up" could otherwise match a binding
+			m.insertRunes(msg.Runes)
+		case key.Matches(msg, m.KeyMap.CharacterForward):
+			m.SetCursor(m.Position() + 1)
+		case key.Matches(msg, m.KeyMap.CharacterBackward):
+			m.SetCursor(m.Position() - 1)
+		case key.Matches(msg, m.KeyMap.WordForward):
+			m.SetCursor(m.wordEnd())
+		case key.Matches(msg, m.KeyMap.WordBackward):
+			m.SetCursor(m.wordStart())
+		case key.Matches(msg, m.KeyMap.LineUp):
+			if m.row > 0 {
+				m.row--
+				m.col = min(m.col, len(m.lines[m.row]))
+			}
+		case key.Matches(msg, m.KeyMap.LineDown):
+			if m.row < len(m.lines)-1 {
+				m.row++
+				m.col = min(m.col, len(m.lines[m.row]))
+			}
+		case key.Matches(msg, m.KeyMap.LineStart):
+			m.col = 0
+		case key.Matches(msg, m.KeyMap.LineEnd):
+			m.col = len(line)
+		case key.Matches(msg, m.KeyMap.InputStart):
+			m.CursorStart()
+		case key.Matches(msg, m.KeyMap.InputEnd):
+			m.CursorEnd()
+		case key.Matches(msg, m.KeyMap.DeleteCharacterBackward):
+			m.deleteRange(m.Position()-1, m.Position())
+		case key.Matches(msg, m.KeyMap.DeleteCharacterForward):
+			m.deleteRange(m.Position(), m.Position()+1)
+		case key.Matches(msg, m.KeyMap.DeleteWordBackward):
+			m.deleteRange(m.wordStart(), m.Position())
+		case key.Matches(msg, m.KeyMap.DeleteWordForward):
+			m.deleteRange(m.Position(), m.wordEnd())
+		case key.Matches(msg, m.KeyMap.DeleteBeforeCursor):
+			m.setLine(m.row, line[m.col:])
+			m.col = 0
+		case key.Matches(msg, m.KeyMap.DeleteAfterCursor):
+			m.setLine(m.row, line[:m.col])
+		case key.Matches(msg, m.KeyMap.InsertNewline):
+			m.insertNewline()
+		}
+	}
+
+	var cmd tea.Cmd
+	m.Cursor, cmd = m.Cursor.Update(msg)
+	return m, cmd
+}
+
+// setLine replaces a line of the text. The lines are copied first, as
+// copies of the model share them.
+func (m *Model) setLine(row int, line []rune) {
+	m.lines = slices.Clone(m.lines)
+	m.lines[row] = line
+}
+
+// runes returns the text as a single slice, lines separated by '\n'.
+func (m Model) runes() []rune {
+	return []rune(m.Value())
+}
+
+// deleteRange deletes the runes from start up to end of Value, clamped to the text.
+func (m *Model) deleteRange(start, end int) {
+	runes := m.runes()
+	start, end = max(0, start), min(len(runes), end)
+	if start >= end {
+		return
+	}
+	m.SetValue(string(runes[:start]) + string(runes[end:]))
+	m.SetCursor(start)
+}
+
+// wordStart returns the position of the start of the word before the cursor.
+func (m Model) wordStart() int {
+	runes, pos := m.runes(), m.Position()
+	for pos > 0 && !isWordRune(runes[pos-1]) {
+		pos--
+	}
+	for pos > 0 && isWordRune(runes[pos-1]) {
+		pos--
+	}
+	return pos
+}
+
+// wordEnd returns the position of the end of the word after the cursor.
+func (m Model) wordEnd() int {
+	runes, pos := m.runes(), m.Position()
+	for pos < len(runes) && !isWordRune(runes[pos]) {
+		pos++
+	}
+	for pos < len(runes) && isWordRune(runes[pos]) {
+		pos++
+	}
+	return pos
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || r == ':' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// insertRunes inserts typed or pasted runes at the cursor. A closing
+// bracket typed on an otherwise blank line is aligned with the line of its
+// opening bracket.
+func (m *Model) insertRunes(runes []rune) {
+	line := m.lines[m.row]
+	if len(runes) == 1 && isClosing(runes[0]) && strings.TrimSpace(string(line[:m.col])) == "" {
+		if open, ok := m.matchBefore(m.Position(), runes[0]); ok {
+			indent := indentation(m.lines[m.rowOf(open)])
+			m.setLine(m.row, append([]rune(indent), line[m.col:]...))
+			m.col = len([]rune(indent))
+		}
+	}
+
+	// Pasted text keeps its lines
+	inserted := []rune(strings.Map(dropControl, whitespace.Replace(string(runes))))
+	pos := m.Position()
+	value := m.runes()
+	m.SetValue(string(value[:pos]) + string(inserted) + string(value[pos:]))
+	m.SetCursor(pos + len(inserted))
+}
+
+// insertNewline splits the line at the cursor, indenting the new line like
+// the current one, and further after an opening bracket. Between a pair of
+// brackets, the closing one moves to a line of its own.
+func (m *Model) insertNewline() {
+	line := m.lines[m.row]
+	before := []rune(strings.TrimRight(string(line[:m.col]), " "))
+	after := []rune(strings.TrimLeft(string(line[m.col:]), " "))
+	indent := indentation(line)
+
+	cursorLine := []rune(indent)
+	newLines := [][]rune{before, nil}
+	if n := len(before); n > 0 && brackets[before[n-1]] != 0 {
+		cursorLine = []rune(indent + strings.Repeat(" ", IndentWidth))
+		if len(after) > 0 && after[0] == brackets[before[n-1]] {
+			newLines = append(newLines, append([]rune(indent), after...))
+			after = nil
+		}
+	}
+	newLines[1] = append(cursorLine, after...)
+
+	lines := append([][]rune{}, m.lines[:m.row]...)
+	lines = append(lines, newLines...)
+	m.lines = append(lines, m.lines[m.row+1:]...)
+	m.row++
+	m.col = len(cursorLine)
+}
+
+// indentation returns the leading spaces of line.
+func indentation(line []rune) string {
+	s := string(line)
+	return s[:len(s)-len(strings.TrimLeft(s, " "))]
+}
+
+// rowOf returns the line of the rune index pos of Value.
+func (m Model) rowOf(pos int) int {
+	for row, line := range m.lines {
+		if pos <= len(line) {
+			return row
+		}
+		pos -= len(line) + 1
+	}
+	return len(m.lines) - 1
+}
+
+func isClosing(r rune) bool {
+	return r == ')' || r == '}' || r == ']'
+}
+
+// quoted returns which runes of runes are inside string literals, including
+// their quotes, so brackets in them aren't matched.
+func quoted(runes []rune) []bool {
+	inside := make([]bool, len(runes))
+	var quote rune
+	escaped := false
+	for i, r := range runes {
+		switch {
+		case quote == 0:
+			if r == '"' || r == '\'' || r == '`' {
+				quote = r
+				inside[i] = true
+			}
+		case escaped:
+			escaped = false
+			inside[i] = true
+		case r == '\\' && quote != '`':
+			escaped = true
+			inside[i] = true
+		default:
+			inside[i] = true
+			if r == quote {
+				quote = 0
+			}
+		}
+	}
+	return inside
+}
+
+// matchBefore returns the position of the unmatched opening bracket of
+// closing before pos.
+func (m Model) matchBefore(pos int, closing rune) (int, bool) {
+	runes := m.runes()
+	inside := quoted(runes)
+	depth := 0
+	for i := min(pos, len(runes)) - 1; i >= 0; i-- {
+		if inside[i] {
+			continue
+		}
+		switch {
+		case isClosing(runes[i]):
+			depth++
+		case brackets[runes[i]] != 0:
+			if depth == 0 {
+				return i, brackets[runes[i]] == closing
+			}
+			depth--
+		}
+	}
+	return 0, false
+}
+
+// MatchingBrackets returns the positions of the bracket at the cursor, or
+// else just before it, and of the bracket matching it.
+func (m Model) MatchingBrackets() (int, int, bool) {
+	runes := m.runes()
+	inside := quoted(runes)
+	pos := m.Position()
+	for _, at := range []int{pos, pos - 1} {
+		if at < 0 || at >= len(runes) || inside[at] {
+			continue
+		}
+		if match, ok := matchBracket(runes, inside, at); ok {
+			return at, match, true
+		}
+	}
+	return 0, 0, false
+}
+
+// matchBracket returns the position of the bracket matching the one at.
+func matchBracket(runes []rune, inside []bool, at int) (int, bool) {
+	r := runes[at]
+	step, open, closing := 1, r, brackets[r]
+	if closing == 0 {
+		if !isClosing(r) {
+			return 0, false
+		}
+		step, closing = -1, r
+		for o, c := range brackets {
+			if c == r {
+				open = o
+			}
+		}
+	}
+
+	depth := 0
+	for i := at; i >= 0 && i < len(runes); i += step {
+		if inside[i] {
+			continue
+		}
+		switch runes[i] {
+		case open, closing:
+			if (runes[i] == open) == (step == 1) {
+				depth++
+			} else {
+				depth--
+			}
+			if depth == 0 {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// firstRow returns the first line shown, scrolled so the cursor is visible.
+func (m Model) firstRow() int {
+	return max(0, m.row-m.Height()+1)
+}
+
+// firstColumn returns the first column shown, scrolled so the cursor is visible.
+func (m Model) firstColumn() int {
+	if m.Width <= 0 {
+		return 0
+	}
+	return max(0, m.col-m.Width+1)
+}
+
+// ScreenPosition returns the line and column the rune index pos of Value
+// is shown at, relative to the first line shown and the end of the prompt.
+// Positions scrolled out of view are outside 0..Height()-1 and 0..Width-1.
+func (m Model) ScreenPosition(pos int) (int, int) {
+	row := m.rowOf(pos)
+	col := pos
+	for _, line := range m.lines[:row] {
+		col -= len(line) + 1
+	}
+	return row - m.firstRow(), col - m.firstColumn()
+}
+
+// View renders the lines shown, with the cursor.
+func (m Model) View() string {
+	promptWidth := lipgloss.Width(m.Prompt)
+	if len(m.lines) == 1 && len(m.lines[0]) == 0 && m.Placeholder != "" {
+		return m.placeholderView()
+	}
+
+	value := m.Value()
+	var styles []*lipgloss.Style
+	if m.Highlight != nil {
+		styles = m.Highlight(value)
+	}
+	runeStyles := make([]*lipgloss.Style, 0, len(value))
+	for i := range value {
+		var style *lipgloss.Style
+		if i < len(styles) {
+			style = styles[i]
+		}
+		runeStyles = append(runeStyles, style)
+	}
+	if m.focus {
+		if a, b, ok := m.MatchingBrackets(); ok {
+			runeStyles[a], runeStyles[b] = &m.MatchStyle, &m.MatchStyle
+		}
+	}
+
+	first, firstCol := m.firstRow(), m.firstColumn()
+	start := 0 // Rune index of the line in Value
+	for _, line := range m.lines[:first] {
+		start += len(line) + 1
+	}
+
+	rows := make([]string, 0, m.Height())
+	for row := first; row < first+m.Height(); row++ {
+		line := m.lines[row]
+		end := len(line)
+		if m.Width > 0 {
+			end = min(end, firstCol+m.Width)
+		}
+
+		var s strings.Builder
+		if row == 0 {
+			s.WriteString(m.PromptStyle.Render(m.Prompt))
+		} else {
+			s.WriteString(strings.Repeat(" ", promptWidth))
+		}
+		for col := firstCol; col < end; {
+			if row == m.row && col == m.col {
+				m.Cursor.SetChar(string(line[col]))
+				s.WriteString(m.Cursor.View())
+				col++
+				continue
+			}
+			// Render runs of runes sharing a style at once
+			next := col + 1
+			for next < end && runeStyles[start+next] == runeStyles[start+col] && (row != m.row || next != m.col) {
+				next++
+			}
+			text := string(line[col:next])
+			if style := runeStyles[start+col]; style != nil {
+				text = style.Render(text)
+			}
+			s.WriteString(text)
+			col = next
+		}
+		width := max(0, end-firstCol)
+		if row == m.row && m.col >= end {
+			m.Cursor.SetChar(" ")
+			s.WriteString(m.Cursor.View())
+			width++
+		}
+		if m.Width > 0 && width < m.Width {
+			s.WriteString(strings.Repeat(" ", m.Width-width))
+		}
+
+		rows = append(rows, s.String())
+		start += len(line) + 1
+	}
+	return strings.Join(rows, "\n")
+}
+
+// placeholderView renders the placeholder with the cursor on its first rune.
+func (m Model) placeholderView() string {
+	placeholder := []rune(m.Placeholder)
+	m.Cursor.TextStyle = m.PlaceholderStyle
+	m.Cursor.SetChar(string(placeholder[0]))
+	view := m.PromptStyle.Render(m.Prompt) + m.Cursor.View() + m.PlaceholderStyle.Render(string(placeholder[1:]))
+	if m.Width > 0 {
+		view = ansi.Truncate(view, lipgloss.Width(m.Prompt)+m.Width, "")
+	}
+	return view
+}
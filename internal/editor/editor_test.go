@@ -0,0 +1,154 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func newEditor() Model {
+	m := New()
+	m.Cursor.SetMode(cursor.CursorStatic)
+	m.Focus()
+	return m
+}
+
+func typeKeys(m Model, keys ...any) Model {
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		switch k := k.(type) {
+		case string:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		case tea.KeyType:
+			msg = tea.KeyMsg{Type: k}
+		case tea.KeyMsg:
+			msg = k
+		}
+		m, _ = m.Update(msg)
+	}
+	return m
+}
+
+var altEnter = tea.KeyMsg{Type: tea.KeyEnter, Alt: true}
+
+func TestAutoIndent(t *testing.T) {
+	m := typeKeys(newEditor(), "sum(", altEnter, "rate(", altEnter, "up[5m]", altEnter, ")", altEnter, ")")
+	want := "sum(\n  rate(\n    up[5m]\n  )\n)"
+	if got := m.Value(); got != want {
+		t.Errorf("Value() = %q, want %q", got, want)
+	}
+
+	// Between brackets, the closing one moves to a line of its own
+	m = newEditor()
+	m.SetValue("sum by (job) ()")
+	m = typeKeys(m, tea.KeyLeft, altEnter, "up")
+	if got, want := m.Value(), "sum by (job) (\n  up\n)"; got != want {
+		t.Errorf("Value() = %q, want %q", got, want)
+	}
+	if m.Line() != 1 {
+		t.Errorf("cursor on line %d, want 1", m.Line())
+	}
+
+	// Closing brackets align with their opening ones, which aren't in strings
+	m = newEditor()
+	m.SetValue("count(\n  {job=\"(\"}\n    ")
+	m = typeKeys(m, ")")
+	if got, want := m.Value(), "count(\n  {job=\"(\"}\n)"; got != want {
+		t.Errorf("Value() = %q, want %q", got, want)
+	}
+}
+
+func TestCursorMovement(t *testing.T) {
+	m := newEditor()
+	m.SetValue("rate(\n  up[5m]\n)")
+	if m.Position() != len([]rune(m.Value())) || m.Line() != 2 {
+		t.Fatalf("SetValue left the cursor at %d on line %d, want the end", m.Position(), m.Line())
+	}
+
+	m = typeKeys(m, tea.KeyUp, tea.KeyEnd)
+	if m.Position() != strings.Index(m.Value(), "]")+1 {
+		t.Errorf("cursor at %d, want the end of the second line", m.Position())
+	}
+	m = typeKeys(m, tea.KeyUp, tea.KeyUp)
+	if m.Line() != 0 || m.Position() != len("rate(") {
+		t.Errorf("cursor at %d on line %d, want clamped to the end of the first line", m.Position(), m.Line())
+	}
+	m = typeKeys(m, tea.KeyRight)
+	if m.Line() != 1 || m.Position() != len("rate(\n") {
+		t.Errorf("cursor at %d, want wrapped to the second line", m.Position())
+	}
+
+	for pos := range len(m.Value()) + 1 {
+		m.SetCursor(pos)
+		if m.Position() != pos {
+			t.Errorf("SetCursor(%d) moved to %d", pos, m.Position())
+		}
+	}
+
+	m = typeKeys(m, tea.KeyCtrlW)
+	if got, want := m.Value(), "rate(\n  up["; got != want {
+		t.Errorf("Value() after deleting a word = %q, want %q", got, want)
+	}
+	m = typeKeys(m, tea.KeyBackspace, tea.KeyBackspace, tea.KeyCtrlU)
+	if got, want := m.Value(), "rate(\n"; got != want {
+		t.Errorf("Value() after deleting = %q, want %q", got, want)
+	}
+
+	m = typeKeys(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a\r\nb\tc"), Paste: true})
+	if got, want := m.Value(), "rate(\na\nb c"; got != want {
+		t.Errorf("Value() after pasting = %q, want %q", got, want)
+	}
+}
+
+func TestMatchingBrackets(t *testing.T) {
+	m := newEditor()
+	m.SetValue(`sum(rate(up{job=")"}[5m]))`)
+
+	tests := []struct {
+		pos, open, closing int
+	}{
+		{3, 3, 25},  // At an opening bracket
+		{26, 3, 25}, // Just after a closing one
+		{9, 8, 24},  // After an opening one
+		{11, 11, 19},
+	}
+	for _, tt := range tests {
+		m.SetCursor(tt.pos)
+		a, b, ok := m.MatchingBrackets()
+		if !ok || min(a, b) != tt.open || max(a, b) != tt.closing {
+			t.Errorf("MatchingBrackets() at %d = %d, %d, %v, want %d and %d", tt.pos, a, b, ok, tt.open, tt.closing)
+		}
+	}
+
+	m.SetCursor(1)
+	if _, _, ok := m.MatchingBrackets(); ok {
+		t.Error("brackets matched away from any bracket")
+	}
+}
+
+func TestView(t *testing.T) {
+	m := newEditor()
+	m.Placeholder = "Enter PromQL query..."
+	if got := ansi.Strip(m.View()); got != "> Enter PromQL query..." {
+		t.Errorf("View() = %q, want the placeholder", got)
+	}
+
+	m.SetValue("sum(\n  rate(\n    http_requests_total[5m]\n  )\n)")
+	m.MaxHeight = 3
+	m.Width = 10
+	m.SetCursor(strings.Index(m.Value(), "total"))
+
+	lines := strings.Split(ansi.Strip(m.View()), "\n")
+	want := []string{">           ", "            ", "  requests_t"}
+	if m.Height() != 3 || strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("View() = %q, want the three lines around the cursor scrolled to it: %q", lines, want)
+	}
+
+	row, col := m.ScreenPosition(strings.Index(m.Value(), "total"))
+	if row != 2 || col != 9 {
+		t.Errorf("ScreenPosition() = %d, %d, want 2, 9", row, col)
+	}
+}
@@ -0,0 +1,212 @@
+// Package history persists the queries run in peat, so they can be recalled
+// and searched in later sessions.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Entry is a query that was run. History files are JSON Lines files with one
+// entry per line, oldest first.
+type Entry struct {
+	Time       time.Time     `json:"time"`
+	Mode       string        `json:"mode"` // Endpoint of the query mode, e.g. /query_range
+	Datasource string        `json:"datasource,omitempty"`
+	Query      string        `json:"query"`
+	Duration   time.Duration `json:"duration"`
+	ResultSize int           `json:"resultSize"` // Series, values or rows returned
+}
+
+// sameQuery reports whether e and other are the same query run in the same
+// mode against the same datasource. Only the latest run of a query is kept.
+func (e Entry) sameQuery(other Entry) bool {
+	return e.Mode == other.Mode && e.Datasource == other.Datasource && e.Query == other.Query
+}
+
+// Store is a query history backed by a file. It is safe for concurrent use.
+type Store struct {
+	mu      sync.Mutex
+	path    string
+	limit   int
+	entries []Entry // Oldest first, without duplicates
+	lines   int     // Number of lines in the file
+	err     error   // First write error, reported by Err
+}
+
+// Open reads the history at path, keeping the latest limit entries. A missing
+// file is an empty history. Lines that can't be parsed, such as one cut short
+// by a crash, are dropped.
+func Open(path string, limit int) (*Store, error) {
+	s := &Store{path: path, limit: max(limit, 1)}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening history: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		s.lines++
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.Query == "" {
+			continue
+		}
+		s.add(e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading history: %w", err)
+	}
+
+	// Drop the duplicates and the entries beyond the limit from the file
+	if s.lines > len(s.entries) {
+		if err := s.rewrite(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Err returns the first error that occurred while writing the history.
+func (s *Store) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Entries returns the entries of the history, newest first.
+func (s *Store) Entries() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := slices.Clone(s.entries)
+	slices.Reverse(entries)
+	return entries
+}
+
+// Add appends e to the history, replacing an earlier run of the same query.
+// Writing stops at the first error.
+func (s *Store) Add(e Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.add(e)
+	if s.err != nil {
+		return
+	}
+
+	// Replaced and dropped entries stay in the file until it grows to twice
+	// the limit, so most queries only append a line.
+	if s.lines+1 > 2*s.limit {
+		s.err = s.rewrite()
+		return
+	}
+	s.err = s.append(e)
+}
+
+func (s *Store) add(e Entry) {
+	s.entries = slices.DeleteFunc(s.entries, e.sameQuery)
+	s.entries = append(s.entries, e)
+	if len(s.entries) > s.limit {
+		s.entries = slices.Delete(s.entries, 0, len(s.entries)-s.limit)
+	}
+}
+
+func (s *Store) append(e Entry) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("writing history: %w", err)
+	}
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("writing history: %w", err)
+	}
+	defer f.Close()
+	if err := json.NewEncoder(f).Encode(e); err != nil {
+		return fmt.Errorf("writing history: %w", err)
+	}
+	s.lines++
+	return nil
+}
+
+// rewrite replaces the file with the entries of the history. The file is
+// renamed into place, so a crash never leaves it half written.
+func (s *Store) rewrite() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("writing history: %w", err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("writing history: %w", err)
+	}
+	defer os.Remove(f.Name()) // Fails once renamed
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, e := range s.entries {
+		if err := enc.Encode(e); err != nil {
+			f.Close()
+			return fmt.Errorf("writing history: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("writing history: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing history: %w", err)
+	}
+	if err := os.Rename(f.Name(), s.path); err != nil {
+		return fmt.Errorf("writing history: %w", err)
+	}
+	s.lines = len(s.entries)
+	return nil
+}
+
+// Match reports whether the runes of pattern appear in order in s, ignoring
+// case, and scores the match: runes matched at the start of a word or right
+// after the previous match score higher. It also returns the rune indexes of
+// s that were matched.
+func Match(pattern, s string) (score int, positions []int, ok bool) {
+	want := []rune(pattern)
+	if len(want) == 0 {
+		return 0, nil, true
+	}
+
+	runes := []rune(s)
+	next := 0
+	for i, r := range runes {
+		if unicode.ToLower(r) != unicode.ToLower(want[next]) {
+			continue
+		}
+		score++
+		switch {
+		case len(positions) > 0 && positions[len(positions)-1] == i-1:
+			score += 2
+		case i == 0 || !isWordRune(runes[i-1]):
+			score += 3
+		}
+		positions = append(positions, i)
+		if next++; next == len(want) {
+			// Shorter queries are more likely the one searched for
+			return score*100 - len(runes), positions, true
+		}
+	}
+	return 0, nil, false
+}
+
+// isWordRune reports whether r is part of a word. Underscores separate the
+// words of metric and label names.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,115 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func entry(mode, query string) Entry {
+	return Entry{Time: time.Unix(1700000000, 0).UTC(), Mode: mode, Datasource: "prod", Query: query, Duration: 20 * time.Millisecond, ResultSize: 3}
+}
+
+func queries(entries []Entry) string {
+	var qs []string
+	for _, e := range entries {
+		qs = append(qs, e.Mode+" "+e.Query)
+	}
+	return strings.Join(qs, ", ")
+}
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "peat", "history.jsonl")
+
+	s, err := Open(path, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Entries()) != 0 {
+		t.Fatalf("Entries() of a missing file = %v, want none", s.Entries())
+	}
+
+	s.Add(entry("/query", "up"))
+	s.Add(entry("/query", "rate(x[5m])"))
+	s.Add(entry("/query_range", "up"))
+	s.Add(entry("/query", "up"))
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := queries(s.Entries()), "/query up, /query_range up, /query rate(x[5m])"; got != want {
+		t.Errorf("Entries() = %s, want %s", got, want)
+	}
+
+	s.Add(entry("/series", "node_load1"))
+	if got, want := queries(s.Entries()), "/series node_load1, /query up, /query_range up"; got != want {
+		t.Errorf("Entries() beyond the limit = %s, want %s", got, want)
+	}
+
+	// The file holds every run until it is compacted on the next start
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 5 {
+		t.Errorf("history file has %d lines, want 5", lines)
+	}
+	if err := os.WriteFile(path, append(data, `{"query": "cut sh`...), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = Open(path, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := s.Entries()
+	if got, want := queries(entries), "/series node_load1, /query up, /query_range up"; got != want {
+		t.Errorf("Entries() after reopening = %s, want %s", got, want)
+	}
+	if entries[0] != entry("/series", "node_load1") {
+		t.Errorf("Entries()[0] = %+v, want all fields kept", entries[0])
+	}
+	data, _ = os.ReadFile(path)
+	if lines := strings.Count(string(data), "\n"); lines != 3 {
+		t.Errorf("history file has %d lines after reopening, want it compacted to 3", lines)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		ok         bool
+		positions  []int
+	}{
+		{"", "up", true, nil},
+		{"rt", "rate(x)", true, []int{0, 2}},
+		{"HTTP", "sum(http_requests)", true, []int{4, 5, 6, 7}},
+		{"ur", "up", false, nil},
+	}
+	for _, tt := range tests {
+		_, positions, ok := Match(tt.pattern, tt.s)
+		if ok != tt.ok || len(positions) != len(tt.positions) {
+			t.Errorf("Match(%q, %q) = %v, %v, want %v, %v", tt.pattern, tt.s, positions, ok, tt.positions, tt.ok)
+			continue
+		}
+		for i := range positions {
+			if positions[i] != tt.positions[i] {
+				t.Errorf("Match(%q, %q) positions = %v, want %v", tt.pattern, tt.s, positions, tt.positions)
+				break
+			}
+		}
+	}
+
+	// Consecutive runes and word starts rank higher, then shorter strings
+	words, _, _ := Match("req", "sum(http_requests_total)")
+	scattered, _, _ := Match("req", "rate(quantile_errors)")
+	if words <= scattered {
+		t.Errorf("score of a word match %d <= score of a scattered one %d", words, scattered)
+	}
+	short, _, _ := Match("up", "up")
+	long, _, _ := Match("up", "up == 0")
+	if short <= long {
+		t.Errorf("score of a short match %d <= score of a longer one %d", short, long)
+	}
+}
@@ -0,0 +1,109 @@
+// Package library stores named queries in YAML files, so a team can keep its
+// queries next to its runbooks and review changes to them.
+package library
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/common/model"
+	"go.yaml.in/yaml/v3"
+)
+
+// Query is a saved query with the parameters it is run with.
+type Query struct {
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description,omitempty"`
+	Tags        []string `yaml:"tags,omitempty,flow"`
+
+	// Mode is the endpoint of the query mode, e.g. /query_range.
+	Mode  string `yaml:"mode"`
+	Query string `yaml:"query"`
+
+	// Range and Step are the parameters of range queries, kept when non-zero.
+	// They are written like PromQL durations, e.g. 6h or 1d.
+	Range model.Duration `yaml:"range,omitempty"`
+	Step  model.Duration `yaml:"step,omitempty"`
+}
+
+// file is the contents of a library file.
+type file struct {
+	Queries []Query `yaml:"queries"`
+}
+
+// Load reads the queries of the library at path, in file order.
+// A missing file yields no queries and an error wrapping os.ErrNotExist.
+func Load(path string) ([]Query, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f file
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	for i, q := range f.Queries {
+		if strings.TrimSpace(q.Name) == "" {
+			return nil, fmt.Errorf("%s: query %d has no name", path, i+1)
+		}
+		if strings.TrimSpace(q.Query) == "" {
+			return nil, fmt.Errorf("%s: query %q is empty", path, q.Name)
+		}
+	}
+	return f.Queries, nil
+}
+
+// Save adds q to the library at path, replacing the query of the same name.
+// The file and its directory are created if needed.
+func Save(path string, q Query) error {
+	queries, err := Load(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if i := slices.IndexFunc(queries, func(saved Query) bool { return saved.Name == q.Name }); i >= 0 {
+		queries[i] = q
+	} else {
+		queries = append(queries, q)
+	}
+
+	b, err := yaml.Marshal(file{Queries: queries})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("saving query: %w", err)
+	}
+	// Write next to the file and rename, so a failed write keeps the library
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("saving query: %w", err)
+	}
+	defer os.Remove(f.Name()) // Fails once renamed
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return fmt.Errorf("saving query: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("saving query: %w", err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("saving query: %w", err)
+	}
+	return nil
+}
+
+// Matches reports whether every word of filter is found in the name,
+// description, tags, mode or query of q, ignoring case.
+func (q Query) Matches(filter string) bool {
+	text := strings.ToLower(strings.Join(append([]string{q.Name, q.Description, q.Mode, q.Query}, q.Tags...), "\n"))
+	for _, word := range strings.Fields(strings.ToLower(filter)) {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package library
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "peat", "queries.yaml")
+	if _, err := Load(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Load() of a missing file error = %v, want os.ErrNotExist", err)
+	}
+
+	errorRatio := Query{
+		Name:        "API error ratio",
+		Description: "5xx responses over all responses",
+		Tags:        []string{"api", "slo"},
+		Mode:        "/query_range",
+		Query:       "sum(rate(http_requests_total{code=~\"5..\"}[5m]))\n/\nsum(rate(http_requests_total[5m]))",
+		Range:       model.Duration(6 * time.Hour),
+		Step:        model.Duration(time.Minute),
+	}
+	up := Query{Name: "Targets down", Mode: "/query", Query: "up == 0"}
+	for _, q := range []Query{errorRatio, up} {
+		if err := Save(path, q); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Saving under an existing name replaces the query in place
+	errorRatio.Range = model.Duration(24 * time.Hour)
+	if err := Save(path, errorRatio); err != nil {
+		t.Fatal(err)
+	}
+
+	queries, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) != 2 || queries[0].Name != errorRatio.Name || queries[1].Name != up.Name {
+		t.Fatalf("Load() = %+v, want the two queries in the order they were first saved", queries)
+	}
+	if got := queries[0]; got.Range != errorRatio.Range || got.Step != errorRatio.Step || got.Query != errorRatio.Query || strings.Join(got.Tags, ",") != "api,slo" {
+		t.Errorf("Load()[0] = %+v, want %+v", got, errorRatio)
+	}
+
+	// The file is readable YAML with durations and multi-line queries as written
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"range: 1d", "tags: [api, slo]", "query: |-\n"} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("library file doesn't contain %q:\n%s", want, b)
+		}
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	for _, content := range []string{
+		"queries: [",
+		"queries:\n  - query: up\n",
+		"queries:\n  - name: empty\n",
+	} {
+		path := filepath.Join(t.TempDir(), "queries.yaml")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("Load(%q) error = nil, want error", content)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	q := Query{Name: "API error ratio", Tags: []string{"slo"}, Mode: "/query_range", Query: "sum(rate(http_requests_total[5m]))"}
+	for filter, want := range map[string]bool{
+		"":              true,
+		"error":         true,
+		"SLO api":       true,
+		"http_requests": true,
+		"query_range":   true,
+		"latency":       false,
+		"api latency":   false,
+	} {
+		if got := q.Matches(filter); got != want {
+			t.Errorf("Matches(%q) = %v, want %v", filter, got, want)
+		}
+	}
+}
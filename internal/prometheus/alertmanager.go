@@ -0,0 +1,181 @@
+package prometheus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// AlertmanagerClient reads alerts and manages silences using the Alertmanager v2 API.
+type AlertmanagerClient interface {
+	AlertGroups(ctx context.Context, timeout time.Duration) ([]AlertGroup, error)
+	Silences(ctx context.Context, timeout time.Duration) ([]Silence, error)
+	CreateSilence(ctx context.Context, silence Silence, timeout time.Duration) (string, error)
+	ExpireSilence(ctx context.Context, id string, timeout time.Duration) error
+}
+
+// AlertGroup is a group of alerts routed to the same receiver.
+type AlertGroup struct {
+	Labels   map[string]string `json:"labels"`
+	Receiver Receiver          `json:"receiver"`
+	Alerts   []Alert           `json:"alerts"`
+}
+
+// Receiver is the name of an Alertmanager receiver.
+type Receiver struct {
+	Name string `json:"name"`
+}
+
+// Alert is an alert known to the Alertmanager.
+type Alert struct {
+	Fingerprint  string            `json:"fingerprint"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	Status       AlertStatus       `json:"status"`
+	GeneratorURL string            `json:"generatorURL"`
+}
+
+// AlertStatus is the state of an alert and the silences and alerts suppressing it.
+type AlertStatus struct {
+	State       string   `json:"state"` // unprocessed, active or suppressed
+	SilencedBy  []string `json:"silencedBy"`
+	InhibitedBy []string `json:"inhibitedBy"`
+}
+
+// Silence mutes the alerts matching all of its matchers between StartsAt and EndsAt.
+type Silence struct {
+	ID        string        `json:"id,omitempty"`
+	Matchers  []Matcher     `json:"matchers"`
+	StartsAt  time.Time     `json:"startsAt"`
+	EndsAt    time.Time     `json:"endsAt"`
+	CreatedBy string        `json:"createdBy"`
+	Comment   string        `json:"comment"`
+	Status    SilenceStatus `json:"status,omitzero"`
+}
+
+// SilenceStatus is the state of a silence: pending, active or expired.
+type SilenceStatus struct {
+	State string `json:"state"`
+}
+
+// Matcher matches the value of an alert label.
+type Matcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual bool   `json:"isEqual"`
+}
+
+// String formats the matcher like a PromQL label matcher, e.g. job=~"api.*".
+func (m Matcher) String() string {
+	op := "="
+	switch {
+	case m.IsRegex && m.IsEqual:
+		op = "=~"
+	case m.IsRegex:
+		op = "!~"
+	case !m.IsEqual:
+		op = "!="
+	}
+	return fmt.Sprintf("%s%s%q", m.Name, op, m.Value)
+}
+
+type alertmanagerClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewAlertmanagerClient creates a client for the Alertmanager at cfg.URL.
+func NewAlertmanagerClient(cfg Config) (AlertmanagerClient, error) {
+	if _, err := url.Parse(cfg.URL); err != nil {
+		return nil, fmt.Errorf("invalid alertmanager url: %w", err)
+	}
+	roundTripper, err := cfg.roundTripper()
+	if err != nil {
+		return nil, err
+	}
+	return &alertmanagerClient{
+		baseURL: strings.TrimRight(cfg.URL, "/") + "/api/v2",
+		client:  &http.Client{Transport: roundTripper},
+	}, nil
+}
+
+// AlertGroups returns the active, silenced and inhibited alerts grouped by route.
+func (c *alertmanagerClient) AlertGroups(ctx context.Context, timeout time.Duration) ([]AlertGroup, error) {
+	var groups []AlertGroup
+	err := c.do(ctx, http.MethodGet, "/alerts/groups", nil, &groups, timeout)
+	return groups, err
+}
+
+// Silences returns all silences, including expired ones.
+func (c *alertmanagerClient) Silences(ctx context.Context, timeout time.Duration) ([]Silence, error) {
+	var silences []Silence
+	err := c.do(ctx, http.MethodGet, "/silences", nil, &silences, timeout)
+	return silences, err
+}
+
+// CreateSilence creates a silence and returns its ID.
+func (c *alertmanagerClient) CreateSilence(ctx context.Context, silence Silence, timeout time.Duration) (string, error) {
+	var result struct {
+		SilenceID string `json:"silenceID"`
+	}
+	err := c.do(ctx, http.MethodPost, "/silences", silence, &result, timeout)
+	return result.SilenceID, err
+}
+
+// ExpireSilence expires the silence with the given ID.
+func (c *alertmanagerClient) ExpireSilence(ctx context.Context, id string, timeout time.Duration) error {
+	return c.do(ctx, http.MethodDelete, "/silence/"+url.PathEscape(id), nil, nil, timeout)
+}
+
+// do sends a request with an optional JSON body and decodes the JSON
+// response into result, unless result is nil.
+func (c *alertmanagerClient) do(ctx context.Context, method, path string, body, result any, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		if len(bytes.TrimSpace(msg)) == 0 {
+			return fmt.Errorf("alertmanager: %s", resp.Status)
+		}
+		return fmt.Errorf("alertmanager: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("alertmanager: decoding response: %w", err)
+	}
+	return nil
+}
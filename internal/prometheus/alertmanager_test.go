@@ -0,0 +1,119 @@
+package prometheus_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akasprzok/peat/internal/prometheus"
+	"github.com/akasprzok/peat/internal/prometheus/prometheustest"
+)
+
+func TestAlertmanagerClient(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	fake := prometheustest.NewAlertmanager(
+		[]prometheus.AlertGroup{{
+			Labels:   map[string]string{"alertname": "HighLatency"},
+			Receiver: prometheus.Receiver{Name: "team-a"},
+			Alerts: []prometheus.Alert{{
+				Labels:   map[string]string{"alertname": "HighLatency", "job": "api"},
+				StartsAt: now.Add(-time.Hour),
+				Status:   prometheus.AlertStatus{State: "active"},
+			}},
+		}},
+		[]prometheus.Silence{{
+			ID:       "existing-silence",
+			Matchers: []prometheus.Matcher{{Name: "job", Value: "batch", IsEqual: true}},
+			StartsAt: now.Add(-time.Hour),
+			EndsAt:   now.Add(time.Hour),
+			Status:   prometheus.SilenceStatus{State: "active"},
+		}},
+	)
+	defer fake.Close()
+
+	client, err := prometheus.NewAlertmanagerClient(prometheus.Config{
+		URL:  fake.URL + "/",
+		Auth: prometheus.AuthConfig{BearerToken: "secret"},
+	})
+	if err != nil {
+		t.Fatalf("prometheus.NewAlertmanagerClient() error = %v", err)
+	}
+	ctx := context.Background()
+
+	groups, err := client.AlertGroups(ctx, time.Second)
+	if err != nil {
+		t.Fatalf("AlertGroups() error = %v", err)
+	}
+	if len(groups) != 1 || groups[0].Receiver.Name != "team-a" || len(groups[0].Alerts) != 1 {
+		t.Fatalf("AlertGroups() = %+v", groups)
+	}
+	if got := fake.Requests()[0].Header.Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("Authorization = %q, want the bearer token", got)
+	}
+
+	id, err := client.CreateSilence(ctx, prometheus.Silence{
+		Matchers:  []prometheus.Matcher{{Name: "alertname", Value: "HighLatency", IsEqual: true}, {Name: "job", Value: "api.*", IsRegex: true, IsEqual: true}},
+		StartsAt:  now,
+		EndsAt:    now.Add(2 * time.Hour),
+		CreatedBy: "alice",
+		Comment:   "deploying",
+	}, time.Second)
+	if err != nil {
+		t.Fatalf("CreateSilence() error = %v", err)
+	}
+	silences, err := client.Silences(ctx, time.Second)
+	if err != nil {
+		t.Fatalf("Silences() error = %v", err)
+	}
+	if len(silences) != 2 || silences[1].ID != id || silences[1].Status.State != "active" {
+		t.Fatalf("Silences() = %+v, want the created silence", silences)
+	}
+	if got := formatMatchersForTest(silences[1].Matchers); got != `alertname="HighLatency", job=~"api.*"` {
+		t.Errorf("matchers = %s", got)
+	}
+
+	if err := client.ExpireSilence(ctx, id, time.Second); err != nil {
+		t.Fatalf("ExpireSilence() error = %v", err)
+	}
+	if state := fake.Silences()[1].Status.State; state != "expired" {
+		t.Errorf("state after ExpireSilence() = %q, want expired", state)
+	}
+
+	t.Run("errors include the response body", func(t *testing.T) {
+		err := client.ExpireSilence(ctx, "missing", time.Second)
+		if err == nil || !strings.Contains(err.Error(), "404") || !strings.Contains(err.Error(), "silence not found") {
+			t.Errorf("ExpireSilence() error = %v, want a 404 with the server's message", err)
+		}
+		_, err = client.CreateSilence(ctx, prometheus.Silence{Matchers: []prometheus.Matcher{{Name: "job", Value: "api", IsEqual: true}}}, time.Second)
+		if err == nil || !strings.Contains(err.Error(), "400") {
+			t.Errorf("CreateSilence() error = %v, want a 400", err)
+		}
+	})
+}
+
+func TestMatcherString(t *testing.T) {
+	tests := []struct {
+		matcher prometheus.Matcher
+		want    string
+	}{
+		{prometheus.Matcher{Name: "job", Value: "api", IsEqual: true}, `job="api"`},
+		{prometheus.Matcher{Name: "job", Value: "api"}, `job!="api"`},
+		{prometheus.Matcher{Name: "job", Value: "api.*", IsRegex: true, IsEqual: true}, `job=~"api.*"`},
+		{prometheus.Matcher{Name: "job", Value: "api.*", IsRegex: true}, `job!~"api.*"`},
+	}
+
+	for _, tt := range tests {
+		if got := tt.matcher.String(); got != tt.want {
+			t.Errorf("String() = %s, want %s", got, tt.want)
+		}
+	}
+}
+
+func formatMatchersForTest(matchers []prometheus.Matcher) string {
+	parts := make([]string, len(matchers))
+	for i, m := range matchers {
+		parts[i] = m.String()
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,97 @@
+package prometheus
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// AuthConfig holds the credentials and extra headers sent with every request.
+type AuthConfig struct {
+	BasicAuthUser         string
+	BasicAuthPassword     string
+	BasicAuthPasswordFile string
+	BearerToken           string
+	BearerTokenFile       string
+	// Headers are added to every request, e.g. X-Scope-OrgID for multi-tenant Mimir/Cortex.
+	Headers map[string]string
+}
+
+// Validate reports conflicting authentication settings.
+func (a AuthConfig) Validate() error {
+	if a.BasicAuthPassword != "" && a.BasicAuthPasswordFile != "" {
+		return errors.New("basic auth password and password file are mutually exclusive")
+	}
+	if a.BearerToken != "" && a.BearerTokenFile != "" {
+		return errors.New("bearer token and bearer token file are mutually exclusive")
+	}
+	if a.BasicAuthUser != "" && (a.BearerToken != "" || a.BearerTokenFile != "") {
+		return errors.New("basic auth and bearer token are mutually exclusive")
+	}
+	if a.BasicAuthUser == "" && (a.BasicAuthPassword != "" || a.BasicAuthPasswordFile != "") {
+		return errors.New("basic auth password requires a basic auth user")
+	}
+	return nil
+}
+
+func (a AuthConfig) isZero() bool {
+	return a.BasicAuthUser == "" && a.BearerToken == "" && a.BearerTokenFile == "" && len(a.Headers) == 0
+}
+
+// authRoundTripper adds authentication and custom headers to outgoing requests.
+// Secret files are read on every request so rotated credentials are picked up
+// without restarting.
+type authRoundTripper struct {
+	auth AuthConfig
+	next http.RoundTripper
+}
+
+func newAuthRoundTripper(auth AuthConfig, next http.RoundTripper) http.RoundTripper {
+	return &authRoundTripper{auth: auth, next: next}
+}
+
+func (rt *authRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers must not modify the caller's request.
+	req = req.Clone(req.Context())
+
+	for name, value := range rt.auth.Headers {
+		req.Header.Set(name, value)
+	}
+
+	if rt.auth.BasicAuthUser != "" {
+		password := rt.auth.BasicAuthPassword
+		if rt.auth.BasicAuthPasswordFile != "" {
+			var err error
+			password, err = readSecretFile(rt.auth.BasicAuthPasswordFile)
+			if err != nil {
+				return nil, fmt.Errorf("reading basic auth password: %w", err)
+			}
+		}
+		req.SetBasicAuth(rt.auth.BasicAuthUser, password)
+	}
+
+	token := rt.auth.BearerToken
+	if rt.auth.BearerTokenFile != "" {
+		var err error
+		token, err = readSecretFile(rt.auth.BearerTokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading bearer token: %w", err)
+		}
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return rt.next.RoundTrip(req)
+}
+
+// readSecretFile returns the contents of a credentials file with surrounding whitespace removed.
+func readSecretFile(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
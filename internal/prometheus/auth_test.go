@@ -0,0 +1,140 @@
+package prometheus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newRecordingServer returns a server that answers label name queries and
+// records the headers of the last request it received.
+func newRecordingServer(t *testing.T) (*httptest.Server, *http.Header) {
+	t.Helper()
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":[]}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &got
+}
+
+func TestAuthRoundTripper(t *testing.T) {
+	dir := t.TempDir()
+	passwordFile := filepath.Join(dir, "password")
+	tokenFile := filepath.Join(dir, "token")
+	if err := os.WriteFile(passwordFile, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(tokenFile, []byte("token-1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		auth       AuthConfig
+		wantHeader string
+		wantValue  string
+	}{
+		{
+			name:       "basic auth with inline password",
+			auth:       AuthConfig{BasicAuthUser: "admin", BasicAuthPassword: "pw"},
+			wantHeader: "Authorization",
+			wantValue:  "Basic YWRtaW46cHc=",
+		},
+		{
+			name:       "basic auth with password file",
+			auth:       AuthConfig{BasicAuthUser: "admin", BasicAuthPasswordFile: passwordFile},
+			wantHeader: "Authorization",
+			wantValue:  "Basic YWRtaW46czNjcmV0",
+		},
+		{
+			name:       "inline bearer token",
+			auth:       AuthConfig{BearerToken: "abc"},
+			wantHeader: "Authorization",
+			wantValue:  "Bearer abc",
+		},
+		{
+			name:       "bearer token file",
+			auth:       AuthConfig{BearerTokenFile: tokenFile},
+			wantHeader: "Authorization",
+			wantValue:  "Bearer token-1",
+		},
+		{
+			name:       "custom header",
+			auth:       AuthConfig{Headers: map[string]string{"X-Scope-OrgID": "tenant-a"}},
+			wantHeader: "X-Scope-OrgID",
+			wantValue:  "tenant-a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, got := newRecordingServer(t)
+			client, err := NewClient(Config{URL: srv.URL, Auth: tt.auth})
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			if _, _, err := client.LabelNames(context.Background(), nil, time.Now().Add(-time.Hour), time.Now(), time.Second); err != nil {
+				t.Fatalf("LabelNames() error = %v", err)
+			}
+			if v := got.Get(tt.wantHeader); v != tt.wantValue {
+				t.Errorf("header %s = %q, want %q", tt.wantHeader, v, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestBearerTokenFileRotation(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("old"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	srv, got := newRecordingServer(t)
+	client, err := NewClient(Config{URL: srv.URL, Auth: AuthConfig{BearerTokenFile: tokenFile}})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	for _, token := range []string{"old", "new"} {
+		if err := os.WriteFile(tokenFile, []byte(token), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := client.LabelNames(context.Background(), nil, time.Now().Add(-time.Hour), time.Now(), time.Second); err != nil {
+			t.Fatalf("LabelNames() error = %v", err)
+		}
+		if v := got.Get("Authorization"); v != "Bearer "+token {
+			t.Errorf("Authorization = %q, want %q", v, "Bearer "+token)
+		}
+	}
+}
+
+func TestAuthConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		auth    AuthConfig
+		wantErr bool
+	}{
+		{"empty", AuthConfig{}, false},
+		{"basic auth", AuthConfig{BasicAuthUser: "u", BasicAuthPassword: "p"}, false},
+		{"password and password file", AuthConfig{BasicAuthUser: "u", BasicAuthPassword: "p", BasicAuthPasswordFile: "f"}, true},
+		{"token and token file", AuthConfig{BearerToken: "t", BearerTokenFile: "f"}, true},
+		{"basic auth and bearer token", AuthConfig{BasicAuthUser: "u", BearerToken: "t"}, true},
+		{"password without user", AuthConfig{BasicAuthPassword: "p"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.auth.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package prometheus
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/textparse"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/util/annotations"
+)
+
+// Content types of the exposition formats read by NewFileClient
+const (
+	promTextContentType    = "text/plain; version=0.0.4"
+	openMetricsContentType = "application/openmetrics-text; version=1.0.0"
+)
+
+// NewFileClient returns a client evaluating queries locally over the samples
+// of Prometheus text exposition or OpenMetrics files, such as /metrics dumps.
+// Samples without a timestamp are stamped with the time the files are loaded.
+func NewFileClient(paths []string) (Client, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("no metrics files")
+	}
+
+	s := &memStorage{series: make(map[string]*memSeries)}
+	metadata := make(map[string][]v1.Metadata)
+	now := time.Now().UnixMilli()
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.load(b, now, metadata); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+	}
+	s.finish()
+	return newLocalClient(s, metadata, "file"), nil
+}
+
+// memStorage is an in-memory storage.Queryable holding the samples of a few
+// metrics dumps.
+type memStorage struct {
+	series map[string]*memSeries // Keyed by the string form of the labels
+	sorted []*memSeries          // Sorted by labels, built by finish
+}
+
+// memSeries is a series and its samples, sorted by time once loaded.
+type memSeries struct {
+	labels  labels.Labels
+	samples []chunks.Sample
+}
+
+// load adds the samples of an exposition file. Metric metadata is added to
+// metadata. Samples without a timestamp get the timestamp now.
+func (s *memStorage) load(b []byte, now int64, metadata map[string][]v1.Metadata) error {
+	contentType := promTextContentType
+	if bytes.HasSuffix(bytes.TrimRight(b, "\n"), []byte("# EOF")) {
+		contentType = openMetricsContentType
+	}
+	if !bytes.HasSuffix(b, []byte("\n")) {
+		b = append(b, '\n')
+	}
+	p, err := textparse.New(b, contentType, labels.NewSymbolTable(), textparse.ParserOptions{
+		// _created series are stored as start timestamps by Prometheus, not as samples
+		OpenMetricsSkipSTSeries: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Metadata of a metric family is spread over its TYPE, HELP and UNIT lines
+	families := make(map[string]*v1.Metadata)
+	family := func(name []byte) *v1.Metadata {
+		if families[string(name)] == nil {
+			families[string(name)] = &v1.Metadata{Type: v1.MetricTypeUnknown}
+		}
+		return families[string(name)]
+	}
+
+	for {
+		entry, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		var sample fileSample
+		switch entry {
+		case textparse.EntryType:
+			name, typ := p.Type()
+			family(name).Type = v1.MetricType(typ)
+			continue
+		case textparse.EntryHelp:
+			name, help := p.Help()
+			family(name).Help = string(help)
+			continue
+		case textparse.EntryUnit:
+			name, unit := p.Unit()
+			family(name).Unit = string(unit)
+			continue
+		case textparse.EntrySeries:
+			var ts *int64
+			_, ts, sample.f = p.Series()
+			sample.t = timestampOr(ts, now)
+		case textparse.EntryHistogram:
+			_, ts, h, fh := p.Histogram()
+			if h != nil {
+				fh = h.ToFloat(nil)
+			}
+			sample.t, sample.fh = timestampOr(ts, now), fh
+		default:
+			continue
+		}
+
+		var lset labels.Labels
+		p.Labels(&lset)
+		key := lset.String()
+		if s.series[key] == nil {
+			s.series[key] = &memSeries{labels: lset}
+		}
+		s.series[key].samples = append(s.series[key].samples, sample)
+	}
+
+	for name, m := range families {
+		metadata[name] = append(metadata[name], *m)
+	}
+	return nil
+}
+
+func timestampOr(ts *int64, now int64) int64 {
+	if ts != nil {
+		return *ts
+	}
+	return now
+}
+
+// finish sorts the loaded series and their samples. Of several samples with
+// the same timestamp, the last one loaded is kept.
+func (s *memStorage) finish() {
+	s.sorted = make([]*memSeries, 0, len(s.series))
+	for _, series := range s.series {
+		slices.SortStableFunc(series.samples, func(a, b chunks.Sample) int {
+			return cmp.Compare(a.T(), b.T())
+		})
+		deduped := series.samples[:0]
+		for _, sample := range series.samples {
+			if n := len(deduped); n > 0 && deduped[n-1].T() == sample.T() {
+				deduped[n-1] = sample
+				continue
+			}
+			deduped = append(deduped, sample)
+		}
+		series.samples = deduped
+		s.sorted = append(s.sorted, series)
+	}
+	slices.SortFunc(s.sorted, func(a, b *memSeries) int {
+		return labels.Compare(a.labels, b.labels)
+	})
+}
+
+func (s *memStorage) Querier(mint, maxt int64) (storage.Querier, error) {
+	return &memQuerier{storage: s, mint: mint, maxt: maxt}, nil
+}
+
+// memQuerier reads the series of a memStorage with samples between mint and maxt.
+type memQuerier struct {
+	storage    *memStorage
+	mint, maxt int64
+}
+
+// matching returns the series matching all matchers with samples in the querier's range.
+func (q *memQuerier) matching(matchers []*labels.Matcher) []*memSeries {
+	var result []*memSeries
+	for _, series := range q.storage.sorted {
+		if !q.inRange(series) {
+			continue
+		}
+		matches := true
+		for _, m := range matchers {
+			if !m.Matches(series.labels.Get(m.Name)) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			result = append(result, series)
+		}
+	}
+	return result
+}
+
+func (q *memQuerier) inRange(series *memSeries) bool {
+	samples := series.samples
+	return len(samples) > 0 && samples[0].T() <= q.maxt && samples[len(samples)-1].T() >= q.mint
+}
+
+func (q *memQuerier) Select(_ context.Context, _ bool, _ *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	// Series are kept sorted, so the result always is
+	matching := q.matching(matchers)
+	series := make([]storage.Series, len(matching))
+	for i, s := range matching {
+		series[i] = storage.NewListSeries(s.labels, s.samples)
+	}
+	return &listSeriesSet{series: series, i: -1}
+}
+
+func (q *memQuerier) LabelValues(_ context.Context, name string, _ *storage.LabelHints, matchers ...*labels.Matcher) ([]string, annotations.Annotations, error) {
+	var values []string
+	for _, series := range q.matching(matchers) {
+		if value := series.labels.Get(name); value != "" {
+			values = append(values, value)
+		}
+	}
+	slices.Sort(values)
+	return slices.Compact(values), nil, nil
+}
+
+func (q *memQuerier) LabelNames(_ context.Context, _ *storage.LabelHints, matchers ...*labels.Matcher) ([]string, annotations.Annotations, error) {
+	var names []string
+	for _, series := range q.matching(matchers) {
+		series.labels.Range(func(l labels.Label) {
+			names = append(names, l.Name)
+		})
+	}
+	slices.Sort(names)
+	return slices.Compact(names), nil, nil
+}
+
+func (q *memQuerier) Close() error {
+	return nil
+}
+
+// listSeriesSet iterates over a slice of series.
+type listSeriesSet struct {
+	series []storage.Series
+	i      int
+}
+
+func (s *listSeriesSet) Next() bool {
+	s.i++
+	return s.i < len(s.series)
+}
+
+func (s *listSeriesSet) At() storage.Series                { return s.series[s.i] }
+func (s *listSeriesSet) Err() error                        { return nil }
+func (s *listSeriesSet) Warnings() annotations.Annotations { return nil }
+
+// fileSample is a float or native histogram sample read from a file.
+type fileSample struct {
+	t  int64
+	f  float64
+	fh *histogram.FloatHistogram
+}
+
+func (s fileSample) T() int64                      { return s.t }
+func (s fileSample) ST() int64                     { return 0 }
+func (s fileSample) F() float64                    { return s.f }
+func (s fileSample) H() *histogram.Histogram       { return nil }
+func (s fileSample) FH() *histogram.FloatHistogram { return s.fh }
+
+func (s fileSample) Type() chunkenc.ValueType {
+	if s.fh != nil {
+		return chunkenc.ValFloatHistogram
+	}
+	return chunkenc.ValFloat
+}
+
+func (s fileSample) Copy() chunks.Sample {
+	if s.fh != nil {
+		s.fh = s.fh.Copy()
+	}
+	return s
+}
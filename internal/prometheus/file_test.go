@@ -0,0 +1,143 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+)
+
+func writeMetricsFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFileClient(t *testing.T) {
+	text := writeMetricsFile(t, "metrics.txt", `# HELP http_requests_total Total HTTP requests.
+# TYPE http_requests_total counter
+http_requests_total{code="200",method="get"} 10 1700000000000
+http_requests_total{code="200",method="get"} 70 1700000060000
+http_requests_total{code="500",method="get"} 1 1700000000000
+http_requests_total{code="500",method="get"} 4 1700000060000
+`)
+	openMetrics := writeMetricsFile(t, "metrics.om", `# TYPE process_open_fds gauge
+# HELP process_open_fds Number of open file descriptors.
+process_open_fds{job="app"} 42 1700000060.000
+# EOF
+`)
+	client, err := NewFileClient([]string{text, openMetrics})
+	if err != nil {
+		t.Fatalf("NewFileClient: %v", err)
+	}
+
+	ctx := context.Background()
+	at := time.UnixMilli(1700000060000)
+
+	t.Run("instant query", func(t *testing.T) {
+		_, value, stats, err := client.Query(ctx, `sum by (code) (increase(http_requests_total[2m]))`, at, time.Minute, true)
+		if err != nil {
+			t.Fatalf("Query: %v", err)
+		}
+		vector, ok := value.(model.Vector)
+		if !ok || len(vector) != 2 {
+			t.Fatalf("value = %v, want a vector of 2 samples", value)
+		}
+		if stats == nil {
+			t.Error("stats not reported")
+		}
+	})
+
+	t.Run("range query", func(t *testing.T) {
+		matrix, _, _, err := client.QueryRange(ctx, `process_open_fds`, at.Add(-time.Minute), at, 30*time.Second, time.Minute, false)
+		if err != nil {
+			t.Fatalf("QueryRange: %v", err)
+		}
+		if len(matrix) != 1 || matrix[0].Metric["job"] != "app" {
+			t.Fatalf("matrix = %v, want process_open_fds{job=\"app\"}", matrix)
+		}
+		if last := matrix[0].Values[len(matrix[0].Values)-1]; last.Value != 42 {
+			t.Errorf("last value = %v, want 42", last.Value)
+		}
+	})
+
+	t.Run("series", func(t *testing.T) {
+		series, warnings, err := client.Series(ctx, []string{`http_requests_total`}, at.Add(-time.Hour), at, 1, time.Minute)
+		if err != nil {
+			t.Fatalf("Series: %v", err)
+		}
+		if len(series) != 1 || series[0]["code"] != "200" {
+			t.Errorf("series = %v, want the first series only", series)
+		}
+		if len(warnings) != 1 {
+			t.Errorf("warnings = %v, want a truncation warning", warnings)
+		}
+	})
+
+	t.Run("label values", func(t *testing.T) {
+		values, _, err := client.LabelValues(ctx, "code", nil, at.Add(-time.Hour), at, time.Minute)
+		if err != nil {
+			t.Fatalf("LabelValues: %v", err)
+		}
+		if strings.Join(values, ",") != "200,500" {
+			t.Errorf("values = %v, want [200 500]", values)
+		}
+	})
+
+	t.Run("metadata", func(t *testing.T) {
+		metadata, err := client.Metadata(ctx, "", time.Minute)
+		if err != nil {
+			t.Fatalf("Metadata: %v", err)
+		}
+		want := v1.Metadata{Type: v1.MetricTypeGauge, Help: "Number of open file descriptors."}
+		if got := metadata["process_open_fds"]; len(got) != 1 || got[0] != want {
+			t.Errorf("process_open_fds metadata = %v, want %v", got, want)
+		}
+		if got := metadata["http_requests_total"]; len(got) != 1 || got[0].Type != v1.MetricTypeCounter {
+			t.Errorf("http_requests_total metadata = %v, want a counter", got)
+		}
+	})
+
+	t.Run("unavailable", func(t *testing.T) {
+		if _, err := client.Targets(ctx, time.Minute); !errors.Is(err, errNotAvailableLocally) {
+			t.Errorf("Targets error = %v, want %v", err, errNotAvailableLocally)
+		}
+	})
+}
+
+func TestFileClientUntimestamped(t *testing.T) {
+	path := writeMetricsFile(t, "metrics.txt", "up{job=\"app\"} 1")
+	client, err := NewFileClient([]string{path})
+	if err != nil {
+		t.Fatalf("NewFileClient: %v", err)
+	}
+
+	_, value, _, err := client.Query(context.Background(), "up", time.Now(), time.Minute, false)
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if vector, ok := value.(model.Vector); !ok || len(vector) != 1 || vector[0].Value != 1 {
+		t.Errorf("value = %v, want up{job=\"app\"} 1", value)
+	}
+}
+
+func TestFileClientErrors(t *testing.T) {
+	if _, err := NewFileClient(nil); err == nil {
+		t.Error("NewFileClient(nil) succeeded, want an error")
+	}
+
+	path := writeMetricsFile(t, "broken.txt", "up{job=\"app\" 1\n")
+	_, err := NewFileClient([]string{path})
+	if err == nil || !strings.Contains(err.Error(), "broken.txt") {
+		t.Errorf("error = %v, want a parse error naming the file", err)
+	}
+}
@@ -0,0 +1,370 @@
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"slices"
+	"time"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/util/annotations"
+	"github.com/prometheus/prometheus/util/stats"
+)
+
+// errNotAvailableLocally is returned for the server endpoints that have no
+// equivalent when evaluating queries locally.
+var errNotAvailableLocally = errors.New("not available without a Prometheus server")
+
+// localMaxSamples bounds the samples a single local query may load.
+const localMaxSamples = 50_000_000
+
+// localClient evaluates queries in-process with the PromQL engine, over
+// samples read from a storage.Queryable.
+type localClient struct {
+	queryable storage.Queryable
+	engine    *promql.Engine
+	metadata  map[string][]v1.Metadata
+	version   string // Reported as the build version
+	started   time.Time
+}
+
+func newLocalClient(queryable storage.Queryable, metadata map[string][]v1.Metadata, version string) *localClient {
+	engine := promql.NewEngine(promql.EngineOpts{
+		MaxSamples:           localMaxSamples,
+		Timeout:              time.Hour, // Queries are bounded by their context
+		EnableAtModifier:     true,
+		EnableNegativeOffset: true,
+		EnablePerStepStats:   true,
+		NoStepSubqueryIntervalFn: func(int64) int64 {
+			return time.Minute.Milliseconds()
+		},
+	})
+	return &localClient{
+		queryable: queryable,
+		engine:    engine,
+		metadata:  metadata,
+		version:   version,
+		started:   time.Now(),
+	}
+}
+
+func (c *localClient) Query(ctx context.Context, query string, ts time.Time, timeout time.Duration, stats bool) (v1.Warnings, model.Value, *QueryStats, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	q, err := c.engine.NewInstantQuery(ctx, c.queryable, promql.NewPrometheusQueryOpts(stats, 0), query, ts)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return c.exec(ctx, q, stats)
+}
+
+func (c *localClient) QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration, timeout time.Duration, stats bool) (model.Matrix, v1.Warnings, *QueryStats, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	q, err := c.engine.NewRangeQuery(ctx, c.queryable, promql.NewPrometheusQueryOpts(stats, 0), query, start, end, step)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	warnings, value, statistics, err := c.exec(ctx, q, stats)
+	if err != nil {
+		return nil, warnings, nil, err
+	}
+	matrix, ok := value.(model.Matrix)
+	if !ok {
+		return nil, warnings, nil, fmt.Errorf("unexpected result type: %s", value.Type())
+	}
+	return matrix, warnings, statistics, nil
+}
+
+// exec runs q and converts its result to the API client types, with the
+// query's stats if asked for.
+func (c *localClient) exec(ctx context.Context, q promql.Query, stats bool) (v1.Warnings, model.Value, *QueryStats, error) {
+	defer q.Close()
+	result := q.Exec(ctx)
+	warnings := annotationWarnings(result.Warnings, q.String())
+	if result.Err != nil {
+		return warnings, nil, nil, result.Err
+	}
+
+	value, err := toModelValue(result.Value)
+	if err != nil {
+		return warnings, nil, nil, err
+	}
+	if !stats {
+		return warnings, value, nil, nil
+	}
+	return warnings, value, queryStats(q.Stats()), nil
+}
+
+func (c *localClient) Series(ctx context.Context, matches []string, start, end time.Time, limit uint64, timeout time.Duration) ([]model.LabelSet, v1.Warnings, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	selectors, err := parseSelectors(matches)
+	if err != nil {
+		return nil, nil, err
+	}
+	querier, err := c.queryable.Querier(start.UnixMilli(), end.UnixMilli())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer querier.Close()
+
+	seen := make(map[string]bool)
+	var series []labels.Labels
+	var warnings annotations.Annotations
+	hints := &storage.SelectHints{Start: start.UnixMilli(), End: end.UnixMilli(), Func: "series"}
+	for _, matchers := range selectors {
+		set := querier.Select(ctx, false, hints, matchers...)
+		for set.Next() {
+			lset := set.At().Labels()
+			if key := lset.String(); !seen[key] {
+				seen[key] = true
+				series = append(series, lset)
+			}
+		}
+		if err := set.Err(); err != nil {
+			return nil, nil, err
+		}
+		warnings.Merge(set.Warnings())
+	}
+
+	slices.SortFunc(series, labels.Compare)
+	if limit > 0 && uint64(len(series)) > limit {
+		series = series[:limit]
+		warnings.Add(errors.New("results truncated due to limit"))
+	}
+	result := make([]model.LabelSet, len(series))
+	for i, lset := range series {
+		result[i] = toModelLabelSet(lset)
+	}
+	return result, annotationWarnings(warnings, ""), nil
+}
+
+func (c *localClient) LabelNames(ctx context.Context, matches []string, start, end time.Time, timeout time.Duration) ([]string, v1.Warnings, error) {
+	return c.labels(ctx, matches, start, end, timeout, func(q storage.Querier, matchers []*labels.Matcher) ([]string, annotations.Annotations, error) {
+		return q.LabelNames(ctx, nil, matchers...)
+	})
+}
+
+func (c *localClient) LabelValues(ctx context.Context, labelName string, matches []string, start, end time.Time, timeout time.Duration) ([]string, v1.Warnings, error) {
+	return c.labels(ctx, matches, start, end, timeout, func(q storage.Querier, matchers []*labels.Matcher) ([]string, annotations.Annotations, error) {
+		return q.LabelValues(ctx, labelName, nil, matchers...)
+	})
+}
+
+// labels returns the sorted union of the label names or values returned by
+// get for each selector in matches, or for all series without selectors.
+func (c *localClient) labels(ctx context.Context, matches []string, start, end time.Time, timeout time.Duration, get func(storage.Querier, []*labels.Matcher) ([]string, annotations.Annotations, error)) ([]string, v1.Warnings, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	selectors, err := parseSelectors(matches)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(selectors) == 0 {
+		selectors = [][]*labels.Matcher{nil}
+	}
+	querier, err := c.queryable.Querier(start.UnixMilli(), end.UnixMilli())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer querier.Close()
+
+	var result []string
+	var warnings annotations.Annotations
+	for _, matchers := range selectors {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		values, w, err := get(querier, matchers)
+		if err != nil {
+			return nil, nil, err
+		}
+		result = append(result, values...)
+		warnings.Merge(w)
+	}
+	slices.Sort(result)
+	return slices.Compact(result), annotationWarnings(warnings, ""), nil
+}
+
+func (c *localClient) Metadata(_ context.Context, metric string, _ time.Duration) (map[string][]v1.Metadata, error) {
+	if metric == "" {
+		return c.metadata, nil
+	}
+	result := make(map[string][]v1.Metadata)
+	if metadata, ok := c.metadata[metric]; ok {
+		result[metric] = metadata
+	}
+	return result, nil
+}
+
+func (c *localClient) Targets(context.Context, time.Duration) (v1.TargetsResult, error) {
+	return v1.TargetsResult{}, fmt.Errorf("targets: %w", errNotAvailableLocally)
+}
+
+func (c *localClient) Rules(context.Context, time.Duration) (RulesResult, error) {
+	return RulesResult{}, fmt.Errorf("rules: %w", errNotAvailableLocally)
+}
+
+func (c *localClient) TSDB(context.Context, uint64, time.Duration) (v1.TSDBResult, error) {
+	return v1.TSDBResult{}, fmt.Errorf("TSDB status: %w", errNotAvailableLocally)
+}
+
+func (c *localClient) QueryExemplars(context.Context, string, time.Time, time.Time, time.Duration) ([]v1.ExemplarQueryResult, error) {
+	return nil, errNotAvailableLocally
+}
+
+func (c *localClient) Buildinfo(context.Context, time.Duration) (v1.BuildinfoResult, error) {
+	return v1.BuildinfoResult{Version: c.version, GoVersion: runtime.Version()}, nil
+}
+
+func (c *localClient) Runtimeinfo(context.Context, time.Duration) (v1.RuntimeinfoResult, error) {
+	return v1.RuntimeinfoResult{
+		StartTime:           c.started,
+		ReloadConfigSuccess: true,
+		GoroutineCount:      runtime.NumGoroutine(),
+		GOMAXPROCS:          runtime.GOMAXPROCS(0),
+	}, nil
+}
+
+func (c *localClient) Flags(context.Context, time.Duration) (v1.FlagsResult, error) {
+	return nil, fmt.Errorf("flags: %w", errNotAvailableLocally)
+}
+
+func (c *localClient) Config(context.Context, time.Duration) (v1.ConfigResult, error) {
+	return v1.ConfigResult{}, fmt.Errorf("config: %w", errNotAvailableLocally)
+}
+
+// Close releases the client's storage.
+func (c *localClient) Close() error {
+	if closer, ok := c.queryable.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// parseSelectors parses series selectors such as up{job="node"}.
+func parseSelectors(matches []string) ([][]*labels.Matcher, error) {
+	p := parser.NewParser(parser.Options{})
+	selectors := make([][]*labels.Matcher, 0, len(matches))
+	for _, match := range matches {
+		matchers, err := p.ParseMetricSelector(match)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector %q: %w", match, err)
+		}
+		selectors = append(selectors, matchers)
+	}
+	return selectors, nil
+}
+
+// annotationWarnings converts engine annotations to API warnings.
+func annotationWarnings(a annotations.Annotations, query string) v1.Warnings {
+	if len(a) == 0 {
+		return nil
+	}
+	warnings, infos := a.AsStrings(query, 0, 0)
+	return append(warnings, infos...)
+}
+
+// queryStats converts engine statistics to the stats reported by the API.
+func queryStats(s *stats.Statistics) *QueryStats {
+	b, err := json.Marshal(stats.NewQueryStats(s))
+	if err != nil {
+		return nil
+	}
+	var result QueryStats
+	if err := json.Unmarshal(b, &result); err != nil {
+		return nil
+	}
+	return &result
+}
+
+// toModelValue converts an engine result to the API client types.
+func toModelValue(v parser.Value) (model.Value, error) {
+	switch v := v.(type) {
+	case promql.Vector:
+		vector := make(model.Vector, 0, len(v))
+		for _, s := range v {
+			vector = append(vector, &model.Sample{
+				Metric:    model.Metric(toModelLabelSet(s.Metric)),
+				Timestamp: model.Time(s.T),
+				Value:     model.SampleValue(s.F),
+				Histogram: toSampleHistogram(s.H),
+			})
+		}
+		return vector, nil
+	case promql.Matrix:
+		matrix := make(model.Matrix, 0, len(v))
+		for _, series := range v {
+			stream := &model.SampleStream{Metric: model.Metric(toModelLabelSet(series.Metric))}
+			for _, p := range series.Floats {
+				stream.Values = append(stream.Values, model.SamplePair{Timestamp: model.Time(p.T), Value: model.SampleValue(p.F)})
+			}
+			for _, p := range series.Histograms {
+				stream.Histograms = append(stream.Histograms, model.SampleHistogramPair{Timestamp: model.Time(p.T), Histogram: toSampleHistogram(p.H)})
+			}
+			matrix = append(matrix, stream)
+		}
+		return matrix, nil
+	case promql.Scalar:
+		return &model.Scalar{Timestamp: model.Time(v.T), Value: model.SampleValue(v.V)}, nil
+	case promql.String:
+		return &model.String{Timestamp: model.Time(v.T), Value: v.V}, nil
+	default:
+		return nil, fmt.Errorf("unknown result type: %s", v.Type())
+	}
+}
+
+// toSampleHistogram converts a native histogram to its API representation.
+func toSampleHistogram(h *histogram.FloatHistogram) *model.SampleHistogram {
+	if h == nil {
+		return nil
+	}
+	result := &model.SampleHistogram{
+		Count: model.FloatString(h.Count),
+		Sum:   model.FloatString(h.Sum),
+	}
+	for it := h.AllBucketIterator(); it.Next(); {
+		bucket := it.At()
+		if bucket.Count == 0 {
+			continue
+		}
+		// Boundaries follow the API: 0 open left, 1 open right, 2 open both, 3 closed both
+		var boundaries int32
+		switch {
+		case bucket.LowerInclusive && bucket.UpperInclusive:
+			boundaries = 3
+		case bucket.LowerInclusive:
+			boundaries = 1
+		case !bucket.UpperInclusive:
+			boundaries = 2
+		}
+		result.Buckets = append(result.Buckets, &model.HistogramBucket{
+			Boundaries: boundaries,
+			Lower:      model.FloatString(bucket.Lower),
+			Upper:      model.FloatString(bucket.Upper),
+			Count:      model.FloatString(bucket.Count),
+		})
+	}
+	return result
+}
+
+func toModelLabelSet(lset labels.Labels) model.LabelSet {
+	result := make(model.LabelSet, lset.Len())
+	lset.Range(func(l labels.Label) {
+		result[model.LabelName(l.Name)] = model.LabelValue(l.Value)
+	})
+	return result
+}
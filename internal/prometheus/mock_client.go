@@ -1,6 +1,7 @@
 package prometheus
 
 import (
+	"context"
 	"time"
 
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
@@ -9,44 +10,152 @@ import (
 
 // MockClient is a mock implementation of the Client interface for testing.
 type MockClient struct {
-	QueryFunc       func(query string, timeout time.Duration) (v1.Warnings, model.Vector, error)
-	QueryRangeFunc  func(query string, start, end time.Time, step time.Duration, timeout time.Duration) (model.Matrix, v1.Warnings, error)
-	SeriesFunc      func(query string, start, end time.Time, limit uint64, timeout time.Duration) ([]model.LabelSet, v1.Warnings, error)
-	LabelNamesFunc  func(start, end time.Time, timeout time.Duration) ([]string, v1.Warnings, error)
-	LabelValuesFunc func(labelName string, start, end time.Time, timeout time.Duration) ([]string, v1.Warnings, error)
+	QueryFunc       func(ctx context.Context, query string, ts time.Time, timeout time.Duration, stats bool) (v1.Warnings, model.Value, *QueryStats, error)
+	QueryRangeFunc  func(ctx context.Context, query string, start, end time.Time, step time.Duration, timeout time.Duration, stats bool) (model.Matrix, v1.Warnings, *QueryStats, error)
+	SeriesFunc      func(ctx context.Context, matches []string, start, end time.Time, limit uint64, timeout time.Duration) ([]model.LabelSet, v1.Warnings, error)
+	LabelNamesFunc  func(ctx context.Context, matches []string, start, end time.Time, timeout time.Duration) ([]string, v1.Warnings, error)
+	LabelValuesFunc func(ctx context.Context, labelName string, matches []string, start, end time.Time, timeout time.Duration) ([]string, v1.Warnings, error)
+	MetadataFunc    func(ctx context.Context, metric string, timeout time.Duration) (map[string][]v1.Metadata, error)
+	TargetsFunc     func(ctx context.Context, timeout time.Duration) (v1.TargetsResult, error)
+	RulesFunc       func(ctx context.Context, timeout time.Duration) (RulesResult, error)
+	TSDBFunc        func(ctx context.Context, limit uint64, timeout time.Duration) (v1.TSDBResult, error)
+	ExemplarsFunc   func(ctx context.Context, query string, start, end time.Time, timeout time.Duration) ([]v1.ExemplarQueryResult, error)
+	BuildinfoFunc   func(ctx context.Context, timeout time.Duration) (v1.BuildinfoResult, error)
+	RuntimeinfoFunc func(ctx context.Context, timeout time.Duration) (v1.RuntimeinfoResult, error)
+	FlagsFunc       func(ctx context.Context, timeout time.Duration) (v1.FlagsResult, error)
+	ConfigFunc      func(ctx context.Context, timeout time.Duration) (v1.ConfigResult, error)
 }
 
-func (m *MockClient) Query(query string, timeout time.Duration) (v1.Warnings, model.Vector, error) {
+func (m *MockClient) Query(ctx context.Context, query string, ts time.Time, timeout time.Duration, stats bool) (v1.Warnings, model.Value, *QueryStats, error) {
 	if m.QueryFunc != nil {
-		return m.QueryFunc(query, timeout)
+		return m.QueryFunc(ctx, query, ts, timeout, stats)
 	}
-	return nil, nil, nil
+	return nil, nil, nil, nil
 }
 
-func (m *MockClient) QueryRange(query string, start, end time.Time, step time.Duration, timeout time.Duration) (model.Matrix, v1.Warnings, error) {
+func (m *MockClient) QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration, timeout time.Duration, stats bool) (model.Matrix, v1.Warnings, *QueryStats, error) {
 	if m.QueryRangeFunc != nil {
-		return m.QueryRangeFunc(query, start, end, step, timeout)
+		return m.QueryRangeFunc(ctx, query, start, end, step, timeout, stats)
 	}
-	return nil, nil, nil
+	return nil, nil, nil, nil
 }
 
-func (m *MockClient) Series(query string, start, end time.Time, limit uint64, timeout time.Duration) ([]model.LabelSet, v1.Warnings, error) {
+func (m *MockClient) Series(ctx context.Context, matches []string, start, end time.Time, limit uint64, timeout time.Duration) ([]model.LabelSet, v1.Warnings, error) {
 	if m.SeriesFunc != nil {
-		return m.SeriesFunc(query, start, end, limit, timeout)
+		return m.SeriesFunc(ctx, matches, start, end, limit, timeout)
 	}
 	return nil, nil, nil
 }
 
-func (m *MockClient) LabelNames(start, end time.Time, timeout time.Duration) ([]string, v1.Warnings, error) {
+func (m *MockClient) LabelNames(ctx context.Context, matches []string, start, end time.Time, timeout time.Duration) ([]string, v1.Warnings, error) {
 	if m.LabelNamesFunc != nil {
-		return m.LabelNamesFunc(start, end, timeout)
+		return m.LabelNamesFunc(ctx, matches, start, end, timeout)
 	}
 	return nil, nil, nil
 }
 
-func (m *MockClient) LabelValues(labelName string, start, end time.Time, timeout time.Duration) ([]string, v1.Warnings, error) {
+func (m *MockClient) LabelValues(ctx context.Context, labelName string, matches []string, start, end time.Time, timeout time.Duration) ([]string, v1.Warnings, error) {
 	if m.LabelValuesFunc != nil {
-		return m.LabelValuesFunc(labelName, start, end, timeout)
+		return m.LabelValuesFunc(ctx, labelName, matches, start, end, timeout)
 	}
 	return nil, nil, nil
 }
+
+func (m *MockClient) Metadata(ctx context.Context, metric string, timeout time.Duration) (map[string][]v1.Metadata, error) {
+	if m.MetadataFunc != nil {
+		return m.MetadataFunc(ctx, metric, timeout)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) Targets(ctx context.Context, timeout time.Duration) (v1.TargetsResult, error) {
+	if m.TargetsFunc != nil {
+		return m.TargetsFunc(ctx, timeout)
+	}
+	return v1.TargetsResult{}, nil
+}
+
+func (m *MockClient) Rules(ctx context.Context, timeout time.Duration) (RulesResult, error) {
+	if m.RulesFunc != nil {
+		return m.RulesFunc(ctx, timeout)
+	}
+	return RulesResult{}, nil
+}
+
+func (m *MockClient) TSDB(ctx context.Context, limit uint64, timeout time.Duration) (v1.TSDBResult, error) {
+	if m.TSDBFunc != nil {
+		return m.TSDBFunc(ctx, limit, timeout)
+	}
+	return v1.TSDBResult{}, nil
+}
+
+func (m *MockClient) QueryExemplars(ctx context.Context, query string, start, end time.Time, timeout time.Duration) ([]v1.ExemplarQueryResult, error) {
+	if m.ExemplarsFunc != nil {
+		return m.ExemplarsFunc(ctx, query, start, end, timeout)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) Buildinfo(ctx context.Context, timeout time.Duration) (v1.BuildinfoResult, error) {
+	if m.BuildinfoFunc != nil {
+		return m.BuildinfoFunc(ctx, timeout)
+	}
+	return v1.BuildinfoResult{}, nil
+}
+
+func (m *MockClient) Runtimeinfo(ctx context.Context, timeout time.Duration) (v1.RuntimeinfoResult, error) {
+	if m.RuntimeinfoFunc != nil {
+		return m.RuntimeinfoFunc(ctx, timeout)
+	}
+	return v1.RuntimeinfoResult{}, nil
+}
+
+func (m *MockClient) Flags(ctx context.Context, timeout time.Duration) (v1.FlagsResult, error) {
+	if m.FlagsFunc != nil {
+		return m.FlagsFunc(ctx, timeout)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) Config(ctx context.Context, timeout time.Duration) (v1.ConfigResult, error) {
+	if m.ConfigFunc != nil {
+		return m.ConfigFunc(ctx, timeout)
+	}
+	return v1.ConfigResult{}, nil
+}
+
+// MockAlertmanagerClient is a mock implementation of the AlertmanagerClient interface for testing.
+type MockAlertmanagerClient struct {
+	AlertGroupsFunc   func(ctx context.Context, timeout time.Duration) ([]AlertGroup, error)
+	SilencesFunc      func(ctx context.Context, timeout time.Duration) ([]Silence, error)
+	CreateSilenceFunc func(ctx context.Context, silence Silence, timeout time.Duration) (string, error)
+	ExpireSilenceFunc func(ctx context.Context, id string, timeout time.Duration) error
+}
+
+func (m *MockAlertmanagerClient) AlertGroups(ctx context.Context, timeout time.Duration) ([]AlertGroup, error) {
+	if m.AlertGroupsFunc != nil {
+		return m.AlertGroupsFunc(ctx, timeout)
+	}
+	return nil, nil
+}
+
+func (m *MockAlertmanagerClient) Silences(ctx context.Context, timeout time.Duration) ([]Silence, error) {
+	if m.SilencesFunc != nil {
+		return m.SilencesFunc(ctx, timeout)
+	}
+	return nil, nil
+}
+
+func (m *MockAlertmanagerClient) CreateSilence(ctx context.Context, silence Silence, timeout time.Duration) (string, error) {
+	if m.CreateSilenceFunc != nil {
+		return m.CreateSilenceFunc(ctx, silence, timeout)
+	}
+	return "", nil
+}
+
+func (m *MockAlertmanagerClient) ExpireSilence(ctx context.Context, id string, timeout time.Duration) error {
+	if m.ExpireSilenceFunc != nil {
+		return m.ExpireSilenceFunc(ctx, id, timeout)
+	}
+	return nil
+}
@@ -2,7 +2,13 @@ package prometheus
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/prometheus/client_golang/api"
@@ -13,96 +19,232 @@ import (
 )
 
 type prometheusClient struct {
-	v1api v1.API
+	client api.Client
+	v1api  v1.API
 }
 
 type Client interface {
-	Query(query string, timeout time.Duration) (v1.Warnings, model.Vector, error)
-	QueryRange(query string, start, end time.Time, step time.Duration, timeout time.Duration) (model.Matrix, v1.Warnings, error)
-	Series(query string, start, end time.Time, limit uint64, timeout time.Duration) ([]model.LabelSet, v1.Warnings, error)
-	LabelNames(start, end time.Time, timeout time.Duration) ([]string, v1.Warnings, error)
-	LabelValues(labelName string, start, end time.Time, timeout time.Duration) ([]string, v1.Warnings, error)
+	// Query and QueryRange only ask the server for query stats when stats is
+	// set, since collecting them costs the server extra work.
+	Query(ctx context.Context, query string, ts time.Time, timeout time.Duration, stats bool) (v1.Warnings, model.Value, *QueryStats, error)
+	QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration, timeout time.Duration, stats bool) (model.Matrix, v1.Warnings, *QueryStats, error)
+	Series(ctx context.Context, matches []string, start, end time.Time, limit uint64, timeout time.Duration) ([]model.LabelSet, v1.Warnings, error)
+	LabelNames(ctx context.Context, matches []string, start, end time.Time, timeout time.Duration) ([]string, v1.Warnings, error)
+	LabelValues(ctx context.Context, labelName string, matches []string, start, end time.Time, timeout time.Duration) ([]string, v1.Warnings, error)
+	Metadata(ctx context.Context, metric string, timeout time.Duration) (map[string][]v1.Metadata, error)
+	Targets(ctx context.Context, timeout time.Duration) (v1.TargetsResult, error)
+	Rules(ctx context.Context, timeout time.Duration) (RulesResult, error)
+	TSDB(ctx context.Context, limit uint64, timeout time.Duration) (v1.TSDBResult, error)
+	QueryExemplars(ctx context.Context, query string, start, end time.Time, timeout time.Duration) ([]v1.ExemplarQueryResult, error)
+	Buildinfo(ctx context.Context, timeout time.Duration) (v1.BuildinfoResult, error)
+	Runtimeinfo(ctx context.Context, timeout time.Duration) (v1.RuntimeinfoResult, error)
+	Flags(ctx context.Context, timeout time.Duration) (v1.FlagsResult, error)
+	Config(ctx context.Context, timeout time.Duration) (v1.ConfigResult, error)
 }
 
-func NewClient(url string) (Client, error) {
+// Config holds the settings used to connect to a Prometheus-compatible endpoint.
+type Config struct {
+	URL  string
+	Auth AuthConfig
+	TLS  TLSConfig
+
+	// Record, if set, records every request and its response.
+	Record *Recorder
+	// Replay, if set, serves responses from a recording instead of the endpoint.
+	Replay *Replay
+}
+
+func NewClient(cfg Config) (Client, error) {
+	roundTripper, err := cfg.roundTripper()
+	if err != nil {
+		return nil, err
+	}
+
 	client, err := api.NewClient(api.Config{
-		Address: url,
+		Address:      cfg.URL,
+		RoundTripper: roundTripper,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("creating prometheus client: %w", err)
 	}
 	v1api := v1.NewAPI(client)
-	return &prometheusClient{v1api: v1api}, nil
+	return &prometheusClient{client: client, v1api: v1api}, nil
 }
 
-func (c *prometheusClient) Query(query string, timeout time.Duration) (v1.Warnings, model.Vector, error) {
-	var vector model.Vector
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-	result, warnings, err := c.v1api.Query(ctx, query, time.Now(), v1.WithTimeout(timeout))
+// roundTripper returns the HTTP transport applying the TLS and auth settings.
+func (cfg Config) roundTripper() (http.RoundTripper, error) {
+	if err := cfg.Auth.Validate(); err != nil {
+		return nil, err
+	}
+	if err := cfg.TLS.Validate(); err != nil {
+		return nil, err
+	}
+	base, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing URL: %w", err)
+	}
+	if cfg.Replay != nil {
+		return &replayRoundTripper{replay: cfg.Replay, base: base}, nil
+	}
+
+	roundTripper, err := newTransport(cfg.TLS)
 	if err != nil {
-		return warnings, vector, err
+		return nil, fmt.Errorf("configuring TLS: %w", err)
+	}
+	if !cfg.Auth.isZero() {
+		roundTripper = newAuthRoundTripper(cfg.Auth, roundTripper)
+	}
+	if cfg.Record != nil {
+		roundTripper = &recordingRoundTripper{recorder: cfg.Record, base: base, next: roundTripper}
 	}
+	return roundTripper, nil
+}
+
+// apiResponse is the envelope of the responses of the Prometheus HTTP API,
+// with the data of successful responses decoded as T.
+type apiResponse[T any] struct {
+	Status    string       `json:"status"`
+	Data      T            `json:"data"`
+	ErrorType v1.ErrorType `json:"errorType"`
+	Error     string       `json:"error"`
+	Warnings  v1.Warnings  `json:"warnings"`
+}
 
-	switch result.Type() {
-	case model.ValVector:
-		v := result.(model.Vector)
-		return warnings, v, nil
-	case model.ValNone, model.ValScalar, model.ValMatrix, model.ValString:
-		return warnings, vector, fmt.Errorf("unexpected result type: %s", result.Type())
-	default:
-		return warnings, vector, fmt.Errorf("unknown result type: %s", result.Type())
+// doAPI sends req and decodes the response's data as T in a single pass,
+// for results the v1 API doesn't fully decode. Errors are reported as
+// *v1.Error, as the v1 API does.
+func doAPI[T any](ctx context.Context, client api.Client, req *http.Request) (T, v1.Warnings, error) {
+	resp, body, err := client.Do(ctx, req)
+	if err != nil {
+		var data T
+		return data, nil, err
 	}
+	return decodeAPI[T](resp, body)
 }
 
-func (c *prometheusClient) QueryRange(query string, start, end time.Time, step time.Duration, timeout time.Duration) (model.Matrix, v1.Warnings, error) {
-	var matrix model.Matrix
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+// doQuery sends args to a query endpoint as a form, falling back to GET for
+// servers that don't accept POST, as the v1 API does.
+func doQuery(ctx context.Context, client api.Client, endpoint string, args url.Values) (queryData, v1.Warnings, error) {
+	u := client.URL(endpoint, nil)
+	encoded := args.Encode()
+	req, err := http.NewRequest(http.MethodPost, u.String(), strings.NewReader(encoded))
+	if err != nil {
+		return queryData{}, nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, body, err := client.Do(ctx, req)
+	if resp != nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		u.RawQuery = encoded
+		if req, err = http.NewRequest(http.MethodGet, u.String(), nil); err != nil {
+			return queryData{}, nil, err
+		}
+		resp, body, err = client.Do(ctx, req)
+	}
+	if err != nil {
+		return queryData{}, nil, err
+	}
+	return decodeAPI[queryData](resp, body)
+}
+
+// decodeAPI decodes the API response body, reporting failed requests as
+// *v1.Error.
+func decodeAPI[T any](resp *http.Response, body []byte) (T, v1.Warnings, error) {
+	var result apiResponse[T]
+
+	// Prometheus reports API errors as 400 or 422 with an error body
+	code := resp.StatusCode
+	if code/100 != 2 && code != http.StatusBadRequest && code != http.StatusUnprocessableEntity {
+		errorType := v1.ErrBadResponse
+		switch code / 100 {
+		case 4:
+			errorType = v1.ErrClient
+		case 5:
+			errorType = v1.ErrServer
+		}
+		return result.Data, nil, &v1.Error{Type: errorType, Msg: resp.Status, Detail: string(body)}
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return result.Data, nil, &v1.Error{Type: v1.ErrBadResponse, Msg: err.Error()}
+	}
+	if result.Status == "error" {
+		return result.Data, result.Warnings, &v1.Error{Type: result.ErrorType, Msg: result.Error}
+	}
+	return result.Data, result.Warnings, nil
+}
+
+// queryArgs returns the arguments common to query and range query requests.
+func queryArgs(query string, timeout time.Duration, stats bool) url.Values {
+	args := url.Values{}
+	args.Set("query", query)
+	args.Set("timeout", timeout.String())
+	if stats {
+		args.Set("stats", string(v1.AllStatsValue))
+	}
+	return args
+}
+
+// formatTime formats t as the unix seconds the API accepts.
+func formatTime(t time.Time) string {
+	return strconv.FormatFloat(float64(t.Unix())+float64(t.Nanosecond())/1e9, 'f', -1, 64)
+}
+
+func (c *prometheusClient) Query(ctx context.Context, query string, ts time.Time, timeout time.Duration, stats bool) (v1.Warnings, model.Value, *QueryStats, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
-	result, warnings, err := c.v1api.QueryRange(ctx, query, v1.Range{
-		Start: start,
-		End:   end,
-		Step:  step,
-	}, v1.WithTimeout(timeout))
+	args := queryArgs(query, timeout, stats)
+	if !ts.IsZero() {
+		args.Set("time", formatTime(ts))
+	}
+	data, warnings, err := doQuery(ctx, c.client, "/api/v1/query", args)
 	if err != nil {
-		return matrix, warnings, err
+		return warnings, nil, nil, err
 	}
+	return warnings, data.Result, data.Stats, nil
+}
 
-	switch result.Type() {
-	case model.ValMatrix:
-		m := result.(model.Matrix)
-		return m, warnings, nil
-	case model.ValNone, model.ValScalar, model.ValVector, model.ValString:
-		return matrix, warnings, fmt.Errorf("unexpected result type: %s", result.Type())
-	default:
-		return matrix, warnings, fmt.Errorf("unknown result type: %s", result.Type())
+func (c *prometheusClient) QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration, timeout time.Duration, stats bool) (model.Matrix, v1.Warnings, *QueryStats, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	args := queryArgs(query, timeout, stats)
+	args.Set("start", formatTime(start))
+	args.Set("end", formatTime(end))
+	args.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+	data, warnings, err := doQuery(ctx, c.client, "/api/v1/query_range", args)
+	if err != nil {
+		return nil, warnings, nil, err
 	}
+	matrix, ok := data.Result.(model.Matrix)
+	if !ok {
+		return nil, warnings, nil, fmt.Errorf("unexpected result type: %s", data.Result.Type())
+	}
+	return matrix, warnings, data.Stats, nil
 }
 
-func (c *prometheusClient) Series(query string, start, end time.Time, limit uint64, timeout time.Duration) ([]model.LabelSet, v1.Warnings, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+func (c *prometheusClient) Series(ctx context.Context, matches []string, start, end time.Time, limit uint64, timeout time.Duration) ([]model.LabelSet, v1.Warnings, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
-	series, warnings, err := c.v1api.Series(ctx, []string{query}, start, end, v1.WithTimeout(timeout), v1.WithLimit(limit))
+	series, warnings, err := c.v1api.Series(ctx, matches, start, end, v1.WithTimeout(timeout), v1.WithLimit(limit))
 	if err != nil {
 		return series, warnings, err
 	}
 	return series, warnings, nil
 }
 
-func (c *prometheusClient) LabelNames(start, end time.Time, timeout time.Duration) ([]string, v1.Warnings, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+func (c *prometheusClient) LabelNames(ctx context.Context, matches []string, start, end time.Time, timeout time.Duration) ([]string, v1.Warnings, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
-	labels, warnings, err := c.v1api.LabelNames(ctx, []string{}, start, end, v1.WithTimeout(timeout))
+	labels, warnings, err := c.v1api.LabelNames(ctx, matches, start, end, v1.WithTimeout(timeout))
 	if err != nil {
 		return nil, warnings, err
 	}
 	return labels, warnings, nil
 }
 
-func (c *prometheusClient) LabelValues(labelName string, start, end time.Time, timeout time.Duration) ([]string, v1.Warnings, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+func (c *prometheusClient) LabelValues(ctx context.Context, labelName string, matches []string, start, end time.Time, timeout time.Duration) ([]string, v1.Warnings, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
-	values, warnings, err := c.v1api.LabelValues(ctx, labelName, []string{}, start, end, v1.WithTimeout(timeout))
+	values, warnings, err := c.v1api.LabelValues(ctx, labelName, matches, start, end, v1.WithTimeout(timeout))
 	if err != nil {
 		return nil, warnings, err
 	}
@@ -114,8 +256,92 @@ func (c *prometheusClient) LabelValues(labelName string, start, end time.Time, t
 	return result, warnings, nil
 }
 
+// Metadata returns the metadata of the named metric, or of all metrics when metric is empty.
+func (c *prometheusClient) Metadata(ctx context.Context, metric string, timeout time.Duration) (map[string][]v1.Metadata, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return c.v1api.Metadata(ctx, metric, "")
+}
+
+// Targets returns the active and dropped scrape targets.
+func (c *prometheusClient) Targets(ctx context.Context, timeout time.Duration) (v1.TargetsResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return c.v1api.Targets(ctx)
+}
+
+// Rules returns the recording and alerting rule groups, including active alerts.
+func (c *prometheusClient) Rules(ctx context.Context, timeout time.Duration) (RulesResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.client.URL("/api/v1/rules", nil).String(), nil)
+	if err != nil {
+		return RulesResult{}, err
+	}
+	rules, _, err := doAPI[RulesResult](ctx, c.client, req)
+	return rules, err
+}
+
+// TSDB returns head block statistics and the top limit entries of each cardinality table.
+func (c *prometheusClient) TSDB(ctx context.Context, limit uint64, timeout time.Duration) (v1.TSDBResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return c.v1api.TSDB(ctx, v1.WithLimit(limit))
+}
+
+// QueryExemplars returns the exemplars of the series selected by query between start and end.
+func (c *prometheusClient) QueryExemplars(ctx context.Context, query string, start, end time.Time, timeout time.Duration) ([]v1.ExemplarQueryResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return c.v1api.QueryExemplars(ctx, query, start, end)
+}
+
+// ErrNoBuildinfo is returned by Buildinfo for servers answering that they
+// don't serve build info, as some Prometheus-compatible servers do.
+var ErrNoBuildinfo = errors.New("build info not served")
+
+// Buildinfo returns the server's version and build information.
+func (c *prometheusClient) Buildinfo(ctx context.Context, timeout time.Duration) (v1.BuildinfoResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequest(http.MethodGet, c.client.URL("/api/v1/status/buildinfo", nil).String(), nil)
+	if err != nil {
+		return v1.BuildinfoResult{}, err
+	}
+	resp, body, err := c.client.Do(ctx, req)
+	if err != nil {
+		return v1.BuildinfoResult{}, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return v1.BuildinfoResult{}, fmt.Errorf("%w: %s", ErrNoBuildinfo, resp.Status)
+	}
+	info, _, err := decodeAPI[v1.BuildinfoResult](resp, body)
+	return info, err
+}
+
+// Runtimeinfo returns the server's runtime properties, such as its start time and storage retention.
+func (c *prometheusClient) Runtimeinfo(ctx context.Context, timeout time.Duration) (v1.RuntimeinfoResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return c.v1api.Runtimeinfo(ctx)
+}
+
+// Flags returns the command-line flags the server was started with.
+func (c *prometheusClient) Flags(ctx context.Context, timeout time.Duration) (v1.FlagsResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return c.v1api.Flags(ctx)
+}
+
+// Config returns the server's currently loaded configuration as YAML.
+func (c *prometheusClient) Config(ctx context.Context, timeout time.Duration) (v1.ConfigResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return c.v1api.Config(ctx)
+}
+
 func FormatQuery(query string) string {
-	ast, err := parser.ParseExpr(query)
+	ast, err := parser.NewParser(parser.Options{}).ParseExpr(query)
 	if err != nil {
 		return query
 	}
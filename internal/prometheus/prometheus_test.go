@@ -1,7 +1,15 @@
 package prometheus
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
 )
 
 func TestNewClient(t *testing.T) {
@@ -24,7 +32,7 @@ func TestNewClient(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			client, err := NewClient(tt.url)
+			client, err := NewClient(Config{URL: tt.url})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("NewClient() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -73,3 +81,253 @@ func TestFormatQuery(t *testing.T) {
 		})
 	}
 }
+
+func TestQueryResultTypes(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		wantType model.ValueType
+	}{
+		{"vector", `{"resultType":"vector","result":[{"metric":{"job":"a"},"value":[1700000000,"1"]}]}`, model.ValVector},
+		{"scalar", `{"resultType":"scalar","result":[1700000000,"42"]}`, model.ValScalar},
+		{"matrix", `{"resultType":"matrix","result":[{"metric":{"job":"a"},"values":[[1700000000,"1"],[1700000015,"2"]]}]}`, model.ValMatrix},
+		{"string", `{"resultType":"string","result":[1700000000,"peat"]}`, model.ValString},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"status":"success","data":` + tt.data + `}`))
+			}))
+			defer srv.Close()
+
+			client, err := NewClient(Config{URL: srv.URL})
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			_, value, _, err := client.Query(context.Background(), "q", time.Now(), time.Second, false)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			if value.Type() != tt.wantType {
+				t.Errorf("Query() type = %s, want %s", value.Type(), tt.wantType)
+			}
+		})
+	}
+}
+
+func TestQueryStats(t *testing.T) {
+	const stats = `"stats":{"timings":{"evalTotalTime":0.25,"resultSortTime":0,"queryPreparationTime":0.01,"innerEvalTime":0.2,"execQueueTime":0.5,"execTotalTime":0.75},` +
+		`"samples":{"totalQueryableSamplesPerStep":[[1700000000,10],[1700000015,30]],"totalQueryableSamples":40,"peakSamples":12}}`
+
+	tests := []struct {
+		name      string
+		request   bool
+		stats     string
+		wantParam string
+		wantStats bool
+	}{
+		{"reported", true, "," + stats, "all", true},
+		{"not supported", true, "", "all", false},
+		{"not requested", false, "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotParam string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseForm(); err != nil {
+					t.Errorf("ParseForm() error = %v", err)
+				}
+				gotParam = r.Form.Get("stats")
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[]` + tt.stats + `}}`))
+			}))
+			defer srv.Close()
+
+			client, err := NewClient(Config{URL: srv.URL})
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			_, _, got, err := client.QueryRange(context.Background(), "q", time.Now().Add(-time.Hour), time.Now(), time.Minute, time.Second, tt.request)
+			if err != nil {
+				t.Fatalf("QueryRange() error = %v", err)
+			}
+			if gotParam != tt.wantParam {
+				t.Errorf("stats parameter = %q, want %q", gotParam, tt.wantParam)
+			}
+			if !tt.wantStats {
+				if got != nil {
+					t.Errorf("QueryRange() stats = %+v, want nil", got)
+				}
+				return
+			}
+
+			if got == nil {
+				t.Fatal("QueryRange() stats = nil")
+			}
+			if got.Samples.TotalQueryableSamples != 40 || got.Samples.PeakSamples != 12 {
+				t.Errorf("samples = %+v, want 40 total and 12 peak", got.Samples)
+			}
+			if steps := got.Samples.TotalQueryableSamplesPerStep; len(steps) != 2 || steps[1].Samples != 30 || steps[1].Time.Unix() != 1700000015 {
+				t.Errorf("samples per step = %+v", steps)
+			}
+			if got.Timings.ExecQueueTime.Duration() != 500*time.Millisecond || got.Timings.EvalTotalTime.Duration() != 250*time.Millisecond {
+				t.Errorf("timings = %+v", got.Timings)
+			}
+		})
+	}
+}
+
+func TestMatchSelectors(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("ParseForm() error = %v", err)
+		}
+		got = r.Form["match[]"]
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":[]}`))
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(Config{URL: srv.URL})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	matches := []string{`up{job="a"}`, `up{job="b"}`}
+	end := time.Now()
+	start := end.Add(-time.Hour)
+
+	calls := map[string]func() error{
+		"series": func() error {
+			_, _, err := client.Series(context.Background(), matches, start, end, 10, time.Second)
+			return err
+		},
+		"label names": func() error {
+			_, _, err := client.LabelNames(context.Background(), matches, start, end, time.Second)
+			return err
+		},
+		"label values": func() error {
+			_, _, err := client.LabelValues(context.Background(), "job", matches, start, end, time.Second)
+			return err
+		},
+	}
+
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			got = nil
+			if err := call(); err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if len(got) != len(matches) || got[0] != matches[0] || got[1] != matches[1] {
+				t.Errorf("match[] = %q, want %q", got, matches)
+			}
+		})
+	}
+}
+
+func TestRules(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/rules" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"groups":[{"name":"node","file":"node.yml","interval":30,` +
+			`"evaluationTime":0.004,"lastEvaluation":"2024-05-06T03:12:00Z","rules":[` +
+			`{"type":"recording","name":"instance:node_cpu:rate5m","query":"rate(node_cpu_seconds_total[5m])","health":"ok","evaluationTime":0.003},` +
+			`{"type":"alerting","name":"NodeDown","query":"up == 0","state":"inactive","health":"ok","evaluationTime":0.003}]}]}}`))
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(Config{URL: srv.URL})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	rules, err := client.Rules(context.Background(), time.Second)
+	if err != nil {
+		t.Fatalf("Rules() error = %v", err)
+	}
+	if len(rules.Groups) != 1 || len(rules.Groups[0].Rules) != 2 {
+		t.Fatalf("Rules() = %+v, want a group of 2 rules", rules)
+	}
+	group := rules.Groups[0]
+	if group.Name != "node" || group.Interval != 30 {
+		t.Errorf("group = %q every %vs, want node every 30s", group.Name, group.Interval)
+	}
+	if group.EvaluationTime.Duration() != 4*time.Millisecond {
+		t.Errorf("EvaluationTime = %v, want the group's 4ms", group.EvaluationTime.Duration())
+	}
+	if want := time.Date(2024, 5, 6, 3, 12, 0, 0, time.UTC); !group.LastEvaluation.Equal(want) {
+		t.Errorf("LastEvaluation = %v, want %v", group.LastEvaluation, want)
+	}
+
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`{"status":"error","errorType":"execution","error":"rule manager not ready"}`))
+	})
+	var apiErr *v1.Error
+	if _, err := client.Rules(context.Background(), time.Second); !errors.As(err, &apiErr) || apiErr.Type != v1.ErrExec {
+		t.Errorf("Rules() error = %v, want an execution error", err)
+	}
+}
+
+func TestBuildinfo(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantVersion string
+		wantType    v1.ErrorType
+	}{
+		{"served", http.StatusOK, `{"status":"success","data":{"version":"2.53.0"}}`, "2.53.0", ""},
+		{"unauthorized", http.StatusUnauthorized, "Unauthorized", "", v1.ErrClient},
+		{"not the API", http.StatusOK, "<html>Sign in</html>", "", v1.ErrBadResponse},
+		{"server error", http.StatusBadGateway, "Bad Gateway", "", v1.ErrServer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v1/status/buildinfo" {
+					http.NotFound(w, r)
+					return
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			client, err := NewClient(Config{URL: srv.URL})
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			info, err := client.Buildinfo(context.Background(), time.Second)
+			var apiErr *v1.Error
+			switch {
+			case tt.wantType == "" && err != nil:
+				t.Fatalf("Buildinfo() error = %v", err)
+			case tt.wantType != "" && (!errors.As(err, &apiErr) || apiErr.Type != tt.wantType || errors.Is(err, ErrNoBuildinfo)):
+				t.Errorf("Buildinfo() error = %v, want a %s error", err, tt.wantType)
+			}
+			if info.Version != tt.wantVersion {
+				t.Errorf("Buildinfo() version = %q, want %q", info.Version, tt.wantVersion)
+			}
+		})
+	}
+
+	// Servers without the endpoint answer 404
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	client, err := NewClient(Config{URL: srv.URL})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if _, err := client.Buildinfo(context.Background(), time.Second); !errors.Is(err, ErrNoBuildinfo) {
+		t.Errorf("Buildinfo() error = %v, want ErrNoBuildinfo", err)
+	}
+}
@@ -0,0 +1,114 @@
+// Package prometheustest provides fakes of the servers the prometheus
+// package talks to, for tests.
+package prometheustest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akasprzok/peat/internal/prometheus"
+)
+
+// Alertmanager is an httptest stand-in for the Alertmanager v2 API,
+// serving fixed alert groups and keeping silences in memory.
+type Alertmanager struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	groups   []prometheus.AlertGroup
+	silences []prometheus.Silence
+	requests []*http.Request
+}
+
+// NewAlertmanager starts a fake Alertmanager serving groups and silences.
+// Close it when done.
+func NewAlertmanager(groups []prometheus.AlertGroup, silences []prometheus.Silence) *Alertmanager {
+	f := &Alertmanager{groups: groups, silences: append([]prometheus.Silence(nil), silences...)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v2/alerts/groups", f.getGroups)
+	mux.HandleFunc("GET /api/v2/silences", f.getSilences)
+	mux.HandleFunc("POST /api/v2/silences", f.postSilence)
+	mux.HandleFunc("DELETE /api/v2/silence/{id}", f.deleteSilence)
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.requests = append(f.requests, r)
+		f.mu.Unlock()
+		mux.ServeHTTP(w, r)
+	}))
+	return f
+}
+
+// Silences returns the silences currently known to the fake.
+func (f *Alertmanager) Silences() []prometheus.Silence {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]prometheus.Silence(nil), f.silences...)
+}
+
+// Requests returns the requests received so far.
+func (f *Alertmanager) Requests() []*http.Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*http.Request(nil), f.requests...)
+}
+
+func (f *Alertmanager) getGroups(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, f.groups)
+}
+
+func (f *Alertmanager) getSilences(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, f.Silences())
+}
+
+func (f *Alertmanager) postSilence(w http.ResponseWriter, r *http.Request) {
+	var silence prometheus.Silence
+	if err := json.NewDecoder(r.Body).Decode(&silence); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	switch {
+	case len(silence.Matchers) == 0:
+		http.Error(w, "at least one matcher required", http.StatusBadRequest)
+		return
+	case strings.TrimSpace(silence.CreatedBy) == "" || strings.TrimSpace(silence.Comment) == "":
+		http.Error(w, "createdBy and comment are required", http.StatusBadRequest)
+		return
+	case !silence.EndsAt.After(silence.StartsAt):
+		http.Error(w, "end time must not be before start time", http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	silence.ID = fmt.Sprintf("fake%04d-0000-0000-0000-000000000000", len(f.silences)+1)
+	silence.Status.State = "active"
+	if silence.StartsAt.After(time.Now()) {
+		silence.Status.State = "pending"
+	}
+	f.silences = append(f.silences, silence)
+	writeJSON(w, map[string]string{"silenceID": silence.ID})
+}
+
+func (f *Alertmanager) deleteSilence(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, silence := range f.silences {
+		if silence.ID == r.PathValue("id") {
+			f.silences[i].Status.State = "expired"
+			f.silences[i].EndsAt = time.Now()
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	}
+	http.Error(w, "silence not found", http.StatusNotFound)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,221 @@
+package prometheus
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Exchange is a recorded API request and its response. Recordings are JSON
+// Lines files with one exchange per line. Request headers are not recorded,
+// so recordings contain no credentials.
+type Exchange struct {
+	Method      string     `json:"method"`
+	Path        string     `json:"path"` // Relative to the endpoint URL, e.g. /api/v1/query
+	Params      url.Values `json:"params,omitempty"`
+	Status      int        `json:"status"`
+	ContentType string     `json:"contentType,omitempty"`
+	Body        string     `json:"body"`
+}
+
+// ignoredParams are the request parameters ignored when matching a request
+// against a recording: timestamps, which change with the wall clock, and the
+// timeout, which changes with --timeout. Other parameters, such as the limit
+// of series queries, must match the recorded request.
+var ignoredParams = []string{"time", "start", "end", "timeout"}
+
+// key identifies the requests an exchange answers: the path and the
+// parameters other than ignoredParams. The method is ignored, as the client
+// may retry a POST as a GET.
+func (e Exchange) key() string {
+	params := url.Values{}
+	for name, values := range e.Params {
+		params[name] = values
+	}
+	for _, name := range ignoredParams {
+		params.Del(name)
+	}
+	return e.Path + "?" + params.Encode()
+}
+
+// newExchange builds the request side of an exchange. The request body, if
+// any, is read and replaced so the request can still be sent.
+func newExchange(req *http.Request, base *url.URL) (Exchange, error) {
+	params := url.Values{}
+	for name, values := range req.URL.Query() {
+		params[name] = values
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return Exchange{}, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		if form, err := url.ParseQuery(string(body)); err == nil {
+			for name, values := range form {
+				params[name] = append(params[name], values...)
+			}
+		}
+	}
+	if len(params) == 0 {
+		params = nil
+	}
+
+	return Exchange{
+		Method: req.Method,
+		Path:   strings.TrimPrefix(req.URL.Path, strings.TrimSuffix(base.Path, "/")),
+		Params: params,
+	}, nil
+}
+
+// Recorder writes the API traffic of the clients using it to a recording.
+// It is safe for concurrent use.
+type Recorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error // First write error, reported by Err
+}
+
+// NewRecorder returns a recorder writing exchanges to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{enc: json.NewEncoder(w)}
+}
+
+// Err returns the first error that occurred while writing the recording.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *Recorder) write(e Exchange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = r.enc.Encode(e)
+	}
+}
+
+// recordingRoundTripper records the requests sent through next and their responses.
+type recordingRoundTripper struct {
+	recorder *Recorder
+	base     *url.URL
+	next     http.RoundTripper
+}
+
+func (rt *recordingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers must not modify the caller's request.
+	req = req.Clone(req.Context())
+	exchange, err := newExchange(req, rt.base)
+	if err != nil {
+		return nil, fmt.Errorf("recording request: %w", err)
+	}
+
+	resp, err := rt.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	exchange.Status = resp.StatusCode
+	exchange.ContentType = resp.Header.Get("Content-Type")
+	exchange.Body = string(body)
+	rt.recorder.write(exchange)
+	return resp, nil
+}
+
+// Replay serves recorded responses without contacting a server. Requests
+// are matched by path and parameters, ignoring timestamps. Requests that
+// were recorded several times get the responses in recorded order, and the
+// last one once exhausted. It is safe for concurrent use.
+type Replay struct {
+	mu        sync.Mutex
+	exchanges map[string][]Exchange
+}
+
+// LoadReplay reads a recording written by a Recorder.
+func LoadReplay(r io.Reader) (*Replay, error) {
+	replay := &Replay{exchanges: make(map[string][]Exchange)}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64<<20) // Responses can be large
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var e Exchange
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("recording line %d: %w", line, err)
+		}
+		replay.exchanges[e.key()] = append(replay.exchanges[e.key()], e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading recording: %w", err)
+	}
+	return replay, nil
+}
+
+// next returns the recorded response for the request e.
+func (r *Replay) next(e Exchange) (Exchange, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	queue := r.exchanges[e.key()]
+	if len(queue) == 0 {
+		return Exchange{}, false
+	}
+	if len(queue) > 1 {
+		r.exchanges[e.key()] = queue[1:]
+	}
+	return queue[0], true
+}
+
+// replayRoundTripper answers requests from a replay.
+type replayRoundTripper struct {
+	replay *Replay
+	base   *url.URL
+}
+
+func (rt *replayRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	exchange, err := newExchange(req, rt.base)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	recorded, ok := rt.replay.next(exchange)
+	if !ok {
+		return nil, fmt.Errorf("replay: no recorded response for %s", exchange.key())
+	}
+
+	header := http.Header{}
+	if recorded.ContentType != "" {
+		header.Set("Content-Type", recorded.ContentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+		Close:         true,
+	}, nil
+}
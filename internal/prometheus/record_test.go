@@ -0,0 +1,106 @@
+package prometheus
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordReplay(t *testing.T) {
+	up := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/prometheus/api/v1/query":
+			up++
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{"job":"a"},"value":[1700000000,"` + strings.Repeat("1", up) + `"]}]}}`))
+		case "/prometheus/api/v1/labels":
+			_, _ = w.Write([]byte(`{"status":"success","data":["__name__","job"]}`))
+		case "/prometheus/api/v1/series":
+			_, _ = w.Write([]byte(`{"status":"success","data":[{"__name__":"up","job":"a"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	var recording bytes.Buffer
+	recorder := NewRecorder(&recording)
+	client, err := NewClient(Config{
+		URL:    srv.URL + "/prometheus",
+		Auth:   AuthConfig{BearerToken: "secret"},
+		Record: recorder,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	ctx := context.Background()
+	for range 2 {
+		if _, _, _, err := client.Query(ctx, "up", time.Now(), time.Second, false); err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+	}
+	if _, _, err := client.LabelNames(ctx, nil, time.Now().Add(-time.Hour), time.Now(), time.Second); err != nil {
+		t.Fatalf("LabelNames() error = %v", err)
+	}
+	if _, _, err := client.Series(ctx, []string{"up"}, time.Now().Add(-time.Hour), time.Now(), 100, time.Second); err != nil {
+		t.Fatalf("Series() error = %v", err)
+	}
+	if err := recorder.Err(); err != nil {
+		t.Fatalf("Recorder.Err() = %v", err)
+	}
+	if strings.Contains(recording.String(), "secret") {
+		t.Error("recording contains the bearer token")
+	}
+	if lines := strings.Count(recording.String(), "\n"); lines != 4 {
+		t.Errorf("recording has %d exchanges, want 4", lines)
+	}
+
+	replay, err := LoadReplay(&recording)
+	if err != nil {
+		t.Fatalf("LoadReplay() error = %v", err)
+	}
+	srv.Close() // Replayed clients don't need the server
+	client, err = NewClient(Config{URL: "http://replay.invalid", Replay: replay})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	// Timestamps and timeouts differ from the recording, and repeated requests
+	// get the responses in recorded order, then the last one
+	later := time.Now().Add(time.Hour)
+	for _, want := range []string{"1", "11", "11"} {
+		_, value, _, err := client.Query(ctx, "up", later, time.Minute, false)
+		if err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+		if !strings.Contains(value.String(), "=> "+want+" @") {
+			t.Errorf("Query() = %s, want value %s", value, want)
+		}
+	}
+	names, _, err := client.LabelNames(ctx, nil, later.Add(-time.Hour), later, time.Second)
+	if err != nil || len(names) != 2 {
+		t.Errorf("LabelNames() = %v, %v, want 2 names", names, err)
+	}
+	if series, _, err := client.Series(ctx, []string{"up"}, later.Add(-time.Hour), later, 100, time.Minute); err != nil || len(series) != 1 {
+		t.Errorf("Series() = %v, %v, want 1 series", series, err)
+	}
+	if _, _, err := client.Series(ctx, []string{"up"}, later.Add(-time.Hour), later, 10, time.Minute); err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Errorf("Series() with another limit error = %v, want no recorded response", err)
+	}
+
+	if _, _, _, err := client.Query(ctx, "down", later, time.Second, false); err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Errorf("Query() of an unrecorded query error = %v, want no recorded response", err)
+	}
+}
+
+func TestLoadReplayInvalid(t *testing.T) {
+	if _, err := LoadReplay(strings.NewReader("{\"path\":\"/api/v1/query\"}\nnot json\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("LoadReplay() error = %v, want an error on line 2", err)
+	}
+}
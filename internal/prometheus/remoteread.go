@@ -0,0 +1,127 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	config_util "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/storage/remote"
+	"github.com/prometheus/prometheus/util/annotations"
+)
+
+// remoteReadChunkLimit bounds the size of a streamed chunk frame, as
+// Prometheus' default remote read configuration does.
+const remoteReadChunkLimit = 50_000_000
+
+// NewRemoteReadClient returns a client evaluating queries locally over raw
+// series fetched from the remote-read endpoint at cfg.URL. Streamed chunks
+// are requested, falling back to samples for servers that don't support
+// them. Label queries need matchers, see seriesLabelsQuerier. Remote-read
+// traffic is binary and is neither recorded nor replayed.
+func NewRemoteReadClient(cfg Config) (Client, error) {
+	cfg.Record, cfg.Replay = nil, nil
+	roundTripper, err := cfg.roundTripper()
+	if err != nil {
+		return nil, err
+	}
+	endpoint, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing URL: %w", err)
+	}
+
+	readClient, err := remote.NewReadClient("peat", &remote.ClientConfig{
+		URL:              &config_util.URL{URL: endpoint},
+		Timeout:          model.Duration(time.Hour), // Queries are bounded by their context
+		ChunkedReadLimit: remoteReadChunkLimit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating remote-read client: %w", err)
+	}
+	// Use the transport applying the datasource's TLS and auth settings
+	client, ok := readClient.(*remote.Client)
+	if !ok {
+		return nil, fmt.Errorf("creating remote-read client: unexpected client type %T", readClient)
+	}
+	client.Client.Transport = roundTripper
+
+	queryable := remote.NewSampleAndChunkQueryableClient(readClient, labels.EmptyLabels(), nil, true, nil)
+	return newLocalClient(remoteReadQueryable{queryable}, map[string][]v1.Metadata{}, "remote-read"), nil
+}
+
+// remoteReadQueryable answers label name and value queries, which the
+// remote-read protocol has no request for, from the labels of the selected
+// series.
+type remoteReadQueryable struct {
+	storage.Queryable
+}
+
+func (q remoteReadQueryable) Querier(mint, maxt int64) (storage.Querier, error) {
+	querier, err := q.Queryable.Querier(mint, maxt)
+	if err != nil {
+		return nil, err
+	}
+	return &seriesLabelsQuerier{Querier: querier, mint: mint, maxt: maxt}, nil
+}
+
+// seriesLabelsQuerier implements the label queries of a querier by selecting
+// the matching series. Queries without matchers are refused rather than
+// streaming every series of the store.
+type seriesLabelsQuerier struct {
+	storage.Querier
+	mint, maxt int64
+}
+
+// errNoMatchers is returned by label queries without matchers.
+var errNoMatchers = errors.New("remote read: label queries need a series selector")
+
+// selectLabels calls f with the labels of every series matching matchers.
+func (q *seriesLabelsQuerier) selectLabels(ctx context.Context, matchers []*labels.Matcher, f func(labels.Labels)) (annotations.Annotations, error) {
+	if len(matchers) == 0 {
+		return nil, errNoMatchers
+	}
+	hints := &storage.SelectHints{Start: q.mint, End: q.maxt, Func: "series"}
+	set := q.Select(ctx, false, hints, matchers...)
+	for set.Next() {
+		f(set.At().Labels())
+	}
+	if err := set.Err(); err != nil {
+		return nil, err
+	}
+	return set.Warnings(), nil
+}
+
+func (q *seriesLabelsQuerier) LabelValues(ctx context.Context, name string, _ *storage.LabelHints, matchers ...*labels.Matcher) ([]string, annotations.Annotations, error) {
+	var values []string
+	warnings, err := q.selectLabels(ctx, matchers, func(lset labels.Labels) {
+		if value := lset.Get(name); value != "" {
+			values = append(values, value)
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	slices.Sort(values)
+	return slices.Compact(values), warnings, nil
+}
+
+func (q *seriesLabelsQuerier) LabelNames(ctx context.Context, _ *storage.LabelHints, matchers ...*labels.Matcher) ([]string, annotations.Annotations, error) {
+	var names []string
+	warnings, err := q.selectLabels(ctx, matchers, func(lset labels.Labels) {
+		lset.Range(func(l labels.Label) {
+			names = append(names, l.Name)
+		})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	slices.Sort(names)
+	return slices.Compact(names), warnings, nil
+}
@@ -0,0 +1,122 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	promconfig "github.com/prometheus/prometheus/config"
+	"github.com/prometheus/prometheus/storage/remote"
+	"github.com/prometheus/prometheus/tsdb"
+)
+
+// newRemoteReadStub serves the series written by writeTSDB over remote read,
+// requiring the bearer token "secret".
+func newRemoteReadStub(t *testing.T, end time.Time) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+	db, err := tsdb.Open(writeTSDB(t, end), slog.New(slog.DiscardHandler), nil, tsdb.DefaultOptions(), nil)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	handler := remote.NewReadHandler(slog.New(slog.DiscardHandler), nil, db,
+		func() promconfig.Config { return promconfig.Config{} }, 0, 1, 1<<20)
+	var streamed atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+		if strings.Contains(w.Header().Get("Content-Type"), "streamed") {
+			streamed.Add(1)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &streamed
+}
+
+func TestRemoteReadClient(t *testing.T) {
+	end := time.UnixMilli(1700000000000)
+	server, streamed := newRemoteReadStub(t, end)
+
+	client, err := NewRemoteReadClient(Config{URL: server.URL, Auth: AuthConfig{BearerToken: "secret"}})
+	if err != nil {
+		t.Fatalf("NewRemoteReadClient: %v", err)
+	}
+	ctx := context.Background()
+
+	t.Run("range query", func(t *testing.T) {
+		matrix, _, _, err := client.QueryRange(ctx, `sum by (job) (up)`, end.Add(-3*time.Hour), end, 30*time.Minute, time.Minute, false)
+		if err != nil {
+			t.Fatalf("QueryRange: %v", err)
+		}
+		if len(matrix) != 2 {
+			t.Fatalf("matrix = %v, want app and node", matrix)
+		}
+		node := matrix[1]
+		if node.Metric["job"] != "node" || node.Values[0].Value != 1 {
+			t.Errorf("node series = %v, want 1 at the start", node)
+		}
+		if streamed.Load() == 0 {
+			t.Error("no streamed chunks response served")
+		}
+	})
+
+	t.Run("instant query", func(t *testing.T) {
+		_, value, _, err := client.Query(ctx, `count(up == 0)`, end, time.Minute, false)
+		if err != nil {
+			t.Fatalf("Query: %v", err)
+		}
+		if vector, ok := value.(model.Vector); !ok || len(vector) != 1 || vector[0].Value != 2 {
+			t.Errorf("value = %v, want 2", value)
+		}
+	})
+
+	t.Run("series and labels", func(t *testing.T) {
+		series, _, err := client.Series(ctx, []string{`up{job="app"}`}, end.Add(-time.Hour), end, 0, time.Minute)
+		if err != nil {
+			t.Fatalf("Series: %v", err)
+		}
+		if len(series) != 1 || series[0]["job"] != "app" {
+			t.Errorf("series = %v, want up{job=\"app\"}", series)
+		}
+
+		if _, _, err := client.LabelNames(ctx, nil, end.Add(-time.Hour), end, time.Minute); !errors.Is(err, errNoMatchers) {
+			t.Errorf("LabelNames without matchers error = %v, want %v", err, errNoMatchers)
+		}
+		names, _, err := client.LabelNames(ctx, []string{`up`}, end.Add(-time.Hour), end, time.Minute)
+		if err != nil {
+			t.Fatalf("LabelNames: %v", err)
+		}
+		if strings.Join(names, ",") != "__name__,job" {
+			t.Errorf("names = %v, want [__name__ job]", names)
+		}
+
+		values, _, err := client.LabelValues(ctx, "job", []string{`up`}, end.Add(-3*time.Hour), end.Add(-2*time.Hour), time.Minute)
+		if err != nil {
+			t.Fatalf("LabelValues: %v", err)
+		}
+		if len(values) != 1 || values[0] != "node" {
+			t.Errorf("values = %v, want only the job with older samples", values)
+		}
+	})
+
+	t.Run("auth", func(t *testing.T) {
+		client, err := NewRemoteReadClient(Config{URL: server.URL})
+		if err != nil {
+			t.Fatalf("NewRemoteReadClient: %v", err)
+		}
+		if _, _, _, err := client.Query(ctx, `up`, end, time.Minute, false); err == nil || !strings.Contains(err.Error(), "401") {
+			t.Errorf("error = %v, want a 401", err)
+		}
+	})
+}
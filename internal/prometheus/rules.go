@@ -0,0 +1,39 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"time"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+)
+
+// RulesResult is the result of the rules endpoint.
+type RulesResult struct {
+	Groups []RuleGroup `json:"groups"`
+}
+
+// RuleGroup is a rule group with the evaluation statistics the server reports
+// for it, which v1.RuleGroup drops when decoding.
+type RuleGroup struct {
+	v1.RuleGroup
+
+	// EvaluationTime is how long the last evaluation of the whole group took,
+	// which isn't the sum of its rules' when they are evaluated concurrently.
+	EvaluationTime Seconds
+	LastEvaluation time.Time
+}
+
+// UnmarshalJSON decodes the group with its rules, as v1.RuleGroup does, and
+// its evaluation statistics.
+func (g *RuleGroup) UnmarshalJSON(b []byte) error {
+	var stats struct {
+		EvaluationTime Seconds   `json:"evaluationTime"`
+		LastEvaluation time.Time `json:"lastEvaluation"`
+	}
+	if err := json.Unmarshal(b, &stats); err != nil {
+		return err
+	}
+	g.EvaluationTime = stats.EvaluationTime
+	g.LastEvaluation = stats.LastEvaluation
+	return json.Unmarshal(b, &g.RuleGroup)
+}
@@ -0,0 +1,101 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// QueryStats are the statistics of a query evaluation, reported by servers
+// that support the stats=all query parameter when they are asked for. Clients
+// return nil stats for servers that don't.
+type QueryStats struct {
+	Timings QueryTimings `json:"timings"`
+	Samples QuerySamples `json:"samples"`
+}
+
+// QueryTimings are the times the server spent in each phase of a query.
+type QueryTimings struct {
+	EvalTotalTime        Seconds `json:"evalTotalTime"`
+	ResultSortTime       Seconds `json:"resultSortTime"`
+	QueryPreparationTime Seconds `json:"queryPreparationTime"`
+	InnerEvalTime        Seconds `json:"innerEvalTime"`
+	ExecQueueTime        Seconds `json:"execQueueTime"`
+	ExecTotalTime        Seconds `json:"execTotalTime"`
+}
+
+// QuerySamples are the number of samples a query loaded.
+type QuerySamples struct {
+	TotalQueryableSamplesPerStep []StepSamples `json:"totalQueryableSamplesPerStep"`
+	TotalQueryableSamples        int64         `json:"totalQueryableSamples"`
+	PeakSamples                  int64         `json:"peakSamples"`
+}
+
+// StepSamples is the number of samples loaded to evaluate a single step.
+type StepSamples struct {
+	Time    time.Time
+	Samples int64
+}
+
+// UnmarshalJSON decodes a [<unix seconds>, <samples>] pair.
+func (s *StepSamples) UnmarshalJSON(b []byte) error {
+	var pair [2]float64
+	if err := json.Unmarshal(b, &pair); err != nil {
+		return fmt.Errorf("decoding step samples: %w", err)
+	}
+	s.Time = time.UnixMilli(int64(pair[0] * 1000))
+	s.Samples = int64(pair[1])
+	return nil
+}
+
+// Seconds is a duration reported in (fractional) seconds.
+type Seconds float64
+
+// Duration converts s to a time.Duration.
+func (s Seconds) Duration() time.Duration {
+	return time.Duration(float64(s) * float64(time.Second))
+}
+
+// queryData is the data of query and range query responses: the result,
+// decoded as the v1 API does, and the stats the v1 API drops.
+type queryData struct {
+	Result model.Value
+	Stats  *QueryStats
+}
+
+func (d *queryData) UnmarshalJSON(b []byte) error {
+	var data struct {
+		ResultType model.ValueType `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+		Stats      *QueryStats     `json:"stats"`
+	}
+	if err := json.Unmarshal(b, &data); err != nil {
+		return err
+	}
+	d.Stats = data.Stats
+
+	var err error
+	switch data.ResultType {
+	case model.ValScalar:
+		var scalar model.Scalar
+		err = json.Unmarshal(data.Result, &scalar)
+		d.Result = &scalar
+	case model.ValString:
+		var str model.String
+		err = json.Unmarshal(data.Result, &str)
+		d.Result = &str
+	case model.ValVector:
+		var vector model.Vector
+		err = json.Unmarshal(data.Result, &vector)
+		d.Result = vector
+	case model.ValMatrix:
+		var matrix model.Matrix
+		err = json.Unmarshal(data.Result, &matrix)
+		d.Result = matrix
+	default:
+		err = fmt.Errorf("unexpected result type %q", data.ResultType)
+	}
+	return err
+}
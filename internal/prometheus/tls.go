@@ -0,0 +1,86 @@
+package prometheus
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/prometheus/client_golang/api"
+)
+
+// TLSConfig holds the TLS settings used to connect to the endpoint.
+type TLSConfig struct {
+	// CAFile is a PEM bundle used instead of the system roots to verify the server.
+	CAFile string
+	// CertFile and KeyFile are the PEM client certificate and key for mutual TLS.
+	CertFile string
+	KeyFile  string
+	// ServerName overrides the host name used to verify the server certificate.
+	ServerName string
+	// InsecureSkipVerify disables server certificate verification.
+	InsecureSkipVerify bool
+}
+
+func (t TLSConfig) isZero() bool {
+	return t == TLSConfig{}
+}
+
+// Validate reports incomplete TLS settings.
+func (t TLSConfig) Validate() error {
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return errors.New("TLS client certificate and key must be set together")
+	}
+	return nil
+}
+
+// build returns the crypto/tls configuration described by t.
+func (t TLSConfig) build() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify, //nolint:gosec // explicitly requested by the user
+	}
+
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", t.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+// newTransport returns a copy of the default transport configured with t.
+func newTransport(t TLSConfig) (http.RoundTripper, error) {
+	if t.isZero() {
+		return api.DefaultRoundTripper, nil
+	}
+	tlsConfig, err := t.build()
+	if err != nil {
+		return nil, err
+	}
+	defaultTransport, ok := api.DefaultRoundTripper.(*http.Transport)
+	if !ok {
+		return nil, errors.New("unexpected default round tripper type")
+	}
+	transport := defaultTransport.Clone()
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
@@ -0,0 +1,173 @@
+package prometheus
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTLSServer(t *testing.T, clientCAs *x509.CertPool) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":[]}`))
+	}))
+	if clientCAs != nil {
+		srv.TLS = &tls.Config{
+			ClientAuth: tls.RequireAndVerifyClientCert,
+			ClientCAs:  clientCAs,
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// writeServerCA writes the test server's certificate as a PEM CA bundle.
+func writeServerCA(t *testing.T, srv *httptest.Server) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeClientCert generates a self-signed client certificate and returns the
+// certificate path, key path and a pool trusting it.
+func writeClientCert(t *testing.T) (certFile, keyFile string, pool *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "peat-test-client"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "client.pem")
+	keyFile = filepath.Join(dir, "client-key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	pool = x509.NewCertPool()
+	pool.AddCert(cert)
+	return certFile, keyFile, pool
+}
+
+func labelNames(client Client) error {
+	_, _, err := client.LabelNames(context.Background(), nil, time.Now().Add(-time.Hour), time.Now(), 5*time.Second)
+	return err
+}
+
+func TestTLSConfig(t *testing.T) {
+	srv := newTLSServer(t, nil)
+	caFile := writeServerCA(t, srv)
+
+	tests := []struct {
+		name    string
+		tls     TLSConfig
+		wantErr bool
+	}{
+		{"default roots reject private CA", TLSConfig{}, true},
+		{"CA file", TLSConfig{CAFile: caFile}, false},
+		{"CA file with server name override", TLSConfig{CAFile: caFile, ServerName: "example.com"}, false},
+		{"CA file with wrong server name", TLSConfig{CAFile: caFile, ServerName: "prometheus.invalid"}, true},
+		{"insecure skip verify", TLSConfig{InsecureSkipVerify: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(Config{URL: srv.URL, TLS: tt.tls})
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			err = labelNames(client)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LabelNames() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	certFile, keyFile, clientCAs := writeClientCert(t)
+	srv := newTLSServer(t, clientCAs)
+	caFile := writeServerCA(t, srv)
+
+	t.Run("without client certificate", func(t *testing.T) {
+		client, err := NewClient(Config{URL: srv.URL, TLS: TLSConfig{CAFile: caFile}})
+		if err != nil {
+			t.Fatalf("NewClient() error = %v", err)
+		}
+		if err := labelNames(client); err == nil {
+			t.Error("LabelNames() succeeded without a client certificate")
+		}
+	})
+
+	t.Run("with client certificate", func(t *testing.T) {
+		client, err := NewClient(Config{URL: srv.URL, TLS: TLSConfig{CAFile: caFile, CertFile: certFile, KeyFile: keyFile}})
+		if err != nil {
+			t.Fatalf("NewClient() error = %v", err)
+		}
+		if err := labelNames(client); err != nil {
+			t.Errorf("LabelNames() error = %v", err)
+		}
+	})
+}
+
+func TestTLSConfigErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		tls  TLSConfig
+	}{
+		{"cert without key", TLSConfig{CertFile: "client.pem"}},
+		{"missing CA file", TLSConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewClient(Config{URL: "https://localhost:9090", TLS: tt.tls}); err == nil {
+				t.Error("NewClient() error = nil, want error")
+			}
+		})
+	}
+}
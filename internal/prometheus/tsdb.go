@@ -0,0 +1,90 @@
+package prometheus
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+)
+
+// NewTSDBClient returns a client evaluating queries locally over the blocks
+// and write-ahead log of a Prometheus data directory, opened read-only.
+// While open, the TSDB keeps a sandbox directory in the system's temporary
+// directory, so nothing is written to dir; Close the client to remove it.
+func NewTSDBClient(dir string) (Client, error) {
+	sandbox, err := os.MkdirTemp("", "peat-tsdb-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating TSDB sandbox: %w", err)
+	}
+	db, err := tsdb.OpenDBReadOnly(dir, sandbox, nil)
+	if err != nil {
+		_ = os.RemoveAll(sandbox)
+		return nil, err
+	}
+
+	// Fail early on directories that aren't a TSDB, and on corrupted blocks
+	blocks, err := db.Blocks()
+	if err == nil && len(blocks) == 0 {
+		if _, statErr := os.Stat(filepath.Join(dir, "wal")); statErr != nil {
+			err = fmt.Errorf("no TSDB blocks or WAL in %s", dir)
+		}
+	}
+	if err != nil {
+		_ = db.Close()
+		_ = os.RemoveAll(sandbox)
+		return nil, err
+	}
+
+	return newLocalClient(&tsdbQueryable{db: db, sandbox: sandbox}, map[string][]v1.Metadata{}, "tsdb"), nil
+}
+
+// tsdbQueryable serializes access to a read-only TSDB, which supports only
+// one querier at a time. Each querier holds the lock until it is closed.
+type tsdbQueryable struct {
+	mu      sync.Mutex
+	db      *tsdb.DBReadOnly
+	sandbox string // Temporary directory the TSDB links the head's chunks into
+}
+
+// Querier loads the blocks, and the WAL when the range isn't covered by the
+// blocks, and returns a querier over them.
+func (q *tsdbQueryable) Querier(mint, maxt int64) (storage.Querier, error) {
+	q.mu.Lock()
+	querier, err := q.db.Querier(mint, maxt)
+	if err != nil {
+		q.mu.Unlock()
+		// Chunks are hard linked, which fails across filesystems
+		if errors.Is(err, syscall.EXDEV) {
+			err = fmt.Errorf("%w: set TMPDIR to a directory on the data directory's filesystem", err)
+		}
+		return nil, err
+	}
+	return &tsdbQuerier{Querier: querier, unlock: q.mu.Unlock}, nil
+}
+
+// Close closes the TSDB and removes its sandbox directory.
+func (q *tsdbQueryable) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	err := q.db.Close()
+	return errors.Join(err, os.RemoveAll(q.sandbox))
+}
+
+// tsdbQuerier releases the lock of its tsdbQueryable when closed.
+type tsdbQuerier struct {
+	storage.Querier
+	once   sync.Once
+	unlock func()
+}
+
+func (q *tsdbQuerier) Close() error {
+	err := q.Querier.Close()
+	q.once.Do(q.unlock)
+	return err
+}
@@ -0,0 +1,158 @@
+package prometheus
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+)
+
+// writeTSDB creates a data directory with a block of samples before end-2h
+// and samples after it in the WAL, as left behind by a stopped Prometheus.
+func writeTSDB(t *testing.T, end time.Time) string {
+	t.Helper()
+	dir := t.TempDir()
+	logger := slog.New(slog.DiscardHandler)
+
+	var old []chunks.Sample
+	for ts := end.Add(-3 * time.Hour); ts.Before(end.Add(-2 * time.Hour)); ts = ts.Add(time.Minute) {
+		old = append(old, fileSample{t: ts.UnixMilli(), f: 1})
+	}
+	series := []storage.Series{
+		storage.NewListSeries(labels.FromStrings("__name__", "up", "job", "node"), old),
+	}
+	if _, err := tsdb.CreateBlock(series, dir, 0, logger); err != nil {
+		t.Fatalf("CreateBlock: %v", err)
+	}
+
+	db, err := tsdb.Open(dir, logger, nil, tsdb.DefaultOptions(), nil)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	app := db.Appender(context.Background())
+	for _, job := range []string{"node", "app"} {
+		lset := labels.FromStrings("__name__", "up", "job", job)
+		for ts := end.Add(-10 * time.Minute); !ts.After(end); ts = ts.Add(time.Minute) {
+			if _, err := app.Append(0, lset, ts.UnixMilli(), 0); err != nil {
+				t.Fatalf("Append: %v", err)
+			}
+		}
+	}
+	if err := app.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return dir
+}
+
+func TestTSDBClient(t *testing.T) {
+	end := time.UnixMilli(1700000000000)
+	dir := writeTSDB(t, end)
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	client, err := NewTSDBClient(dir)
+	if err != nil {
+		t.Fatalf("NewTSDBClient: %v", err)
+	}
+	ctx := context.Background()
+
+	t.Run("blocks and WAL", func(t *testing.T) {
+		matrix, _, _, err := client.QueryRange(ctx, `sum(up)`, end.Add(-3*time.Hour), end, 30*time.Minute, time.Minute, false)
+		if err != nil {
+			t.Fatalf("QueryRange: %v", err)
+		}
+		if len(matrix) != 1 {
+			t.Fatalf("matrix = %v, want one series", matrix)
+		}
+		values := matrix[0].Values
+		if first := values[0]; first.Value != 1 {
+			t.Errorf("first value = %v, want 1 from the block", first)
+		}
+		if last := values[len(values)-1]; last.Value != 0 || !last.Timestamp.Time().Equal(end) {
+			t.Errorf("last value = %v, want 0 from the WAL", last)
+		}
+	})
+
+	t.Run("concurrent queries", func(t *testing.T) {
+		var wg sync.WaitGroup
+		errs := make([]error, 4)
+		for i := range errs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _, _, errs[i] = client.Query(ctx, `count(up)`, end, time.Minute, false)
+			}()
+		}
+		wg.Wait()
+		for _, err := range errs {
+			if err != nil {
+				t.Errorf("Query: %v", err)
+			}
+		}
+	})
+
+	t.Run("series and labels", func(t *testing.T) {
+		series, _, err := client.Series(ctx, []string{`up`}, end.Add(-time.Hour), end, 0, time.Minute)
+		if err != nil {
+			t.Fatalf("Series: %v", err)
+		}
+		if len(series) != 2 || series[0]["job"] != "app" {
+			t.Errorf("series = %v, want up for app and node", series)
+		}
+
+		values, _, err := client.LabelValues(ctx, "job", nil, end.Add(-3*time.Hour), end.Add(-2*time.Hour), time.Minute)
+		if err != nil {
+			t.Fatalf("LabelValues: %v", err)
+		}
+		if len(values) != 1 || values[0] != "node" {
+			t.Errorf("values = %v, want only the job in the block", values)
+		}
+	})
+
+	if sandboxes, _ := filepath.Glob(filepath.Join(dir, "tmp_dbro_sandbox*")); len(sandboxes) > 0 {
+		t.Errorf("sandbox directories in the data directory: %v", sandboxes)
+	}
+	if sandboxes, _ := filepath.Glob(filepath.Join(tmp, "peat-tsdb-*")); len(sandboxes) != 1 {
+		t.Errorf("sandbox directories = %v, want one in TMPDIR", sandboxes)
+	}
+
+	if err := client.(io.Closer).Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if sandboxes, _ := filepath.Glob(filepath.Join(tmp, "peat-tsdb-*")); len(sandboxes) > 0 {
+		t.Errorf("sandbox directories left behind: %v", sandboxes)
+	}
+	if _, _, _, err := client.Query(ctx, "up", end, time.Minute, false); err == nil {
+		t.Error("Query after Close succeeded, want an error")
+	}
+}
+
+func TestTSDBClientNotATSDB(t *testing.T) {
+	dir := t.TempDir()
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	if _, err := NewTSDBClient(dir); err == nil {
+		t.Error("NewTSDBClient on an empty directory succeeded, want an error")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) > 0 {
+		t.Errorf("directory not left empty: %v", entries)
+	}
+	if entries, _ := os.ReadDir(tmp); len(entries) > 0 {
+		t.Errorf("sandbox directories left behind: %v", entries)
+	}
+	if _, err := NewTSDBClient(filepath.Join(dir, "missing")); err == nil {
+		t.Error("NewTSDBClient on a missing directory succeeded, want an error")
+	}
+}
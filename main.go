@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/akasprzok/peat/internal/commands"
@@ -14,6 +15,7 @@ func main() {
 		kong.Description("Terminal-native Prometheus metrics viewer with interactive visualizations."),
 	)
 	if err := cli.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "peat: %v\n", err)
 		os.Exit(1)
 	}
 }